@@ -117,7 +117,7 @@ func NewThorchainApp(logger log.Logger, db dbm.DB, traceStore io.Writer, baseApp
 	app.feeCollectionKeeper = auth.NewFeeCollectionKeeper(app.cdc, app.keyFeeCollection)
 	app.slashingKeeper = slashing.NewKeeper(app.cdc, app.keySlashing, app.stakeKeeper, app.paramsKeeper.Getter(), app.RegisterCodespace(slashing.DefaultCodespace))
 	app.clpKeeper = clp.NewKeeper(app.keyCLP, app.baseCoinTicker, app.coinKeeper, app.RegisterCodespace(clp.DefaultCodespace))
-	app.exchangeKeeper = exchange.NewKeeper(app.keyExchange, app.coinKeeper, app.RegisterCodespace(exchange.DefaultCodespace))
+	app.exchangeKeeper = exchange.NewKeeper(app.keyExchange, app.coinKeeper, app.feeCollectionKeeper, app.govKeeper, app.paramsKeeper.Setter(), app.RegisterCodespace(exchange.DefaultCodespace))
 
 	// register message routes
 	app.Router().
@@ -174,7 +174,7 @@ func MakeCodec() *wire.Codec {
 // by the application.
 func (app *ThorchainApp) BeginBlocker(ctx sdk.Context, req abci.RequestBeginBlock) abci.ResponseBeginBlock {
 	tags := slashing.BeginBlocker(ctx, req, app.slashingKeeper)
-	exchange.BeginBlocker(ctx, app.exchangeKeeper)
+	tags = tags.AppendTags(exchange.BeginBlocker(ctx, app.exchangeKeeper))
 
 	return abci.ResponseBeginBlock{
 		Tags: tags.ToKVPairs(),
@@ -185,6 +185,7 @@ func (app *ThorchainApp) BeginBlocker(ctx sdk.Context, req abci.RequestBeginBloc
 // application.
 func (app *ThorchainApp) EndBlocker(ctx sdk.Context, req abci.RequestEndBlock) abci.ResponseEndBlock {
 	tags := gov.EndBlocker(ctx, app.govKeeper)
+	tags = tags.AppendTags(exchange.EndBlocker(ctx, app.exchangeKeeper))
 	validatorUpdates := stake.EndBlocker(ctx, app.stakeKeeper)
 	// Add these new validators to the addr -> pubkey map.
 	app.slashingKeeper.AddValidators(ctx, validatorUpdates)
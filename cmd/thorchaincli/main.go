@@ -167,10 +167,27 @@ func main() {
 	exchangeCmd.AddCommand(
 		client.PostCommands(
 			exchangecmd.GetCmdLimitOrderCreate(cdc),
+			exchangecmd.GetCmdLimitOrderCancel(cdc),
+			exchangecmd.GetCmdLimitOrderReplace(cdc),
+			exchangecmd.GetCmdBatchOrders(cdc),
+			exchangecmd.GetCmdTrailingStopCreate(cdc),
+			exchangecmd.GetCmdTrailingStopCancel(cdc),
+			exchangecmd.GetCmdSetMarketMode(cdc),
+			exchangecmd.GetCmdSetMarketConfig(cdc),
+			exchangecmd.GetCmdSetMarketHalt(cdc),
+			exchangecmd.GetCmdListMarket(cdc),
+			exchangecmd.GetCmdDelistMarket(cdc),
 		)...)
 	exchangeCmd.AddCommand(
 		client.GetCommands(
 			exchangecmd.GetCmdQueryOrderbook("exchange", cdc),
+			exchangecmd.GetCmdQueryDepth("exchange", cdc),
+			exchangecmd.GetCmdQueryTrades("exchange", cdc),
+			exchangecmd.GetCmdQueryCandles("exchange", cdc),
+			exchangecmd.GetCmdQueryMyOrders("exchange", cdc),
+			exchangecmd.GetCmdQueryMyTrailingStops("exchange", cdc),
+			exchangecmd.GetCmdQueryMarkets("exchange", cdc),
+			exchangecmd.GetCmdQueryEscrow("exchange", cdc),
 		)...)
 	rootCmd.AddCommand(
 		exchangeCmd,
@@ -420,22 +420,24 @@ func (sp *Spammer) makeRandomLimitOrderMsg(spamCtx *spamCtx) (sdk.Msg, sdk.Coins
 
 	var kind exchange.OrderKind
 	var amt sdk.Coin
-	var price sdk.Coin
+	var price exchange.Price
 	var coinsUsed sdk.Coin
 
 	if buy {
 		kind = exchange.BuyOrder
-		price = sdk.NewInt64Coin(priceDenom, rand.Int63n(totalPriceIfBuy.Amount.Int64())+1) // TODO use market prices
-		amt = sdk.NewCoin(amtDenom, totalPriceIfBuy.Amount.Div(price.Amount))
+		priceAmt := rand.Int63n(totalPriceIfBuy.Amount.Int64()) + 1 // TODO use market prices
+		price = exchange.NewInt64Price(priceDenom, priceAmt)
+		amt = sdk.NewCoin(amtDenom, totalPriceIfBuy.Amount.Div(sdk.NewInt(priceAmt)))
 		coinsUsed = totalPriceIfBuy
 	} else {
 		kind = exchange.SellOrder
-		price = sdk.NewInt64Coin(priceDenom, rand.Int63n(amtIfSell.Amount.Int64())+1)
+		price = exchange.NewInt64Price(priceDenom, rand.Int63n(amtIfSell.Amount.Int64())+1)
 		amt = amtIfSell
 		coinsUsed = amtIfSell
 	}
 
-	msg := exchange.NewMsgCreateLimitOrder(sp.accountAddress, kind, amt, price, time.Now().Add(24*time.Hour))
+	msg := exchange.NewMsgCreateLimitOrder(sp.accountAddress, kind, amt, price, time.Now().Add(24*time.Hour), 0,
+		exchange.GoodTillTime, exchange.MarketDefaultSTP, sdk.ZeroInt(), "", "")
 
 	log.Log.Debugf("Spammer %v: Will create limit order, buy? %v with amt %v and price %v\\n", sp.index, buy, amt,
 		price)
@@ -7,7 +7,10 @@ import (
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/cosmos/cosmos-sdk/x/auth"
 	bank "github.com/cosmos/cosmos-sdk/x/bank"
+	"github.com/cosmos/cosmos-sdk/x/gov"
 	"github.com/cosmos/cosmos-sdk/x/mock"
+	"github.com/cosmos/cosmos-sdk/x/params"
+	"github.com/cosmos/cosmos-sdk/x/stake"
 	"github.com/stretchr/testify/require"
 	abci "github.com/tendermint/tendermint/abci/types"
 	"github.com/tendermint/tendermint/crypto/ed25519"
@@ -25,35 +28,35 @@ var (
 		Sender:    addr1,
 		Kind:      SellOrder,
 		Amount:    sdk.NewInt64Coin("ETH", 70),
-		Price:     sdk.NewInt64Coin("RUNE", 5),
+		Price:     NewInt64Price("RUNE", 5),
 		ExpiresAt: time.Now().Add(time.Minute),
 	}
 	buyLimOrder1 = MsgCreateLimitOrder{
 		Sender:    addr2,
 		Kind:      BuyOrder,
 		Amount:    sdk.NewInt64Coin("ETH", 90),
-		Price:     sdk.NewInt64Coin("RUNE", 4),
+		Price:     NewInt64Price("RUNE", 4),
 		ExpiresAt: time.Now().Add(time.Minute),
 	}
 	buyLimOrder2 = MsgCreateLimitOrder{
 		Sender:    addr2,
 		Kind:      BuyOrder,
 		Amount:    sdk.NewInt64Coin("ETH", 50),
-		Price:     sdk.NewInt64Coin("RUNE", 8),
+		Price:     NewInt64Price("RUNE", 8),
 		ExpiresAt: time.Now().Add(time.Minute),
 	}
 	buyLimOrder3 = MsgCreateLimitOrder{
 		Sender:    addr2,
 		Kind:      BuyOrder,
 		Amount:    sdk.NewInt64Coin("ETH", 50000),
-		Price:     sdk.NewInt64Coin("RUNE", 800),
+		Price:     NewInt64Price("RUNE", 800),
 		ExpiresAt: time.Now().Add(time.Minute),
 	}
 	buyLimOrder4 = MsgCreateLimitOrder{
 		Sender:    addr2,
 		Kind:      BuyOrder,
 		Amount:    sdk.NewInt64Coin("ETH", 40),
-		Price:     sdk.NewInt64Coin("RUNE", 8),
+		Price:     NewInt64Price("RUNE", 8),
 		ExpiresAt: time.Now().Add(-time.Minute),
 	}
 )
@@ -65,12 +68,19 @@ func getMockApp(t *testing.T) *mock.App {
 	RegisterWire(app.Cdc)
 	exchangeKey := sdk.NewKVStoreKey("exchangeTestAppKey")
 	bankKeeper := bank.NewKeeper(app.AccountMapper)
-	exchangeKeeper := NewKeeper(exchangeKey, bankKeeper, app.RegisterCodespace(DefaultCodespace))
+	paramsKey := sdk.NewKVStoreKey("paramsTestAppKey")
+	paramsKeeper := params.NewKeeper(app.Cdc, paramsKey)
+	stakeKey := sdk.NewKVStoreKey("stakeTestAppKey")
+	stakeKeeper := stake.NewKeeper(app.Cdc, stakeKey, bankKeeper, stake.DefaultCodespace)
+	govKey := sdk.NewKVStoreKey("govTestAppKey")
+	govKeeper := gov.NewKeeper(app.Cdc, govKey, paramsKeeper.Setter(), bankKeeper, stakeKeeper, gov.DefaultCodespace)
+	exchangeKeeper := NewKeeper(exchangeKey, bankKeeper, app.FeeCollectionKeeper, govKeeper, paramsKeeper.Setter(),
+		app.RegisterCodespace(DefaultCodespace))
 	app.Router().AddRoute("exchange", NewHandler(exchangeKeeper))
 
 	app.SetInitChainer(getInitChainer(app, exchangeKeeper, bankKeeper))
 
-	require.NoError(t, app.CompleteSetup([]*sdk.KVStoreKey{exchangeKey}))
+	require.NoError(t, app.CompleteSetup([]*sdk.KVStoreKey{exchangeKey, paramsKey, stakeKey, govKey}))
 	return app
 }
 
@@ -79,7 +89,7 @@ func getInitChainer(mapp *mock.App, exchangeKeeper Keeper, bankKeeper bank.Keepe
 	return func(ctx sdk.Context, req abci.RequestInitChain) abci.ResponseInitChain {
 		mapp.InitChainer(ctx, req)
 
-		InitGenesis(ctx, exchangeKeeper, DefaultGenesisState())
+		InitGenesis(ctx, exchangeKeeper, testGenesisState())
 
 		return abci.ResponseInitChain{}
 	}
@@ -1,10 +1,24 @@
 package exchange
 
 import (
+	"encoding/json"
+
 	sdk "github.com/cosmos/cosmos-sdk/types"
 )
 
-// slashing begin block functionality
-func BeginBlocker(ctx sdk.Context, k Keeper) {
+// BeginBlocker removes the expired orders and triggers the trailing stops that the trades of the last block have
+// reached, the triggered stops are returned as tags
+func BeginBlocker(ctx sdk.Context, k Keeper) sdk.Tags {
 	k.refundExpiredLimitOrders(ctx)
+
+	tags := sdk.NewTags()
+	for _, triggered := range k.processTrailingStops(ctx) {
+		b, err := json.Marshal(triggered)
+		if err != nil {
+			panic(err)
+		}
+		tags = tags.AppendTag("trailing_stop_triggered", b)
+	}
+
+	return tags
 }
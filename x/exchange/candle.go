@@ -0,0 +1,140 @@
+package exchange
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/wire"
+)
+
+// CandleInterval is the time span a candle aggregates trades for
+type CandleInterval string
+
+const (
+	// OneMinute candles aggregate the trades of one minute
+	OneMinute CandleInterval = "1m"
+	// FiveMinutes candles aggregate the trades of five minutes
+	FiveMinutes CandleInterval = "5m"
+	// OneHour candles aggregate the trades of one hour
+	OneHour CandleInterval = "1h"
+	// OneDay candles aggregate the trades of one day
+	OneDay CandleInterval = "1d"
+)
+
+// CandleIntervals are all intervals candles are maintained for
+var CandleIntervals = []CandleInterval{OneMinute, FiveMinutes, OneHour, OneDay}
+
+// Duration returns the time span of the interval
+func (ci CandleInterval) Duration() time.Duration {
+	switch ci {
+	case OneMinute:
+		return time.Minute
+	case FiveMinutes:
+		return 5 * time.Minute
+	case OneHour:
+		return time.Hour
+	case OneDay:
+		return 24 * time.Hour
+	}
+	return 0
+}
+
+// ParseCandleInterval parses a candle interval string
+func ParseCandleInterval(str string) (CandleInterval, error) {
+	for _, ci := range CandleIntervals {
+		if str == string(ci) {
+			return ci, nil
+		}
+	}
+	return "", fmt.Errorf("'%s' is not a valid candle interval, use '1m', '5m', '1h' or '1d'", str)
+}
+
+// Candle contains open, high, low and close price as well as the traded volume of a token pair for one interval
+type Candle struct {
+	AmountDenom string         `json:"amount_denom"`
+	PriceDenom  string         `json:"price_denom"`
+	Interval    CandleInterval `json:"interval"`
+	StartTime   time.Time      `json:"start_time"`
+	Open        Price          `json:"open"`
+	High        Price          `json:"high"`
+	Low         Price          `json:"low"`
+	Close       Price          `json:"close"`
+	Volume      sdk.Coin       `json:"volume"`
+	NumTrades   int64          `json:"num_trades"`
+}
+
+// NewCandle creates a new candle for the interval containing the given time, starting with one trade
+func NewCandle(interval CandleInterval, t time.Time, amount sdk.Coin, price Price) Candle {
+	return Candle{
+		AmountDenom: amount.Denom,
+		PriceDenom:  price.Denom,
+		Interval:    interval,
+		StartTime:   getCandleStartTime(interval, t),
+		Open:        price,
+		High:        price,
+		Low:         price,
+		Close:       price,
+		Volume:      amount,
+		NumTrades:   1,
+	}
+}
+
+// AddTrade updates the candle with a later trade of the interval
+func (c *Candle) AddTrade(amount sdk.Coin, price Price) {
+	if price.Amount.GT(c.High.Amount) {
+		c.High = price
+	}
+	if price.Amount.LT(c.Low.Amount) {
+		c.Low = price
+	}
+	c.Close = price
+	c.Volume = c.Volume.Plus(amount)
+	c.NumTrades++
+}
+
+func getCandleStartTime(interval CandleInterval, t time.Time) time.Time {
+	return t.UTC().Truncate(interval.Duration())
+}
+
+var candleSubspace = []byte("candle:")
+
+// Prefix of all candles of a token pair and interval, sorted by start time
+func MakeKeyCandlesSubspace(amountDenom string, priceDenom string, interval CandleInterval) []byte {
+	return []byte(fmt.Sprintf("candle:%v:%v:%v:", amountDenom, priceDenom, interval))
+}
+
+// Key for getting the candle of a token pair and interval starting at the given time from the store
+func MakeKeyCandle(amountDenom string, priceDenom string, interval CandleInterval, startTime time.Time) []byte {
+	return []byte(fmt.Sprintf("candle:%v:%v:%v:%020d", amountDenom, priceDenom, interval, startTime.Unix()))
+}
+
+// MakeKeyCandleRange returns the start (inclusive) and end (exclusive) key of the candles of a token pair and
+// interval that cover the time range from `from` to `to`
+func MakeKeyCandleRange(amountDenom string, priceDenom string, interval CandleInterval, from time.Time, to time.Time,
+) ([]byte, []byte) {
+	start := MakeKeyCandle(amountDenom, priceDenom, interval, getCandleStartTime(interval, from))
+	end := MakeKeyCandle(amountDenom, priceDenom, interval, getCandleStartTime(interval, to).Add(time.Second))
+	return start, end
+}
+
+// GetCandlesInRange decodes the candles of a token pair and interval that cover the time range from `from` to `to`
+// from store entries sorted by key
+func GetCandlesInRange(cdc *wire.Codec, entries []sdk.KVPair, amountDenom string, priceDenom string,
+	interval CandleInterval, from time.Time, to time.Time) []Candle {
+	candles := make([]Candle, 0)
+	start, end := MakeKeyCandleRange(amountDenom, priceDenom, interval, from, to)
+
+	for _, entry := range entries {
+		if bytes.Compare(entry.Key, start) < 0 || bytes.Compare(entry.Key, end) >= 0 {
+			continue
+		}
+
+		var candle Candle
+		cdc.MustUnmarshalBinary(entry.Value, &candle)
+		candles = append(candles, candle)
+	}
+
+	return candles
+}
@@ -1,7 +1,9 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"time"
 
@@ -23,8 +25,39 @@ const (
 	flagAmount      = "amount"
 	flagPrice       = "price"
 	flagExpiresAt   = "expires-at"
+	flagExpiresIn   = "expires-in-blocks"
+	flagTimeInForce = "time-in-force"
+	flagSTP         = "self-trade-prevention"
+	flagDisplay     = "display-amount"
+	flagClientID    = "client-order-id"
+	flagOCOGroup    = "oco-group"
 	flagAmountDenom = "amount-denom"
 	flagPriceDenom  = "price-denom"
+	flagOrderID     = "order-id"
+	flagAddress     = "address"
+	flagPage        = "page"
+	flagLimit       = "limit"
+	flagLevels      = "levels"
+	flagInterval    = "interval"
+	flagFrom        = "from-time"
+	flagTo          = "to-time"
+	flagMode        = "mode"
+	flagTickSize    = "tick-size"
+	flagLotSize     = "lot-size"
+	flagMinNotional = "min-notional"
+	flagMakerFee    = "maker-fee-rate"
+	flagTakerFee    = "taker-fee-rate"
+	flagProposalID  = "proposal-id"
+	flagPrintDesc   = "print-proposal-description"
+	flagFile        = "file"
+	flagAtomic      = "atomic"
+	flagTrailOffset = "trailing-offset"
+	flagTrailRate   = "trailing-rate"
+	flagMarketOrder = "market-order"
+	flagLimitOffset = "limit-offset"
+	flagStopID      = "stop-id"
+	flagPriceBand   = "price-band"
+	flagHalted      = "halted"
 )
 
 // get cmd to create new limit order
@@ -57,34 +90,662 @@ func GetCmdLimitOrderCreate(cdc *wire.Codec) *cobra.Command {
 				return err
 			}
 
-			price, err := sdk.ParseCoin(viper.GetString(flagPrice))
+			price, err := exchange.ParsePrice(viper.GetString(flagPrice))
 			if err != nil {
 				return err
 			}
 
-			expiresAt, err := time.Parse(time.RFC3339, viper.GetString(flagExpiresAt))
+			// the order expires at the given time and/or after the given number of blocks, good-till-cancelled
+			// orders have neither
+			var expiresAt time.Time
+			if viper.GetString(flagExpiresAt) != "" {
+				expiresAt, err = time.Parse(time.RFC3339, viper.GetString(flagExpiresAt))
+				if err != nil {
+					return err
+				}
+			}
+
+			expiresAtHeight, err := getExpiresAtHeight(cliCtx, viper.GetInt64(flagExpiresIn))
+			if err != nil {
+				return err
+			}
+
+			timeInForce, err := exchange.ParseTimeInForce(viper.GetString(flagTimeInForce))
+			if err != nil {
+				return err
+			}
+
+			stp, err := exchange.ParseSelfTradePrevention(viper.GetString(flagSTP))
+			if err != nil {
+				return err
+			}
+
+			// create the msg
+			msg := exchange.NewMsgCreateLimitOrder(sender, kind, amount, price, expiresAt, expiresAtHeight, timeInForce,
+				stp, sdk.NewInt(viper.GetInt64(flagDisplay)), viper.GetString(flagClientID),
+				viper.GetString(flagOCOGroup))
+
+			err = msg.ValidateBasic()
+			if err != nil {
+				return err
+			}
+
+			// Build and sign the transaction, then broadcast to a Tendermint
+			// node.
+			return utils.SendTx(txCtx, cliCtx, []sdk.Msg{msg})
+		},
+	}
+
+	cmd.Flags().String(flagKind, "", "kind of the order ('sell' or 'buy')")
+	cmd.Flags().String(flagAmount, "", "amount to be sold or bought, e. g. '8ETH'")
+	cmd.Flags().String(flagPrice, "", "price limit per unit of amount (maximum buy price or minimum sell price), e. g. '25RUNE', '0.25RUNE' or '1/3RUNE'")
+	cmd.Flags().String(flagExpiresAt, "", "expiration of the order in RFC3339, e. g. '2018-10-31T11:45:05.000Z'")
+	cmd.Flags().Int64(flagExpiresIn, 0, "number of blocks after the latest block at which the order expires")
+	cmd.Flags().String(flagTimeInForce, "gtt",
+		"time in force of the order ('gtt' good-till-time, 'ioc' immediate-or-cancel, 'fok' fill-or-kill, 'post-only' "+
+			"or 'gtc' good-till-cancelled, which must not have an expiry)")
+	cmd.Flags().String(flagSTP, "market", "what happens if the order would match an own order ('market' default of "+
+		"the market, 'cancel-newest', 'cancel-oldest', 'cancel-both' or 'decrement-and-cancel')")
+	cmd.Flags().Int64(flagDisplay, 0, "amount shown in the order book at a time, the rest is hidden until the shown "+
+		"amount is filled (iceberg order), 0 shows the whole amount")
+	cmd.Flags().String(flagClientID, "", "id of the order chosen by the sender, each id can only be used once")
+	cmd.Flags().String(flagOCOGroup, "", "one-cancels-other group of the order, once an order of the group is filled "+
+		"or cancelled, the other open orders of the sender in the group are cancelled")
+
+	return cmd
+}
+
+// getExpiresAtHeight returns the height of the block the given number of blocks after the latest block, zero if the
+// number of blocks is not positive
+func getExpiresAtHeight(cliCtx context.CLIContext, expiresIn int64) (int64, error) {
+	if expiresIn <= 0 {
+		return 0, nil
+	}
+
+	node, err := cliCtx.GetNode()
+	if err != nil {
+		return 0, err
+	}
+	status, err := node.Status()
+	if err != nil {
+		return 0, err
+	}
+	return status.SyncInfo.LatestBlockHeight + expiresIn, nil
+}
+
+// get cmd to cancel an open limit order
+func GetCmdLimitOrderCancel(cdc *wire.Codec) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cancel-limit-order",
+		Short: "Cancel an open limit order",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			txCtx := authctx.NewTxContextFromCLI().WithCodec(cdc)
+			cliCtx := context.NewCLIContext().
+				WithCodec(cdc).
+				WithLogger(os.Stdout).
+				WithAccountDecoder(authcmd.GetAccountDecoder(cdc))
+
+			// get the from address from the name flag
+			sender, err := cliCtx.GetFromAddress()
+			if err != nil {
+				return err
+			}
+
+			msg := exchange.NewMsgCancelLimitOrder(sender, viper.GetInt64(flagOrderID))
+			if clientOrderID := viper.GetString(flagClientID); clientOrderID != "" {
+				msg = exchange.NewMsgCancelLimitOrderByClientOrderID(sender, clientOrderID)
+			}
+
+			err = msg.ValidateBasic()
+			if err != nil {
+				return err
+			}
+
+			return utils.SendTx(txCtx, cliCtx, []sdk.Msg{msg})
+		},
+	}
+
+	cmd.Flags().Int64(flagOrderID, 0, "id of the order to cancel")
+	cmd.Flags().String(flagClientID, "", "client order id of the order to cancel instead of the order id")
+
+	return cmd
+}
+
+// get cmd to replace price and/or amount of an open limit order
+func GetCmdLimitOrderReplace(cdc *wire.Codec) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "replace-limit-order",
+		Short: "Replace price and/or amount of an open limit order",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			txCtx := authctx.NewTxContextFromCLI().WithCodec(cdc)
+			cliCtx := context.NewCLIContext().
+				WithCodec(cdc).
+				WithLogger(os.Stdout).
+				WithAccountDecoder(authcmd.GetAccountDecoder(cdc))
+
+			// get the from address from the name flag
+			sender, err := cliCtx.GetFromAddress()
+			if err != nil {
+				return err
+			}
+
+			amount, err := sdk.ParseCoin(viper.GetString(flagAmount))
+			if err != nil {
+				return err
+			}
+
+			price, err := exchange.ParsePrice(viper.GetString(flagPrice))
+			if err != nil {
+				return err
+			}
+
+			msg := exchange.NewMsgReplaceLimitOrder(sender, viper.GetInt64(flagOrderID), amount, price)
+
+			err = msg.ValidateBasic()
+			if err != nil {
+				return err
+			}
+
+			return utils.SendTx(txCtx, cliCtx, []sdk.Msg{msg})
+		},
+	}
+
+	cmd.Flags().Int64(flagOrderID, 0, "id of the order to replace")
+	cmd.Flags().String(flagAmount, "", "new amount to be sold or bought, e. g. '8ETH'")
+	cmd.Flags().String(flagPrice, "", "new price limit per unit of amount, e. g. '25RUNE'")
+
+	return cmd
+}
+
+// batchFileItem is a create or a cancel of a batch file, exactly one of both must be set
+type batchFileItem struct {
+	Create *batchFileCreate `json:"create"`
+	Cancel *batchFileCancel `json:"cancel"`
+}
+
+// batchFileCreate is a create of a batch file with the values of the flags of create-limit-order
+type batchFileCreate struct {
+	Kind                string `json:"kind"`
+	Amount              string `json:"amount"`
+	Price               string `json:"price"`
+	ExpiresAt           string `json:"expires_at"`
+	ExpiresIn           int64  `json:"expires_in_blocks"`
+	TimeInForce         string `json:"time_in_force"`
+	SelfTradePrevention string `json:"self_trade_prevention"`
+	DisplayAmount       int64  `json:"display_amount"`
+	ClientOrderID       string `json:"client_order_id"`
+	OCOGroup            string `json:"oco_group"`
+}
+
+// batchFileCancel is a cancel of a batch file with the values of the flags of cancel-limit-order
+type batchFileCancel struct {
+	OrderID       int64  `json:"order_id"`
+	ClientOrderID string `json:"client_order_id"`
+}
+
+// toMsg converts a create of a batch file to a create message of the sender
+func (c batchFileCreate) toMsg(cliCtx context.CLIContext, sender sdk.AccAddress) (exchange.MsgCreateLimitOrder,
+	error) {
+	kind, err := exchange.ParseKind(c.Kind)
+	if err != nil {
+		return exchange.MsgCreateLimitOrder{}, err
+	}
+
+	amount, err := sdk.ParseCoin(c.Amount)
+	if err != nil {
+		return exchange.MsgCreateLimitOrder{}, err
+	}
+
+	price, err := exchange.ParsePrice(c.Price)
+	if err != nil {
+		return exchange.MsgCreateLimitOrder{}, err
+	}
+
+	var expiresAt time.Time
+	if c.ExpiresAt != "" {
+		expiresAt, err = time.Parse(time.RFC3339, c.ExpiresAt)
+		if err != nil {
+			return exchange.MsgCreateLimitOrder{}, err
+		}
+	}
+
+	expiresAtHeight, err := getExpiresAtHeight(cliCtx, c.ExpiresIn)
+	if err != nil {
+		return exchange.MsgCreateLimitOrder{}, err
+	}
+
+	timeInForce, err := exchange.ParseTimeInForce(c.TimeInForce)
+	if err != nil {
+		return exchange.MsgCreateLimitOrder{}, err
+	}
+
+	stp, err := exchange.ParseSelfTradePrevention(c.SelfTradePrevention)
+	if err != nil {
+		return exchange.MsgCreateLimitOrder{}, err
+	}
+
+	return exchange.NewMsgCreateLimitOrder(sender, kind, amount, price, expiresAt, expiresAtHeight, timeInForce, stp,
+		sdk.NewInt(c.DisplayAmount), c.ClientOrderID, c.OCOGroup), nil
+}
+
+// readBatchFile reads the creates and cancels of the sender from a JSON batch file
+func readBatchFile(cliCtx context.CLIContext, sender sdk.AccAddress, path string) ([]exchange.BatchOrderItem, error) {
+	bz, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var fileItems []batchFileItem
+	err = json.Unmarshal(bz, &fileItems)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]exchange.BatchOrderItem, len(fileItems))
+	for i, fileItem := range fileItems {
+		if fileItem.Create != nil {
+			create, err := fileItem.Create.toMsg(cliCtx, sender)
+			if err != nil {
+				return nil, fmt.Errorf("item %v: %v", i, err)
+			}
+			items[i].Create = &create
+		}
+
+		if fileItem.Cancel != nil {
+			cancel := exchange.NewMsgCancelLimitOrder(sender, fileItem.Cancel.OrderID)
+			if fileItem.Cancel.ClientOrderID != "" {
+				cancel = exchange.NewMsgCancelLimitOrderByClientOrderID(sender, fileItem.Cancel.ClientOrderID)
+			}
+			items[i].Cancel = &cancel
+		}
+	}
+
+	return items, nil
+}
+
+// get cmd to create and cancel many limit orders in one message
+func GetCmdBatchOrders(cdc *wire.Codec) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "batch-orders",
+		Short: "Create and cancel many limit orders in one message, applied in the order of the batch file",
+		Long: "Create and cancel many limit orders in one message, applied in the order of the batch file. The file " +
+			"is a JSON list of items like {\"create\": {\"kind\": \"buy\", \"amount\": \"8ETH\", \"price\": " +
+			"\"25RUNE\", \"time_in_force\": \"gtc\"}} or {\"cancel\": {\"order_id\": 3}}. Creates take the " +
+			"fields kind, amount, price, expires_at, expires_in_blocks, time_in_force, self_trade_prevention, " +
+			"display_amount, client_order_id and oco_group, cancels take order_id or client_order_id.",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			txCtx := authctx.NewTxContextFromCLI().WithCodec(cdc)
+			cliCtx := context.NewCLIContext().
+				WithCodec(cdc).
+				WithLogger(os.Stdout).
+				WithAccountDecoder(authcmd.GetAccountDecoder(cdc))
+
+			// get the from address from the name flag
+			sender, err := cliCtx.GetFromAddress()
+			if err != nil {
+				return err
+			}
+
+			items, err := readBatchFile(cliCtx, sender, viper.GetString(flagFile))
+			if err != nil {
+				return err
+			}
+
+			msg := exchange.NewMsgBatchOrders(sender, items, viper.GetBool(flagAtomic))
+
+			err = msg.ValidateBasic()
+			if err != nil {
+				return err
+			}
+
+			return utils.SendTx(txCtx, cliCtx, []sdk.Msg{msg})
+		},
+	}
+
+	cmd.Flags().String(flagFile, "", "path of the JSON batch file")
+	cmd.Flags().Bool(flagAtomic, false, "apply either all items or none, otherwise failed items are skipped")
+
+	return cmd
+}
+
+// get cmd to create a trailing stop
+func GetCmdTrailingStopCreate(cdc *wire.Codec) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "create-trailing-stop",
+		Short: "Create a trailing stop whose trigger price follows the best last-trade price of the market",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			txCtx := authctx.NewTxContextFromCLI().WithCodec(cdc)
+			cliCtx := context.NewCLIContext().
+				WithCodec(cdc).
+				WithLogger(os.Stdout).
+				WithAccountDecoder(authcmd.GetAccountDecoder(cdc))
+
+			// get the from address from the name flag
+			sender, err := cliCtx.GetFromAddress()
+			if err != nil {
+				return err
+			}
+
+			kind, err := exchange.ParseKind(viper.GetString(flagKind))
+			if err != nil {
+				return err
+			}
+
+			amount, err := sdk.ParseCoin(viper.GetString(flagAmount))
+			if err != nil {
+				return err
+			}
+
+			trailingOffset, err := exchange.ParseRat(viper.GetString(flagTrailOffset))
+			if err != nil {
+				return err
+			}
+
+			limitOffset, err := exchange.ParseRat(viper.GetString(flagLimitOffset))
+			if err != nil {
+				return err
+			}
+
+			msg := exchange.NewMsgCreateTrailingStop(sender, kind, amount, viper.GetString(flagPriceDenom),
+				trailingOffset, viper.GetInt64(flagTrailRate), viper.GetBool(flagMarketOrder), limitOffset)
+
+			err = msg.ValidateBasic()
+			if err != nil {
+				return err
+			}
+
+			return utils.SendTx(txCtx, cliCtx, []sdk.Msg{msg})
+		},
+	}
+
+	cmd.Flags().String(flagKind, "", "kind of the order submitted when the stop triggers ('sell' or 'buy')")
+	cmd.Flags().String(flagAmount, "", "amount to be sold or bought, e. g. '8ETH'")
+	cmd.Flags().String(flagPriceDenom, "", "price denom of the market, e. g. 'RUNE'")
+	cmd.Flags().String(flagTrailOffset, "0", "distance of the trigger price from the best price in the price "+
+		"denom, e. g. '2' or '0.5'")
+	cmd.Flags().Int64(flagTrailRate, 0, "distance of the trigger price from the best price in basis points, "+
+		"instead of the trailing offset")
+	cmd.Flags().Bool(flagMarketOrder, false, "submit an immediate-or-cancel order at the prices of the order book "+
+		"when triggered instead of a limit order")
+	cmd.Flags().String(flagLimitOffset, "0", "distance of the limit price from the trigger price, below it for "+
+		"sells and above it for buys")
+
+	return cmd
+}
+
+// get cmd to cancel a trailing stop that has not been submitted yet
+func GetCmdTrailingStopCancel(cdc *wire.Codec) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cancel-trailing-stop",
+		Short: "Cancel a trailing stop that has not been submitted yet",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			txCtx := authctx.NewTxContextFromCLI().WithCodec(cdc)
+			cliCtx := context.NewCLIContext().
+				WithCodec(cdc).
+				WithLogger(os.Stdout).
+				WithAccountDecoder(authcmd.GetAccountDecoder(cdc))
+
+			// get the from address from the name flag
+			sender, err := cliCtx.GetFromAddress()
+			if err != nil {
+				return err
+			}
+
+			msg := exchange.NewMsgCancelTrailingStop(sender, viper.GetInt64(flagStopID))
+
+			err = msg.ValidateBasic()
+			if err != nil {
+				return err
+			}
+
+			return utils.SendTx(txCtx, cliCtx, []sdk.Msg{msg})
+		},
+	}
+
+	cmd.Flags().Int64(flagStopID, 0, "id of the trailing stop to cancel")
+
+	return cmd
+}
+
+// get cmd to switch a market between continuous matching and batch auctions
+func GetCmdSetMarketMode(cdc *wire.Codec) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "set-market-mode",
+		Short: "Switch a market between continuous matching and batch auctions, only allowed for the authority",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			txCtx := authctx.NewTxContextFromCLI().WithCodec(cdc)
+			cliCtx := context.NewCLIContext().
+				WithCodec(cdc).
+				WithLogger(os.Stdout).
+				WithAccountDecoder(authcmd.GetAccountDecoder(cdc))
+
+			// get the from address from the name flag
+			sender, err := cliCtx.GetFromAddress()
+			if err != nil {
+				return err
+			}
+
+			mode, err := exchange.ParseMarketMode(viper.GetString(flagMode))
+			if err != nil {
+				return err
+			}
+
+			msg := exchange.NewMsgSetMarketMode(sender, viper.GetString(flagAmountDenom),
+				viper.GetString(flagPriceDenom), mode)
+
+			err = msg.ValidateBasic()
+			if err != nil {
+				return err
+			}
+
+			return utils.SendTx(txCtx, cliCtx, []sdk.Msg{msg})
+		},
+	}
+
+	cmd.Flags().String(flagAmountDenom, "", "amount denom of the market, e. g. 'ETH'")
+	cmd.Flags().String(flagPriceDenom, "", "price denom of the market, e. g. 'RUNE'")
+	cmd.Flags().String(flagMode, "continuous", "matching mode, 'continuous' or 'batch'")
+
+	return cmd
+}
+
+// get cmd to change tick size, lot size, minimum notional, self-trade prevention mode and price band of a market
+func GetCmdSetMarketConfig(cdc *wire.Codec) *cobra.Command {
+	cmd := &cobra.Command{
+		Use: "set-market-config",
+		Short: "Change tick size, lot size, minimum notional, self-trade prevention mode and price band of a market, " +
+			"only allowed for the authority",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			txCtx := authctx.NewTxContextFromCLI().WithCodec(cdc)
+			cliCtx := context.NewCLIContext().
+				WithCodec(cdc).
+				WithLogger(os.Stdout).
+				WithAccountDecoder(authcmd.GetAccountDecoder(cdc))
+
+			// get the from address from the name flag
+			sender, err := cliCtx.GetFromAddress()
+			if err != nil {
+				return err
+			}
+
+			tickSize, err := exchange.ParseRat(viper.GetString(flagTickSize))
+			if err != nil {
+				return err
+			}
+
+			stp, err := exchange.ParseSelfTradePrevention(viper.GetString(flagSTP))
+			if err != nil {
+				return err
+			}
+
+			msg := exchange.NewMsgSetMarketConfig(sender, viper.GetString(flagAmountDenom),
+				viper.GetString(flagPriceDenom), tickSize, sdk.NewInt(viper.GetInt64(flagLotSize)),
+				sdk.NewInt(viper.GetInt64(flagMinNotional)), stp, viper.GetInt64(flagPriceBand))
+
+			err = msg.ValidateBasic()
+			if err != nil {
+				return err
+			}
+
+			return utils.SendTx(txCtx, cliCtx, []sdk.Msg{msg})
+		},
+	}
+
+	cmd.Flags().String(flagAmountDenom, "", "amount denom of the market, e. g. 'ETH'")
+	cmd.Flags().String(flagPriceDenom, "", "price denom of the market, e. g. 'RUNE'")
+	cmd.Flags().String(flagTickSize, "1", "prices must be multiples of the tick size, e. g. '0.01' or '1/100'")
+	cmd.Flags().Int64(flagLotSize, 1, "amounts must be multiples of the lot size")
+	cmd.Flags().Int64(flagMinNotional, 0, "minimum total price of an order in the price denom")
+	cmd.Flags().String(flagSTP, "cancel-newest", "self-trade prevention mode of orders that do not set one "+
+		"('cancel-newest', 'cancel-oldest', 'cancel-both' or 'decrement-and-cancel')")
+	cmd.Flags().Int64(flagPriceBand, 0, "maximum distance of order prices from the last trade price in basis points, "+
+		"0 disables the price band")
+
+	return cmd
+}
+
+// get cmd to halt or resume a market by the authority or by a passed governance proposal
+func GetCmdSetMarketHalt(cdc *wire.Codec) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "set-market-halt",
+		Short: "Halt or resume a market by the authority or by a passed governance proposal",
+		Long: "Halt or resume a market by the authority or by a passed governance proposal. A halt proposal is a " +
+			"text proposal with the description printed by --" + flagPrintDesc + ". A halted market rejects new " +
+			"orders, but still accepts cancels.",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			halt := exchange.NewMarketHalt(viper.GetString(flagAmountDenom), viper.GetString(flagPriceDenom),
+				viper.GetBool(flagHalted))
+
+			if viper.GetBool(flagPrintDesc) {
+				fmt.Println(exchange.GetHaltProposalDescription(halt))
+				return nil
+			}
+
+			txCtx := authctx.NewTxContextFromCLI().WithCodec(cdc)
+			cliCtx := context.NewCLIContext().
+				WithCodec(cdc).
+				WithLogger(os.Stdout).
+				WithAccountDecoder(authcmd.GetAccountDecoder(cdc))
+
+			// get the from address from the name flag
+			sender, err := cliCtx.GetFromAddress()
+			if err != nil {
+				return err
+			}
+
+			msg := exchange.NewMsgSetMarketHalt(sender, halt.AmountDenom, halt.PriceDenom, halt.Halted,
+				viper.GetInt64(flagProposalID))
+
+			err = msg.ValidateBasic()
+			if err != nil {
+				return err
+			}
+
+			return utils.SendTx(txCtx, cliCtx, []sdk.Msg{msg})
+		},
+	}
+
+	cmd.Flags().String(flagAmountDenom, "", "amount denom of the market, e. g. 'ETH'")
+	cmd.Flags().String(flagPriceDenom, "", "price denom of the market, e. g. 'RUNE'")
+	cmd.Flags().Bool(flagHalted, true, "halt the market, resume it if false")
+	cmd.Flags().Int64(flagProposalID, 0, "id of the passed halt proposal, only the authority may halt if not set")
+	cmd.Flags().Bool(flagPrintDesc, false, "print the description of a proposal for this halt instead of halting")
+
+	return cmd
+}
+
+// get cmd to list a market by a passed governance proposal or by paying the listing deposit
+func GetCmdListMarket(cdc *wire.Codec) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list-market",
+		Short: "List a market by a passed governance proposal or by paying the listing deposit",
+		Long: "List a market by a passed governance proposal or by paying the listing deposit. A listing proposal " +
+			"is a text proposal with the description printed by --" + flagPrintDesc + ".",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			tickSize, err := exchange.ParseRat(viper.GetString(flagTickSize))
+			if err != nil {
+				return err
+			}
+
+			listing := exchange.NewMarketListing(viper.GetString(flagAmountDenom), viper.GetString(flagPriceDenom),
+				tickSize, sdk.NewInt(viper.GetInt64(flagLotSize)), sdk.NewInt(viper.GetInt64(flagMinNotional)),
+				viper.GetInt64(flagMakerFee), viper.GetInt64(flagTakerFee))
+
+			if viper.GetBool(flagPrintDesc) {
+				fmt.Println(exchange.GetListingProposalDescription(listing))
+				return nil
+			}
+
+			txCtx := authctx.NewTxContextFromCLI().WithCodec(cdc)
+			cliCtx := context.NewCLIContext().
+				WithCodec(cdc).
+				WithLogger(os.Stdout).
+				WithAccountDecoder(authcmd.GetAccountDecoder(cdc))
+
+			// get the from address from the name flag
+			sender, err := cliCtx.GetFromAddress()
 			if err != nil {
 				return err
 			}
 
-			// create the msg
-			msg := exchange.NewMsgCreateLimitOrder(sender, kind, amount, price, expiresAt)
+			msg := exchange.NewMsgListMarket(sender, listing, viper.GetInt64(flagProposalID))
 
 			err = msg.ValidateBasic()
 			if err != nil {
 				return err
 			}
 
-			// Build and sign the transaction, then broadcast to a Tendermint
-			// node.
 			return utils.SendTx(txCtx, cliCtx, []sdk.Msg{msg})
 		},
 	}
 
-	cmd.Flags().String(flagKind, "", "kind of the order ('sell' or 'buy')")
-	cmd.Flags().String(flagAmount, "", "amount to be sold or bought, e. g. '8ETH'")
-	cmd.Flags().String(flagPrice, "", "price limit per unit of amount (maximum buy price or minimum sell price), e. g. '25RUNE'")
-	cmd.Flags().String(flagExpiresAt, "", "expiration of the order in RFC3339, e. g. '2018-10-31T11:45:05.000Z'")
+	cmd.Flags().String(flagAmountDenom, "", "amount denom of the market, e. g. 'ETH'")
+	cmd.Flags().String(flagPriceDenom, "", "price denom of the market, e. g. 'RUNE'")
+	cmd.Flags().String(flagTickSize, "1", "prices must be multiples of the tick size, e. g. '0.01' or '1/100'")
+	cmd.Flags().Int64(flagLotSize, 1, "amounts must be multiples of the lot size")
+	cmd.Flags().Int64(flagMinNotional, 0, "minimum total price of an order in the price denom")
+	cmd.Flags().Int64(flagMakerFee, -1, "maker fee rate in basis points, -1 uses the exchange fee rate")
+	cmd.Flags().Int64(flagTakerFee, -1, "taker fee rate in basis points, -1 uses the exchange fee rate")
+	cmd.Flags().Int64(flagProposalID, 0, "id of the passed listing proposal, the listing deposit is paid if not set")
+	cmd.Flags().Bool(flagPrintDesc, false, "print the description of a proposal for this listing instead of listing")
+
+	return cmd
+}
+
+// get cmd to delist a market by its lister or the authority, which refunds the listing deposit
+func GetCmdDelistMarket(cdc *wire.Codec) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delist-market",
+		Short: "Delist a market by its lister or the authority, which refunds the listing deposit",
+		Long: "Delist a market by its lister or the authority, which refunds the listing deposit to the lister. A " +
+			"delisted market rejects new orders, but still accepts cancels.",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			txCtx := authctx.NewTxContextFromCLI().WithCodec(cdc)
+			cliCtx := context.NewCLIContext().
+				WithCodec(cdc).
+				WithLogger(os.Stdout).
+				WithAccountDecoder(authcmd.GetAccountDecoder(cdc))
+
+			// get the from address from the name flag
+			sender, err := cliCtx.GetFromAddress()
+			if err != nil {
+				return err
+			}
+
+			msg := exchange.NewMsgDelistMarket(sender, viper.GetString(flagAmountDenom),
+				viper.GetString(flagPriceDenom))
+
+			err = msg.ValidateBasic()
+			if err != nil {
+				return err
+			}
+
+			return utils.SendTx(txCtx, cliCtx, []sdk.Msg{msg})
+		},
+	}
+
+	cmd.Flags().String(flagAmountDenom, "", "amount denom of the market, e. g. 'ETH'")
+	cmd.Flags().String(flagPriceDenom, "", "price denom of the market, e. g. 'RUNE'")
 
 	return cmd
 }
@@ -118,7 +779,7 @@ func GetCmdQueryOrderbook(storeName string, cdc *wire.Codec) *cobra.Command {
 				orderbook = exchange.NewOrderBook(kind, amountDenom, priceDenom)
 			}
 
-			output, err2 := wire.MarshalJSONIndent(cdc, orderbook)
+			output, err2 := wire.MarshalJSONIndent(cdc, exchange.GetPublicOrderBook(orderbook))
 			if err2 != nil {
 				return err2
 			}
@@ -134,3 +795,399 @@ func GetCmdQueryOrderbook(storeName string, cdc *wire.Codec) *cobra.Command {
 
 	return cmd
 }
+
+// get command to query the aggregated price levels of the buy and sell orderbook of a token pair
+func GetCmdQueryDepth(storeName string, cdc *wire.Codec) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "depth",
+		Short: "Get the price levels, best bid, best ask and spread of the orderbooks of a token pair",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			cliCtx := context.NewCLIContext().WithCodec(cdc)
+
+			amountDenom := viper.GetString(flagAmountDenom)
+			priceDenom := viper.GetString(flagPriceDenom)
+
+			orderBooks := make(map[exchange.OrderKind]exchange.OrderBook)
+			for _, kind := range []exchange.OrderKind{exchange.BuyOrder, exchange.SellOrder} {
+				res, err := cliCtx.QueryStore(exchange.MakeKeyOrderBook(kind, amountDenom, priceDenom), storeName)
+				if err != nil {
+					return err
+				}
+
+				orderBook := exchange.NewOrderBook(kind, amountDenom, priceDenom)
+				if len(res) > 0 {
+					cdc.MustUnmarshalBinary(res, &orderBook)
+				}
+				orderBooks[kind] = orderBook
+			}
+
+			depth := exchange.GetOrderBookDepth(orderBooks[exchange.BuyOrder], orderBooks[exchange.SellOrder],
+				viper.GetInt(flagLevels))
+
+			output, err := wire.MarshalJSONIndent(cdc, depth)
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(string(output))
+			return nil
+		},
+	}
+
+	cmd.Flags().String(flagAmountDenom, "", "denom of the amount to be sold or bought, e. g. 'ETH'")
+	cmd.Flags().String(flagPriceDenom, "", "denom of the price limit for the sell or buy, e. g. 'RUNE'")
+	cmd.Flags().Int(flagLevels, 20, fmt.Sprintf("maximum number of price levels per side (at most %v)",
+		exchange.MaxDepthLevels))
+
+	return cmd
+}
+
+// get command to query the trade history of a token pair or an account
+func GetCmdQueryTrades(storeName string, cdc *wire.Codec) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "trades",
+		Short: "Get trades for given amount and price denoms or for an account, newest first",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			cliCtx := context.NewCLIContext().WithCodec(cdc)
+
+			var subspace []byte
+			if address := viper.GetString(flagAddress); address != "" {
+				addr, err := sdk.AccAddressFromBech32(address)
+				if err != nil {
+					return err
+				}
+				subspace = exchange.MakeKeyTradesByAccountSubspace(addr)
+			} else {
+				amountDenom := viper.GetString(flagAmountDenom)
+				priceDenom := viper.GetString(flagPriceDenom)
+				if amountDenom == "" || priceDenom == "" {
+					return fmt.Errorf("either --%v or --%v and --%v must be given", flagAddress, flagAmountDenom,
+						flagPriceDenom)
+				}
+				subspace = exchange.MakeKeyTradesByPairSubspace(amountDenom, priceDenom)
+			}
+
+			// only the trades of the page are read, by their sequence numbers in the index
+			res, err := cliCtx.QueryStore(exchange.MakeKeyTradeCount(subspace), storeName)
+			if err != nil {
+				return err
+			}
+
+			var count int64
+			if len(res) > 0 {
+				cdc.MustUnmarshalBinary(res, &count)
+			}
+
+			trades := make([]exchange.Trade, 0)
+			for _, seq := range exchange.GetTradePageSeqs(count, viper.GetInt(flagPage), viper.GetInt(flagLimit)) {
+				key, err := cliCtx.QueryStore(exchange.MakeKeyTradeBySeq(subspace, seq), storeName)
+				if err != nil {
+					return err
+				}
+
+				res, err := cliCtx.QueryStore(key, storeName)
+				if err != nil {
+					return err
+				}
+
+				var trade exchange.Trade
+				cdc.MustUnmarshalBinary(res, &trade)
+				trades = append(trades, trade)
+			}
+
+			output, err := wire.MarshalJSONIndent(cdc, trades)
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(string(output))
+			return nil
+		},
+	}
+
+	cmd.Flags().String(flagAmountDenom, "", "denom of the traded amount, e. g. 'ETH'")
+	cmd.Flags().String(flagPriceDenom, "", "denom of the trade price, e. g. 'RUNE'")
+	cmd.Flags().String(flagAddress, "", "address of an account to get the trades of instead of a token pair")
+	cmd.Flags().Int(flagPage, 1, "page of trades to get, starting at 1")
+	cmd.Flags().Int(flagLimit, 50, "maximum number of trades per page")
+
+	return cmd
+}
+
+// get command to query the OHLCV candles of a token pair
+func GetCmdQueryCandles(storeName string, cdc *wire.Codec) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "candles",
+		Short: "Get OHLCV candles for given amount and price denoms, interval and time range",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			cliCtx := context.NewCLIContext().WithCodec(cdc)
+
+			amountDenom := viper.GetString(flagAmountDenom)
+			priceDenom := viper.GetString(flagPriceDenom)
+
+			interval, err := exchange.ParseCandleInterval(viper.GetString(flagInterval))
+			if err != nil {
+				return err
+			}
+
+			to := time.Now()
+			if viper.GetString(flagTo) != "" {
+				to, err = time.Parse(time.RFC3339, viper.GetString(flagTo))
+				if err != nil {
+					return err
+				}
+			}
+
+			from := to.Add(-100 * interval.Duration())
+			if viper.GetString(flagFrom) != "" {
+				from, err = time.Parse(time.RFC3339, viper.GetString(flagFrom))
+				if err != nil {
+					return err
+				}
+			}
+
+			entries, err := cliCtx.QuerySubspace(
+				exchange.MakeKeyCandlesSubspace(amountDenom, priceDenom, interval), storeName)
+			if err != nil {
+				return err
+			}
+
+			candles := exchange.GetCandlesInRange(cdc, entries, amountDenom, priceDenom, interval, from, to)
+
+			output, err := wire.MarshalJSONIndent(cdc, candles)
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(string(output))
+			return nil
+		},
+	}
+
+	cmd.Flags().String(flagAmountDenom, "", "denom of the traded amount, e. g. 'ETH'")
+	cmd.Flags().String(flagPriceDenom, "", "denom of the trade price, e. g. 'RUNE'")
+	cmd.Flags().String(flagInterval, "1h", "interval of the candles ('1m', '5m', '1h' or '1d')")
+	cmd.Flags().String(flagFrom, "", "start of the time range in RFC3339, defaults to 100 intervals before the end")
+	cmd.Flags().String(flagTo, "", "end of the time range in RFC3339, defaults to now")
+
+	return cmd
+}
+
+// get command to query all open orders of an account across all token pairs
+func GetCmdQueryMyOrders(storeName string, cdc *wire.Codec) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "my-orders",
+		Short: "Get all open orders of an account with their remaining amounts and locked coins",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			cliCtx := context.NewCLIContext().WithCodec(cdc)
+
+			sender, err := sdk.AccAddressFromBech32(viper.GetString(flagAddress))
+			if err != nil {
+				return err
+			}
+
+			entries, err := cliCtx.QuerySubspace(exchange.MakeKeyOrdersBySenderSubspace(sender), storeName)
+			if err != nil {
+				return err
+			}
+
+			// the index entries point to the orderbooks that contain open orders of the sender
+			orderBooks := make([]exchange.OrderBook, 0)
+			seen := make(map[string]bool)
+			for _, entry := range entries {
+				if seen[string(entry.Value)] {
+					continue
+				}
+				seen[string(entry.Value)] = true
+
+				res, err := cliCtx.QueryStore(entry.Value, storeName)
+				if err != nil {
+					return err
+				}
+
+				var orderBook exchange.OrderBook
+				cdc.MustUnmarshalBinary(res, &orderBook)
+				orderBooks = append(orderBooks, orderBook)
+			}
+
+			openOrders := exchange.GetOpenLimitOrdersOfSender(sender, orderBooks)
+			if clientOrderID := viper.GetString(flagClientID); clientOrderID != "" {
+				openOrders = exchange.FilterByClientOrderID(openOrders, clientOrderID)
+			}
+
+			output, err := wire.MarshalJSONIndent(cdc, openOrders)
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(string(output))
+			return nil
+		},
+	}
+
+	cmd.Flags().String(flagAddress, "", "address of the account to get the open orders of")
+	cmd.Flags().String(flagClientID, "", "only get the open order with this client order id")
+
+	return cmd
+}
+
+// get command to query the trailing stops of an account that have not been submitted yet
+func GetCmdQueryMyTrailingStops(storeName string, cdc *wire.Codec) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "my-trailing-stops",
+		Short: "Get the trailing stops of an account that have not been submitted yet",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			cliCtx := context.NewCLIContext().WithCodec(cdc)
+
+			sender, err := sdk.AccAddressFromBech32(viper.GetString(flagAddress))
+			if err != nil {
+				return err
+			}
+
+			entries, err := cliCtx.QuerySubspace(exchange.MakeKeyTrailingStopsBySenderSubspace(sender), storeName)
+			if err != nil {
+				return err
+			}
+
+			// the index entries point to the stops
+			stops := make([]exchange.TrailingStop, 0, len(entries))
+			for _, entry := range entries {
+				res, err := cliCtx.QueryStore(entry.Value, storeName)
+				if err != nil {
+					return err
+				}
+
+				var stop exchange.TrailingStop
+				cdc.MustUnmarshalBinary(res, &stop)
+				stops = append(stops, stop)
+			}
+
+			output, err := wire.MarshalJSONIndent(cdc, stops)
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(string(output))
+			return nil
+		},
+	}
+
+	cmd.Flags().String(flagAddress, "", "address of the account to get the trailing stops of")
+
+	return cmd
+}
+
+// get command to query the listed markets
+func GetCmdQueryMarkets(storeName string, cdc *wire.Codec) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "markets",
+		Short: "Get all listed markets with their configuration",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			cliCtx := context.NewCLIContext().WithCodec(cdc)
+
+			entries, err := cliCtx.QuerySubspace(exchange.MakeKeyMarketsSubspace(), storeName)
+			if err != nil {
+				return err
+			}
+
+			markets := make([]exchange.Market, 0, len(entries))
+			for _, entry := range entries {
+				var market exchange.Market
+				cdc.MustUnmarshalBinary(entry.Value, &market)
+				markets = append(markets, market)
+			}
+
+			output, err := wire.MarshalJSONIndent(cdc, exchange.GetListedMarkets(markets))
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(string(output))
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+// get command to query the coins held by the escrow account and the coins locked for open orders
+func GetCmdQueryEscrow(storeName string, cdc *wire.Codec) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "escrow",
+		Short: "Get the coins held by the escrow account and the coins locked for open orders, per denom",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			cliCtx := context.NewCLIContext().
+				WithCodec(cdc).
+				WithAccountDecoder(authcmd.GetAccountDecoder(cdc))
+
+			// the escrow account does not exist before the first order is placed
+			balance := sdk.Coins{}
+			account, err := cliCtx.GetAccount(exchange.EscrowAddress)
+			if err != nil {
+				return err
+			}
+			if account != nil {
+				balance = account.GetCoins()
+			}
+
+			orderBookEntries, err := cliCtx.QuerySubspace(exchange.MakeKeyOrderBooksSubspace(), storeName)
+			if err != nil {
+				return err
+			}
+
+			orderBooks := make([]exchange.OrderBook, 0, len(orderBookEntries))
+			for _, entry := range orderBookEntries {
+				var orderBook exchange.OrderBook
+				cdc.MustUnmarshalBinary(entry.Value, &orderBook)
+				orderBooks = append(orderBooks, orderBook)
+			}
+
+			batchOrderEntries, err := cliCtx.QuerySubspace(exchange.MakeKeyBatchOrdersSubspace(), storeName)
+			if err != nil {
+				return err
+			}
+
+			batchOrders := make([]exchange.LimitOrder, 0, len(batchOrderEntries))
+			for _, entry := range batchOrderEntries {
+				var order exchange.LimitOrder
+				cdc.MustUnmarshalBinary(entry.Value, &order)
+				batchOrders = append(batchOrders, order)
+			}
+
+			stopEntries, err := cliCtx.QuerySubspace(exchange.MakeKeyTrailingStopsSubspace(), storeName)
+			if err != nil {
+				return err
+			}
+
+			stops := make([]exchange.TrailingStop, 0, len(stopEntries))
+			for _, entry := range stopEntries {
+				var stop exchange.TrailingStop
+				cdc.MustUnmarshalBinary(entry.Value, &stop)
+				stops = append(stops, stop)
+			}
+
+			marketEntries, err := cliCtx.QuerySubspace(exchange.MakeKeyMarketsSubspace(), storeName)
+			if err != nil {
+				return err
+			}
+
+			markets := make([]exchange.Market, 0, len(marketEntries))
+			for _, entry := range marketEntries {
+				var market exchange.Market
+				cdc.MustUnmarshalBinary(entry.Value, &market)
+				markets = append(markets, market)
+			}
+
+			output, err := wire.MarshalJSONIndent(cdc,
+				exchange.GetEscrowTotals(balance, orderBooks, batchOrders, stops, markets))
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(string(output))
+			return nil
+		},
+	}
+
+	return cmd
+}
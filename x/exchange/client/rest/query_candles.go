@@ -0,0 +1,81 @@
+package rest
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/client/context"
+	"github.com/cosmos/cosmos-sdk/crypto/keys"
+	"github.com/cosmos/cosmos-sdk/wire"
+	"github.com/gorilla/mux"
+	"github.com/thorchain/THORChain/x/exchange"
+)
+
+// number of intervals returned if no start of the time range is given
+const defaultCandlesCount = 100
+
+func registerQueryCandlesRoute(ctx context.CLIContext, r *mux.Router, cdc *wire.Codec, _ keys.Keybase,
+	storeName string) {
+	r.HandleFunc("/exchange/candles", handleQueryCandles(cdc, ctx, storeName)).Methods("GET")
+}
+
+// handleQueryCandles returns the OHLCV candles of a token pair (query params amount_denom, price_denom and interval)
+// for a time range (optional query params from and to in RFC3339)
+func handleQueryCandles(cdc *wire.Codec, ctx context.CLIContext, storeName string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		amountDenom := query.Get("amount_denom")
+		priceDenom := query.Get("price_denom")
+		if amountDenom == "" || priceDenom == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("amount_denom and price_denom must not be empty"))
+			return
+		}
+
+		interval, err := exchange.ParseCandleInterval(query.Get("interval"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		to := time.Now()
+		if query.Get("to") != "" {
+			to, err = time.Parse(time.RFC3339, query.Get("to"))
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(err.Error()))
+				return
+			}
+		}
+
+		from := to.Add(-defaultCandlesCount * interval.Duration())
+		if query.Get("from") != "" {
+			from, err = time.Parse(time.RFC3339, query.Get("from"))
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(err.Error()))
+				return
+			}
+		}
+
+		entries, err := ctx.QuerySubspace(exchange.MakeKeyCandlesSubspace(amountDenom, priceDenom, interval), storeName)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		candles := exchange.GetCandlesInRange(cdc, entries, amountDenom, priceDenom, interval, from, to)
+
+		output, err := wire.MarshalJSONIndent(cdc, candles)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		w.Write(output)
+	}
+}
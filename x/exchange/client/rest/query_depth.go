@@ -0,0 +1,68 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/cosmos/cosmos-sdk/client/context"
+	"github.com/cosmos/cosmos-sdk/crypto/keys"
+	"github.com/cosmos/cosmos-sdk/wire"
+	"github.com/gorilla/mux"
+	"github.com/thorchain/THORChain/x/exchange"
+)
+
+const defaultDepthLevels = 20
+
+func registerQueryDepthRoute(ctx context.CLIContext, r *mux.Router, cdc *wire.Codec, _ keys.Keybase,
+	storeName string) {
+	r.HandleFunc("/exchange/depth", handleQueryDepth(cdc, ctx, storeName)).Methods("GET")
+}
+
+// handleQueryDepth returns the price levels, best bid, best ask and spread of the buy and sell orderbook of a token
+// pair (query params amount_denom and price_denom). The query param levels is optional
+func handleQueryDepth(cdc *wire.Codec, ctx context.CLIContext, storeName string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		amountDenom := query.Get("amount_denom")
+		priceDenom := query.Get("price_denom")
+		if amountDenom == "" || priceDenom == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("amount_denom and price_denom must be given"))
+			return
+		}
+
+		levels, err := parseIntQueryParam(query.Get("levels"), defaultDepthLevels)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		orderBooks := make(map[exchange.OrderKind]exchange.OrderBook)
+		for _, kind := range []exchange.OrderKind{exchange.BuyOrder, exchange.SellOrder} {
+			res, err := ctx.QueryStore(exchange.MakeKeyOrderBook(kind, amountDenom, priceDenom), storeName)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				w.Write([]byte(err.Error()))
+				return
+			}
+
+			orderBook := exchange.NewOrderBook(kind, amountDenom, priceDenom)
+			if len(res) > 0 {
+				cdc.MustUnmarshalBinary(res, &orderBook)
+			}
+			orderBooks[kind] = orderBook
+		}
+
+		depth := exchange.GetOrderBookDepth(orderBooks[exchange.BuyOrder], orderBooks[exchange.SellOrder], levels)
+
+		output, err := wire.MarshalJSONIndent(cdc, depth)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		w.Write(output)
+	}
+}
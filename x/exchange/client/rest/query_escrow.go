@@ -0,0 +1,102 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/cosmos/cosmos-sdk/client/context"
+	"github.com/cosmos/cosmos-sdk/crypto/keys"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/wire"
+	authcmd "github.com/cosmos/cosmos-sdk/x/auth/client/cli"
+	"github.com/gorilla/mux"
+	"github.com/thorchain/THORChain/x/exchange"
+)
+
+func registerQueryEscrowRoute(ctx context.CLIContext, r *mux.Router, cdc *wire.Codec, _ keys.Keybase,
+	storeName string) {
+	r.HandleFunc("/exchange/escrow", handleQueryEscrow(cdc, ctx, storeName)).Methods("GET")
+}
+
+// handleQueryEscrow returns the coins held by the escrow account and the coins locked for open orders and listing
+// deposits, per denom
+func handleQueryEscrow(cdc *wire.Codec, ctx context.CLIContext, storeName string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// the escrow account does not exist before the first order is placed
+		balance := sdk.Coins{}
+		account, err := ctx.WithAccountDecoder(authcmd.GetAccountDecoder(cdc)).GetAccount(exchange.EscrowAddress)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(err.Error()))
+			return
+		}
+		if account != nil {
+			balance = account.GetCoins()
+		}
+
+		orderBookEntries, err := ctx.QuerySubspace(exchange.MakeKeyOrderBooksSubspace(), storeName)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		orderBooks := make([]exchange.OrderBook, 0, len(orderBookEntries))
+		for _, entry := range orderBookEntries {
+			var orderBook exchange.OrderBook
+			cdc.MustUnmarshalBinary(entry.Value, &orderBook)
+			orderBooks = append(orderBooks, orderBook)
+		}
+
+		batchOrderEntries, err := ctx.QuerySubspace(exchange.MakeKeyBatchOrdersSubspace(), storeName)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		batchOrders := make([]exchange.LimitOrder, 0, len(batchOrderEntries))
+		for _, entry := range batchOrderEntries {
+			var order exchange.LimitOrder
+			cdc.MustUnmarshalBinary(entry.Value, &order)
+			batchOrders = append(batchOrders, order)
+		}
+
+		stopEntries, err := ctx.QuerySubspace(exchange.MakeKeyTrailingStopsSubspace(), storeName)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		stops := make([]exchange.TrailingStop, 0, len(stopEntries))
+		for _, entry := range stopEntries {
+			var stop exchange.TrailingStop
+			cdc.MustUnmarshalBinary(entry.Value, &stop)
+			stops = append(stops, stop)
+		}
+
+		marketEntries, err := ctx.QuerySubspace(exchange.MakeKeyMarketsSubspace(), storeName)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		markets := make([]exchange.Market, 0, len(marketEntries))
+		for _, entry := range marketEntries {
+			var market exchange.Market
+			cdc.MustUnmarshalBinary(entry.Value, &market)
+			markets = append(markets, market)
+		}
+
+		output, err := wire.MarshalJSONIndent(cdc,
+			exchange.GetEscrowTotals(balance, orderBooks, batchOrders, stops, markets))
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		w.Write(output)
+	}
+}
@@ -0,0 +1,44 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/cosmos/cosmos-sdk/client/context"
+	"github.com/cosmos/cosmos-sdk/crypto/keys"
+	"github.com/cosmos/cosmos-sdk/wire"
+	"github.com/gorilla/mux"
+	"github.com/thorchain/THORChain/x/exchange"
+)
+
+func registerQueryMarketsRoute(ctx context.CLIContext, r *mux.Router, cdc *wire.Codec, _ keys.Keybase,
+	storeName string) {
+	r.HandleFunc("/exchange/markets", handleQueryMarkets(cdc, ctx, storeName)).Methods("GET")
+}
+
+// handleQueryMarkets returns all listed markets with their configuration
+func handleQueryMarkets(cdc *wire.Codec, ctx context.CLIContext, storeName string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		entries, err := ctx.QuerySubspace(exchange.MakeKeyMarketsSubspace(), storeName)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		markets := make([]exchange.Market, 0, len(entries))
+		for _, entry := range entries {
+			var market exchange.Market
+			cdc.MustUnmarshalBinary(entry.Value, &market)
+			markets = append(markets, market)
+		}
+
+		output, err := wire.MarshalJSONIndent(cdc, exchange.GetListedMarkets(markets))
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		w.Write(output)
+	}
+}
@@ -0,0 +1,72 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/cosmos/cosmos-sdk/client/context"
+	"github.com/cosmos/cosmos-sdk/crypto/keys"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/wire"
+	"github.com/gorilla/mux"
+	"github.com/thorchain/THORChain/x/exchange"
+)
+
+func registerQueryOpenOrdersRoute(ctx context.CLIContext, r *mux.Router, cdc *wire.Codec, _ keys.Keybase,
+	storeName string) {
+	r.HandleFunc("/exchange/orders/{address}", handleQueryOpenOrders(cdc, ctx, storeName)).Methods("GET")
+}
+
+// handleQueryOpenOrders returns all open orders of an account across all token pairs with their remaining amounts
+// and locked coins. The optional query param client_order_id only returns the open order with this client order id
+func handleQueryOpenOrders(cdc *wire.Codec, ctx context.CLIContext, storeName string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sender, err := sdk.AccAddressFromBech32(mux.Vars(r)["address"])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		entries, err := ctx.QuerySubspace(exchange.MakeKeyOrdersBySenderSubspace(sender), storeName)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		// the index entries point to the orderbooks that contain open orders of the sender
+		orderBooks := make([]exchange.OrderBook, 0)
+		seen := make(map[string]bool)
+		for _, entry := range entries {
+			if seen[string(entry.Value)] {
+				continue
+			}
+			seen[string(entry.Value)] = true
+
+			res, err := ctx.QueryStore(entry.Value, storeName)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				w.Write([]byte(err.Error()))
+				return
+			}
+
+			var orderBook exchange.OrderBook
+			cdc.MustUnmarshalBinary(res, &orderBook)
+			orderBooks = append(orderBooks, orderBook)
+		}
+
+		openOrders := exchange.GetOpenLimitOrdersOfSender(sender, orderBooks)
+		if clientOrderID := r.URL.Query().Get("client_order_id"); clientOrderID != "" {
+			openOrders = exchange.FilterByClientOrderID(openOrders, clientOrderID)
+		}
+
+		output, err := wire.MarshalJSONIndent(cdc, openOrders)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		w.Write(output)
+	}
+}
@@ -73,7 +73,7 @@ func handleQueryOrderbook(cdc *wire.Codec, _ auth.AccountDecoder, ctx context.CL
 			orderbook = exchange.NewOrderBook(kind, m.AmountDenom, m.PriceDenom)
 		}
 
-		output, err2 := wire.MarshalJSONIndent(cdc, orderbook)
+		output, err2 := wire.MarshalJSONIndent(cdc, exchange.GetPublicOrderBook(orderbook))
 		if err2 != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 			w.Write([]byte(err2.Error()))
@@ -0,0 +1,135 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/cosmos/cosmos-sdk/client/context"
+	"github.com/cosmos/cosmos-sdk/crypto/keys"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/wire"
+	"github.com/gorilla/mux"
+	"github.com/thorchain/THORChain/x/exchange"
+)
+
+const (
+	defaultTradesPage  = 1
+	defaultTradesLimit = 50
+)
+
+func registerQueryTradesRoute(ctx context.CLIContext, r *mux.Router, cdc *wire.Codec, _ keys.Keybase,
+	storeName string) {
+	r.HandleFunc("/exchange/trades", handleQueryTrades(cdc, ctx, storeName)).Methods("GET")
+}
+
+// handleQueryTrades returns the trades of a token pair (query params amount_denom and price_denom) or of an account
+// (query param address), newest first. The query params page and limit are optional
+func handleQueryTrades(cdc *wire.Codec, ctx context.CLIContext, storeName string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		page, err := parseIntQueryParam(query.Get("page"), defaultTradesPage)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		limit, err := parseIntQueryParam(query.Get("limit"), defaultTradesLimit)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		var subspace []byte
+		if address := query.Get("address"); address != "" {
+			addr, err := sdk.AccAddressFromBech32(address)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(err.Error()))
+				return
+			}
+			subspace = exchange.MakeKeyTradesByAccountSubspace(addr)
+		} else {
+			amountDenom := query.Get("amount_denom")
+			priceDenom := query.Get("price_denom")
+			if amountDenom == "" || priceDenom == "" {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte("either address or amount_denom and price_denom must be given"))
+				return
+			}
+			subspace = exchange.MakeKeyTradesByPairSubspace(amountDenom, priceDenom)
+		}
+
+		count, err := queryTradeCount(ctx, cdc, storeName, subspace)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		trades, err := queryTradesBySeq(ctx, cdc, storeName, subspace,
+			exchange.GetTradePageSeqs(count, page, limit))
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		output, err := wire.MarshalJSONIndent(cdc, trades)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		w.Write(output)
+	}
+}
+
+// queryTradeCount returns the number of trades in the trade index with the given prefix
+func queryTradeCount(ctx context.CLIContext, cdc *wire.Codec, storeName string, indexSubspace []byte) (int64,
+	error) {
+	res, err := ctx.QueryStore(exchange.MakeKeyTradeCount(indexSubspace), storeName)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	if len(res) > 0 {
+		cdc.MustUnmarshalBinary(res, &count)
+	}
+	return count, nil
+}
+
+// queryTradesBySeq returns the trades with the given sequence numbers in the trade index with the given prefix
+func queryTradesBySeq(ctx context.CLIContext, cdc *wire.Codec, storeName string, indexSubspace []byte,
+	seqs []int64) ([]exchange.Trade, error) {
+	trades := make([]exchange.Trade, 0, len(seqs))
+	for _, seq := range seqs {
+		key, err := ctx.QueryStore(exchange.MakeKeyTradeBySeq(indexSubspace, seq), storeName)
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := ctx.QueryStore(key, storeName)
+		if err != nil {
+			return nil, err
+		}
+
+		var trade exchange.Trade
+		cdc.MustUnmarshalBinary(res, &trade)
+		trades = append(trades, trade)
+	}
+
+	return trades, nil
+}
+
+// parseIntQueryParam parses an optional integer query param, returning the default value if it is empty
+func parseIntQueryParam(param string, defaultValue int) (int, error) {
+	if param == "" {
+		return defaultValue, nil
+	}
+	return strconv.Atoi(param)
+}
@@ -12,4 +12,11 @@ import (
 func RegisterRoutes(cliCtx context.CLIContext, r *mux.Router, cdc *wire.Codec, kb keys.Keybase, storeName string) {
 	// registerCreateLimitOrderRoute(cliCtx, r, cdc, kb)
 	registerQueryOrderbookRoute(cliCtx, r, cdc, kb, storeName)
+	registerQueryDepthRoute(cliCtx, r, cdc, kb, storeName)
+	registerQueryTradesRoute(cliCtx, r, cdc, kb, storeName)
+	registerQueryCandlesRoute(cliCtx, r, cdc, kb, storeName)
+	registerQueryOpenOrdersRoute(cliCtx, r, cdc, kb, storeName)
+	registerQueryMarketsRoute(cliCtx, r, cdc, kb, storeName)
+	registerQueryEscrowRoute(cliCtx, r, cdc, kb, storeName)
+	registerStreamRoute(cliCtx, r, cdc, kb, storeName)
 }
@@ -0,0 +1,517 @@
+package rest
+
+import (
+	"bytes"
+	gocontext "context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/client/context"
+	"github.com/cosmos/cosmos-sdk/crypto/keys"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/wire"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/spf13/viper"
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+	tmtypes "github.com/tendermint/tendermint/types"
+
+	"github.com/thorchain/THORChain/x/exchange"
+)
+
+const (
+	streamWriteTimeout = 10 * time.Second
+	streamSubscriber   = "exchange-stream"
+
+	// waits before subscribing to the node again after the subscription has been lost, doubling with every failed
+	// attempt up to the maximum
+	streamResubscribeBackoff    = time.Second
+	streamMaxResubscribeBackoff = time.Minute
+)
+
+// flagCORS is the flag of the REST server with the domains that can make CORS requests (* for all), streams accept
+// connections from the same origins
+const flagCORS = "cors"
+
+func registerStreamRoute(ctx context.CLIContext, r *mux.Router, cdc *wire.Codec, _ keys.Keybase,
+	storeName string) {
+	hub := newStreamHub(ctx.Client)
+	upgrader := newStreamUpgrader(strings.Split(viper.GetString(flagCORS), ","))
+	r.HandleFunc("/exchange/stream", handleStream(cdc, ctx, storeName, hub, upgrader)).Methods("GET")
+}
+
+// newStreamUpgrader returns an upgrader that accepts connections from clients without origin, from the origin of the
+// REST server itself and from the allowed origins, where * allows all origins
+func newStreamUpgrader(allowedOrigins []string) websocket.Upgrader {
+	return websocket.Upgrader{CheckOrigin: func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+
+		u, err := url.Parse(origin)
+		if err == nil && strings.EqualFold(u.Host, r.Host) {
+			return true
+		}
+
+		for _, allowed := range allowedOrigins {
+			allowed = strings.TrimSpace(allowed)
+			if allowed == "*" || (allowed != "" && strings.EqualFold(allowed, origin)) {
+				return true
+			}
+		}
+		return false
+	}}
+}
+
+// streamEvent tells a stream about a new block. After the subscription to the node has been lost, blocks may have
+// been missed and the stream has to resync its client instead
+type streamEvent struct {
+	height int64 // zero if not known for a resync
+	resync bool
+}
+
+// streamHub shares one subscription to the new blocks of the node between all streams, as the RPC client keeps only
+// one subscription per query
+type streamHub struct {
+	mtx        sync.Mutex
+	subscribe  func(events chan<- interface{}) error // subscribes to the new block headers of the node
+	backoff    time.Duration
+	maxBackoff time.Duration
+	started    bool
+	streams    map[chan streamEvent]bool
+}
+
+func newStreamHub(client rpcclient.Client) *streamHub {
+	subscribe := func(events chan<- interface{}) error {
+		if client == nil {
+			return errors.New("no node to subscribe to is configured")
+		}
+		if !client.IsRunning() {
+			err := client.Start()
+			if err != nil {
+				return err
+			}
+		}
+
+		// a lost subscription may still be known to the node
+		client.UnsubscribeAll(gocontext.Background(), streamSubscriber) // nolint: errcheck
+		return client.Subscribe(gocontext.Background(), streamSubscriber, tmtypes.EventQueryNewBlockHeader, events)
+	}
+
+	return &streamHub{
+		subscribe:  subscribe,
+		backoff:    streamResubscribeBackoff,
+		maxBackoff: streamMaxResubscribeBackoff,
+		streams:    make(map[chan streamEvent]bool),
+	}
+}
+
+// register returns a channel that receives the events of every new block, starting the subscription on first use
+func (h *streamHub) register() (chan streamEvent, error) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	if !h.started {
+		events := make(chan interface{}, 1)
+		err := h.subscribe(events)
+		if err != nil {
+			return nil, err
+		}
+
+		go h.run(events)
+		h.started = true
+	}
+
+	stream := make(chan streamEvent, 1)
+	h.streams[stream] = true
+	return stream, nil
+}
+
+func (h *streamHub) unregister(stream chan streamEvent) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	delete(h.streams, stream)
+}
+
+// run forwards the new blocks to the streams. Once the subscription is closed, e. g. because the connection to the
+// node has been lost, it subscribes again and tells the streams to resync, as blocks may have been missed in between
+func (h *streamHub) run(events <-chan interface{}) {
+	for events != nil {
+		for event := range events {
+			data, ok := event.(tmtypes.EventDataNewBlockHeader)
+			if !ok {
+				continue
+			}
+			h.broadcast(streamEvent{height: data.Header.Height})
+		}
+
+		events = h.resubscribe()
+		if events != nil {
+			h.broadcast(streamEvent{resync: true})
+		}
+	}
+}
+
+// resubscribe subscribes to the node again, waiting longer after every failed attempt. Returns nil if no stream is
+// left, the hub is stopped then and the next stream starts a new subscription
+func (h *streamHub) resubscribe() <-chan interface{} {
+	backoff := h.backoff
+	for {
+		h.mtx.Lock()
+		if len(h.streams) == 0 {
+			h.started = false
+			h.mtx.Unlock()
+			return nil
+		}
+
+		events := make(chan interface{}, 1)
+		err := h.subscribe(events)
+		h.mtx.Unlock()
+		if err == nil {
+			return events
+		}
+
+		time.Sleep(backoff)
+		backoff *= 2
+		if backoff > h.maxBackoff {
+			backoff = h.maxBackoff
+		}
+	}
+}
+
+// broadcast sends the event to all streams. A stream that is still busy with an older event only needs to catch up to
+// the latest block, as its updates are computed from the state it sent last, but must not miss a resync
+func (h *streamHub) broadcast(event streamEvent) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	for stream := range h.streams {
+		next := event
+		select {
+		case pending := <-stream:
+			next.resync = next.resync || pending.resync
+		default:
+		}
+		stream <- next
+	}
+}
+
+// orderBookStream keeps the state a client of a stream has been sent, so that updates only contain changes
+type orderBookStream struct {
+	cdc          *wire.Codec
+	ctx          context.CLIContext
+	conn         *websocket.Conn
+	storeName    string
+	amountDenom  string
+	priceDenom   string
+	levels       int
+	address      sdk.AccAddress // nil if the client does not follow the orders of an account
+	sequence     int64
+	height       int64
+	depth        exchange.OrderBookDepth
+	lastTradeSeq int64  // sequence number of the last trade of the token pair the client knows about
+	orders       []byte // JSON of the open orders of the account that were sent last
+}
+
+// handleStream upgrades the connection to a WebSocket that streams the order books of a token pair (query params
+// amount_denom and price_denom) and optionally the open orders of an account (query param address). The query param
+// levels is optional. The stream starts with a snapshot, clients can request a new one by sending
+// {"type": "snapshot"}. The updates are not built from the events of a block: at every block, each stream queries the
+// depth, the new trades and the open orders from the node again and compares them with the state it sent last. This
+// costs a few queries per stream and block, however many orders the block changed, and keeps the updates correct
+// even if blocks are skipped
+func handleStream(cdc *wire.Codec, ctx context.CLIContext, storeName string, hub *streamHub,
+	upgrader websocket.Upgrader) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		amountDenom := query.Get("amount_denom")
+		priceDenom := query.Get("price_denom")
+		if amountDenom == "" || priceDenom == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("amount_denom and price_denom must be given"))
+			return
+		}
+
+		levels, err := parseIntQueryParam(query.Get("levels"), defaultDepthLevels)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		var address sdk.AccAddress
+		if bech32 := query.Get("address"); bech32 != "" {
+			address, err = sdk.AccAddressFromBech32(bech32)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(err.Error()))
+				return
+			}
+		}
+
+		events, err := hub.register()
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(err.Error()))
+			return
+		}
+		defer hub.unregister(events)
+
+		// the upgrader replies with an error itself
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		stream := &orderBookStream{
+			cdc:         cdc,
+			ctx:         ctx,
+			conn:        conn,
+			storeName:   storeName,
+			amountDenom: amountDenom,
+			priceDenom:  priceDenom,
+			levels:      levels,
+			address:     address,
+		}
+
+		done := make(chan struct{})
+		defer close(done)
+		requests := make(chan exchange.StreamRequest)
+		go readStreamRequests(conn, requests, done)
+
+		height, err := getLatestHeight(ctx)
+		if err == nil {
+			err = stream.sendSnapshot(height)
+		}
+
+		for err == nil {
+			select {
+			case req, ok := <-requests:
+				if !ok {
+					return
+				}
+				if req.Type == exchange.StreamSnapshot {
+					err = stream.sendSnapshot(stream.height)
+				}
+			case event := <-events:
+				if event.resync {
+					err = stream.resync(event.height)
+				} else {
+					err = stream.sendUpdates(event.height)
+				}
+			}
+		}
+	}
+}
+
+// readStreamRequests forwards the requests of a client until the connection is closed
+func readStreamRequests(conn *websocket.Conn, requests chan<- exchange.StreamRequest, done <-chan struct{}) {
+	defer close(requests)
+
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var req exchange.StreamRequest
+		if json.Unmarshal(message, &req) != nil {
+			continue
+		}
+
+		select {
+		case requests <- req:
+		case <-done:
+			return
+		}
+	}
+}
+
+// getLatestHeight returns the height of the latest block of the node
+func getLatestHeight(ctx context.CLIContext) (int64, error) {
+	status, err := ctx.Client.Status()
+	if err != nil {
+		return 0, err
+	}
+	return status.SyncInfo.LatestBlockHeight, nil
+}
+
+// resync tells the client that blocks may have been missed and sends a new snapshot at the given height, or at the
+// latest height if it is not known
+func (s *orderBookStream) resync(height int64) error {
+	if height == 0 {
+		var err error
+		height, err = getLatestHeight(s.ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	s.height = height
+	err := s.send(exchange.StreamMessage{Type: exchange.StreamResync})
+	if err != nil {
+		return err
+	}
+
+	return s.sendSnapshot(height)
+}
+
+// sendSnapshot sends the full depth and open orders at the given height, later updates are relative to it
+func (s *orderBookStream) sendSnapshot(height int64) error {
+	s.height = height
+
+	depth, err := s.queryDepth()
+	if err != nil {
+		return err
+	}
+
+	orders, err := s.queryOpenOrders()
+	if err != nil {
+		return err
+	}
+
+	s.lastTradeSeq, err = queryTradeCount(s.queryCtx(), s.cdc, s.storeName,
+		exchange.MakeKeyTradesByPairSubspace(s.amountDenom, s.priceDenom))
+	if err != nil {
+		return err
+	}
+
+	s.orders, err = s.cdc.MarshalJSON(orders)
+	if err != nil {
+		return err
+	}
+	s.depth = depth
+
+	return s.send(exchange.StreamMessage{Type: exchange.StreamSnapshot, Depth: &depth, Orders: orders})
+}
+
+// sendUpdates sends the changes of the depth, the new trades and the open orders if they changed since the last
+// message
+func (s *orderBookStream) sendUpdates(height int64) error {
+	s.height = height
+
+	depth, err := s.queryDepth()
+	if err != nil {
+		return err
+	}
+
+	bids, asks := exchange.GetDepthDelta(s.depth, depth)
+	s.depth = depth
+	if len(bids) > 0 || len(asks) > 0 {
+		err = s.send(exchange.StreamMessage{Type: exchange.StreamBookDelta, Bids: bids, Asks: asks})
+		if err != nil {
+			return err
+		}
+	}
+
+	// only the trades numbered after the last trade the client knows about are read, oldest first
+	pair := exchange.MakeKeyTradesByPairSubspace(s.amountDenom, s.priceDenom)
+	count, err := queryTradeCount(s.queryCtx(), s.cdc, s.storeName, pair)
+	if err != nil {
+		return err
+	}
+
+	seqs := make([]int64, 0)
+	for seq := s.lastTradeSeq + 1; seq <= count; seq++ {
+		seqs = append(seqs, seq)
+	}
+
+	trades, err := queryTradesBySeq(s.queryCtx(), s.cdc, s.storeName, pair, seqs)
+	if err != nil {
+		return err
+	}
+
+	if len(trades) > 0 {
+		s.lastTradeSeq = count
+		err = s.send(exchange.StreamMessage{Type: exchange.StreamTrades, Trades: trades})
+		if err != nil {
+			return err
+		}
+	}
+
+	orders, err := s.queryOpenOrders()
+	if err != nil {
+		return err
+	}
+
+	ordersJSON, err := s.cdc.MarshalJSON(orders)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(ordersJSON, s.orders) {
+		return nil
+	}
+	s.orders = ordersJSON
+
+	return s.send(exchange.StreamMessage{Type: exchange.StreamOrders, Orders: orders})
+}
+
+func (s *orderBookStream) send(msg exchange.StreamMessage) error {
+	s.sequence++
+	msg.Sequence = s.sequence
+	msg.BlockHeight = s.height
+
+	output, err := s.cdc.MarshalJSON(msg)
+	if err != nil {
+		return err
+	}
+
+	s.conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+	return s.conn.WriteMessage(websocket.TextMessage, output)
+}
+
+// queryCtx returns the context to query the state at the height of the current message
+func (s *orderBookStream) queryCtx() context.CLIContext {
+	ctx := s.ctx
+	ctx.Height = s.height
+	return ctx
+}
+
+func (s *orderBookStream) queryDepth() (exchange.OrderBookDepth, error) {
+	orderBooks := make(map[exchange.OrderKind]exchange.OrderBook)
+	for _, kind := range []exchange.OrderKind{exchange.BuyOrder, exchange.SellOrder} {
+		res, err := s.queryCtx().QueryStore(exchange.MakeKeyOrderBook(kind, s.amountDenom, s.priceDenom), s.storeName)
+		if err != nil {
+			return exchange.OrderBookDepth{}, err
+		}
+
+		orderBook := exchange.NewOrderBook(kind, s.amountDenom, s.priceDenom)
+		if len(res) > 0 {
+			s.cdc.MustUnmarshalBinary(res, &orderBook)
+		}
+		orderBooks[kind] = orderBook
+	}
+
+	return exchange.GetOrderBookDepth(orderBooks[exchange.BuyOrder], orderBooks[exchange.SellOrder], s.levels), nil
+}
+
+// queryOpenOrders returns the open orders of the account in the token pair of the stream
+func (s *orderBookStream) queryOpenOrders() ([]exchange.OpenLimitOrder, error) {
+	if s.address == nil {
+		return nil, nil
+	}
+
+	orderBooks := make([]exchange.OrderBook, 0)
+	for _, kind := range []exchange.OrderKind{exchange.BuyOrder, exchange.SellOrder} {
+		res, err := s.queryCtx().QueryStore(exchange.MakeKeyOrderBook(kind, s.amountDenom, s.priceDenom), s.storeName)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(res) > 0 {
+			var orderBook exchange.OrderBook
+			s.cdc.MustUnmarshalBinary(res, &orderBook)
+			orderBooks = append(orderBooks, orderBook)
+		}
+	}
+
+	return exchange.GetOpenLimitOrdersOfSender(s.address, orderBooks), nil
+}
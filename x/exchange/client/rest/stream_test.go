@@ -0,0 +1,169 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	tmtypes "github.com/tendermint/tendermint/types"
+)
+
+// fakeSubscriptions records the subscriptions of a hub, failing as often as requested
+type fakeSubscriptions struct {
+	mtx      sync.Mutex
+	failures int
+	events   []chan<- interface{}
+}
+
+func (f *fakeSubscriptions) subscribe(events chan<- interface{}) error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	if f.failures > 0 {
+		f.failures--
+		return errors.New("node not reachable")
+	}
+	f.events = append(f.events, events)
+	return nil
+}
+
+func (f *fakeSubscriptions) last() (chan<- interface{}, int) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return f.events[len(f.events)-1], len(f.events)
+}
+
+func newFakeStreamHub(subs *fakeSubscriptions) *streamHub {
+	return &streamHub{
+		subscribe:  subs.subscribe,
+		backoff:    time.Millisecond,
+		maxBackoff: 4 * time.Millisecond,
+		streams:    make(map[chan streamEvent]bool),
+	}
+}
+
+func receiveStreamEvent(t *testing.T, stream chan streamEvent) streamEvent {
+	select {
+	case event := <-stream:
+		return event
+	case <-time.After(time.Second):
+		require.FailNow(t, "no stream event received")
+		return streamEvent{}
+	}
+}
+
+func newBlockHeaderEvent(height int64) tmtypes.EventDataNewBlockHeader {
+	return tmtypes.EventDataNewBlockHeader{Header: tmtypes.Header{Height: height}}
+}
+
+func TestStreamHubResubscribes(t *testing.T) {
+	subs := &fakeSubscriptions{}
+	hub := newFakeStreamHub(subs)
+
+	stream, err := hub.register()
+	require.Nil(t, err)
+
+	events, count := subs.last()
+	require.Equal(t, 1, count)
+	events <- newBlockHeaderEvent(5)
+	require.Equal(t, streamEvent{height: 5}, receiveStreamEvent(t, stream))
+
+	// the subscription gets lost and the node cannot be reached twice
+	subs.mtx.Lock()
+	subs.failures = 2
+	subs.mtx.Unlock()
+	close(events)
+
+	require.Equal(t, streamEvent{resync: true}, receiveStreamEvent(t, stream))
+	events, count = subs.last()
+	require.Equal(t, 2, count)
+
+	events <- newBlockHeaderEvent(9)
+	require.Equal(t, streamEvent{height: 9}, receiveStreamEvent(t, stream))
+}
+
+func TestStreamHubStopsWithoutStreams(t *testing.T) {
+	subs := &fakeSubscriptions{}
+	hub := newFakeStreamHub(subs)
+
+	stream, err := hub.register()
+	require.Nil(t, err)
+	hub.unregister(stream)
+
+	// without streams the hub does not subscribe again
+	events, _ := subs.last()
+	close(events)
+	stopped := false
+	for i := 0; i < 1000 && !stopped; i++ {
+		time.Sleep(time.Millisecond)
+		hub.mtx.Lock()
+		stopped = !hub.started
+		hub.mtx.Unlock()
+	}
+	require.True(t, stopped)
+
+	// the next stream starts a new subscription
+	stream, err = hub.register()
+	require.Nil(t, err)
+	events, count := subs.last()
+	require.Equal(t, 2, count)
+
+	events <- newBlockHeaderEvent(3)
+	require.Equal(t, streamEvent{height: 3}, receiveStreamEvent(t, stream))
+}
+
+func TestStreamHubRegisterFails(t *testing.T) {
+	subs := &fakeSubscriptions{failures: 1}
+	hub := newFakeStreamHub(subs)
+
+	_, err := hub.register()
+	require.NotNil(t, err)
+	require.False(t, hub.started)
+
+	_, err = hub.register()
+	require.Nil(t, err)
+	require.True(t, hub.started)
+}
+
+func TestStreamHubBroadcastKeepsResync(t *testing.T) {
+	hub := newFakeStreamHub(&fakeSubscriptions{})
+	stream := make(chan streamEvent, 1)
+	hub.streams[stream] = true
+
+	// a busy stream only gets the latest block, but still has to resync
+	hub.broadcast(streamEvent{resync: true})
+	hub.broadcast(streamEvent{height: 7})
+	require.Equal(t, streamEvent{height: 7, resync: true}, receiveStreamEvent(t, stream))
+
+	hub.broadcast(streamEvent{height: 8})
+	hub.broadcast(streamEvent{height: 9})
+	require.Equal(t, streamEvent{height: 9}, receiveStreamEvent(t, stream))
+}
+
+func TestStreamUpgraderCheckOrigin(t *testing.T) {
+	newRequest := func(origin string) *http.Request {
+		r := httptest.NewRequest("GET", "http://localhost:1317/exchange/stream", nil)
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+		return r
+	}
+
+	upgrader := newStreamUpgrader([]string{""})
+	require.True(t, upgrader.CheckOrigin(newRequest("")))
+	require.True(t, upgrader.CheckOrigin(newRequest("http://localhost:1317")))
+	require.False(t, upgrader.CheckOrigin(newRequest("https://dex.example.com")))
+
+	upgrader = newStreamUpgrader([]string{"https://dex.example.com", " https://wallet.example.com"})
+	require.True(t, upgrader.CheckOrigin(newRequest("https://dex.example.com")))
+	require.True(t, upgrader.CheckOrigin(newRequest("https://wallet.example.com")))
+	require.False(t, upgrader.CheckOrigin(newRequest("https://evil.example.com")))
+
+	upgrader = newStreamUpgrader([]string{"*"})
+	require.True(t, upgrader.CheckOrigin(newRequest("https://evil.example.com")))
+}
@@ -0,0 +1,77 @@
+package exchange
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// MaxDepthLevels is the maximum number of price levels per side of a depth query
+const MaxDepthLevels = 100
+
+// PriceLevel is the total open amount and the number of open orders at one price of an order book
+type PriceLevel struct {
+	Price      Price    `json:"price"`
+	Amount     sdk.Coin `json:"amount"`
+	OrderCount int      `json:"order_count"`
+}
+
+// OrderBookDepth is the aggregated (level 2) view of the buy and sell order books of a token pair. Best bid, best ask
+// and spread are nil if the respective order book is empty
+type OrderBookDepth struct {
+	AmountDenom string       `json:"amount_denom"`
+	PriceDenom  string       `json:"price_denom"`
+	Bids        []PriceLevel `json:"bids"` // buy orders, highest price first
+	Asks        []PriceLevel `json:"asks"` // sell orders, lowest price first
+	BestBid     *Price       `json:"best_bid"`
+	BestAsk     *Price       `json:"best_ask"`
+	Spread      *Price       `json:"spread"`
+}
+
+// GetOrderBookDepth aggregates the orders of the buy and sell order book of a token pair into at most the given
+// number of price levels per side, which is capped at MaxDepthLevels
+func GetOrderBookDepth(buyOrderBook OrderBook, sellOrderBook OrderBook, levels int) OrderBookDepth {
+	if levels > MaxDepthLevels {
+		levels = MaxDepthLevels
+	}
+
+	depth := OrderBookDepth{
+		AmountDenom: buyOrderBook.AmountDenom,
+		PriceDenom:  buyOrderBook.PriceDenom,
+		Bids:        getPriceLevels(buyOrderBook.Orders, levels),
+		Asks:        getPriceLevels(sellOrderBook.Orders, levels),
+	}
+
+	if len(buyOrderBook.Orders) > 0 {
+		depth.BestBid = &buyOrderBook.Orders[0].Price
+	}
+	if len(sellOrderBook.Orders) > 0 {
+		depth.BestAsk = &sellOrderBook.Orders[0].Price
+	}
+	if depth.BestBid != nil && depth.BestAsk != nil {
+		spread := NewPrice(depth.PriceDenom, depth.BestAsk.Amount.Sub(depth.BestBid.Amount))
+		depth.Spread = &spread
+	}
+
+	return depth
+}
+
+// getPriceLevels sums up the orders of an order book by price, keeping the order of the order book, which is sorted
+// by best price
+func getPriceLevels(orders []LimitOrder, levels int) []PriceLevel {
+	priceLevels := make([]PriceLevel, 0)
+
+	for _, order := range orders {
+		last := len(priceLevels) - 1
+		if last >= 0 && priceLevels[last].Price.IsEqual(order.Price) {
+			priceLevels[last].Amount = priceLevels[last].Amount.Plus(order.Amount)
+			priceLevels[last].OrderCount++
+			continue
+		}
+
+		if len(priceLevels) >= levels {
+			break
+		}
+		priceLevels = append(priceLevels, PriceLevel{order.Price, order.Amount, 1})
+	}
+
+	return priceLevels
+}
@@ -0,0 +1,44 @@
+package exchange
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+func TestGetOrderBookDepth(t *testing.T) {
+	expiresAt := time.Now().Add(time.Minute).UTC()
+	buyOrderBook := NewOrderBook(BuyOrder, "ETH", "RUNE")
+	sellOrderBook := NewOrderBook(SellOrder, "ETH", "RUNE")
+
+	// empty order books have no best prices
+	depth := GetOrderBookDepth(buyOrderBook, sellOrderBook, 10)
+	require.Len(t, depth.Bids, 0)
+	require.Len(t, depth.Asks, 0)
+	require.Nil(t, depth.BestBid)
+	require.Nil(t, depth.Spread)
+
+	buyOrderBook.Orders = []LimitOrder{
+		NewLimitOrder(1, nil, BuyOrder, sdk.NewInt64Coin("ETH", 10), NewInt64Price("RUNE", 8), expiresAt, GoodTillTime),
+		NewLimitOrder(2, nil, BuyOrder, sdk.NewInt64Coin("ETH", 20), NewInt64Price("RUNE", 8), expiresAt, GoodTillTime),
+		NewLimitOrder(3, nil, BuyOrder, sdk.NewInt64Coin("ETH", 5), NewInt64Price("RUNE", 7), expiresAt, GoodTillTime),
+		NewLimitOrder(4, nil, BuyOrder, sdk.NewInt64Coin("ETH", 5), NewInt64Price("RUNE", 6), expiresAt, GoodTillTime),
+	}
+	sellOrderBook.Orders = []LimitOrder{
+		NewLimitOrder(5, nil, SellOrder, sdk.NewInt64Coin("ETH", 3), NewPrice("RUNE", sdk.NewRat(17, 2)), expiresAt,
+			GoodTillTime),
+	}
+
+	depth = GetOrderBookDepth(buyOrderBook, sellOrderBook, 2)
+	require.Equal(t, []PriceLevel{
+		{NewInt64Price("RUNE", 8), sdk.NewInt64Coin("ETH", 30), 2},
+		{NewInt64Price("RUNE", 7), sdk.NewInt64Coin("ETH", 5), 1},
+	}, depth.Bids)
+	require.Equal(t, []PriceLevel{{NewPrice("RUNE", sdk.NewRat(17, 2)), sdk.NewInt64Coin("ETH", 3), 1}}, depth.Asks)
+	require.Equal(t, "8RUNE", depth.BestBid.String())
+	require.Equal(t, "8.5RUNE", depth.BestAsk.String())
+	require.Equal(t, "0.5RUNE", depth.Spread.String())
+}
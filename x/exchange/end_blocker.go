@@ -0,0 +1,30 @@
+package exchange
+
+import (
+	"encoding/json"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// EndBlocker clears the batch auctions of the block and returns the results and the prevented self-trades as tags
+func EndBlocker(ctx sdk.Context, k Keeper) sdk.Tags {
+	tags := sdk.NewTags()
+
+	for _, result := range k.clearBatchAuctions(ctx) {
+		b, err := json.Marshal(result)
+		if err != nil {
+			panic(err)
+		}
+		tags = tags.AppendTag("batch_auction", b)
+
+		for _, prevented := range result.PreventedSelfTrades {
+			b, err = json.Marshal(prevented)
+			if err != nil {
+				panic(err)
+			}
+			tags = tags.AppendTag("self_trade_prevented", b)
+		}
+	}
+
+	return tags
+}
@@ -1,6 +1,8 @@
 package exchange
 
 import (
+	"fmt"
+
 	sdk "github.com/cosmos/cosmos-sdk/types"
 )
 
@@ -18,6 +20,39 @@ const (
 	CodeAmountNotPositive  CodeType = 5
 	CodePriceNotPositive   CodeType = 6
 	CodeOrderBookDirection CodeType = 7
+	CodeInvalidTimeInForce CodeType = 8
+	CodeOrderWouldMatch    CodeType = 9
+	CodeOrderNotFillable   CodeType = 10
+	CodeOrderNotFound      CodeType = 11
+	CodeNotOrderOwner      CodeType = 12
+	CodeDenomMismatch      CodeType = 13
+	CodeUnauthorized       CodeType = 14
+	CodeInvalidMarketMode  CodeType = 15
+	CodeInvalidTickSize    CodeType = 16
+	CodeInvalidLotSize     CodeType = 17
+	CodeBelowMinNotional   CodeType = 18
+	CodeInvalidMarket      CodeType = 19
+	CodeMarketNotListed    CodeType = 20
+	CodeInvalidListing     CodeType = 21
+	CodeInvalidSTPMode     CodeType = 22
+	CodeInexactInversion   CodeType = 23
+	CodeInvalidDisplay     CodeType = 24
+	CodeInvalidExpiry      CodeType = 25
+	CodeInvalidClientID    CodeType = 26
+	CodeDuplicateClientID  CodeType = 27
+	CodeClientIDNotFound   CodeType = 28
+	CodeMaxOpenOrders      CodeType = 29
+	CodeMaxMarketOrders    CodeType = 30
+	CodeMissingDeposit     CodeType = 31
+	CodeInvalidBatch       CodeType = 32
+	CodeInvalidOCOGroup    CodeType = 33
+	CodeInvalidStop        CodeType = 34
+	CodeStopNotFound       CodeType = 35
+	CodeNoLiquidity        CodeType = 36
+	CodeMarketHalted       CodeType = 37
+	CodeOutsidePriceBand   CodeType = 38
+	CodeInvalidHalt        CodeType = 39
+	CodeInvalidInversion   CodeType = 40
 )
 
 // Invalid order kind error
@@ -55,3 +90,188 @@ func ErrOrderBookDirection(codespace sdk.CodespaceType) sdk.Error {
 	return sdk.NewError(codespace, CodeOrderBookDirection,
 		"orderbook direction is not supported, please swap amount and price denoms")
 }
+
+// Invalid time in force error
+func ErrInvalidTimeInForce(codespace sdk.CodespaceType) sdk.Error {
+	return sdk.NewError(codespace, CodeInvalidTimeInForce,
+		"time in force must be 'gtt', 'ioc', 'fok' or 'post-only'")
+}
+
+// Post-only order would match error
+func ErrOrderWouldMatch(codespace sdk.CodespaceType) sdk.Error {
+	return sdk.NewError(codespace, CodeOrderWouldMatch, "post-only order must not match any stored order")
+}
+
+// Fill-or-kill order cannot be filled completely error
+func ErrOrderNotFillable(codespace sdk.CodespaceType) sdk.Error {
+	return sdk.NewError(codespace, CodeOrderNotFillable, "fill-or-kill order cannot be filled completely")
+}
+
+// Order not found error
+func ErrOrderNotFound(codespace sdk.CodespaceType, orderID int64) sdk.Error {
+	return sdk.NewError(codespace, CodeOrderNotFound, fmt.Sprintf("open order with id %v not found", orderID))
+}
+
+// Sender is not the owner of the order error
+func ErrNotOrderOwner(codespace sdk.CodespaceType, orderID int64) sdk.Error {
+	return sdk.NewError(codespace, CodeNotOrderOwner, fmt.Sprintf("order with id %v belongs to another sender", orderID))
+}
+
+// Amount or price denom does not match the stored order error
+func ErrDenomMismatch(codespace sdk.CodespaceType) sdk.Error {
+	return sdk.NewError(codespace, CodeDenomMismatch, "denom of amount and price must match the stored order")
+}
+
+// Sender is not allowed to change the market configuration error
+func ErrUnauthorized(codespace sdk.CodespaceType) sdk.Error {
+	return sdk.NewError(codespace, CodeUnauthorized, "only the exchange authority may change the market configuration")
+}
+
+// Invalid market mode error
+func ErrInvalidMarketMode(codespace sdk.CodespaceType) sdk.Error {
+	return sdk.NewError(codespace, CodeInvalidMarketMode, "market mode must be 'continuous' or 'batch'")
+}
+
+// Time in force not supported by the market mode error
+func ErrTimeInForceNotSupported(codespace sdk.CodespaceType) sdk.Error {
+	return sdk.NewError(codespace, CodeInvalidTimeInForce,
+		"batch auction markets only support 'gtt' and 'ioc' orders")
+}
+
+// Price is not a multiple of the tick size error
+func ErrInvalidTickSize(codespace sdk.CodespaceType, tickSize sdk.Rat) sdk.Error {
+	return sdk.NewError(codespace, CodeInvalidTickSize,
+		fmt.Sprintf("price must be a multiple of the tick size %v", NewPrice("", tickSize)))
+}
+
+// Amount is not a multiple of the lot size error
+func ErrInvalidLotSize(codespace sdk.CodespaceType, lotSize sdk.Int) sdk.Error {
+	return sdk.NewError(codespace, CodeInvalidLotSize, fmt.Sprintf("amount must be a multiple of the lot size %v", lotSize))
+}
+
+// Total price is below the minimum notional error
+func ErrBelowMinNotional(codespace sdk.CodespaceType, minNotional sdk.Coin) sdk.Error {
+	return sdk.NewError(codespace, CodeBelowMinNotional, fmt.Sprintf("total price must be at least %v", minNotional))
+}
+
+// Invalid market configuration error
+func ErrInvalidMarket(codespace sdk.CodespaceType, msg string) sdk.Error {
+	return sdk.NewError(codespace, CodeInvalidMarket, msg)
+}
+
+// Market is not listed error
+func ErrMarketNotListed(codespace sdk.CodespaceType, amountDenom string, priceDenom string) sdk.Error {
+	return sdk.NewError(codespace, CodeMarketNotListed,
+		fmt.Sprintf("market with amount denom %v and price denom %v is not listed", amountDenom, priceDenom))
+}
+
+// Invalid market listing error
+func ErrInvalidListing(codespace sdk.CodespaceType, msg string) sdk.Error {
+	return sdk.NewError(codespace, CodeInvalidListing, msg)
+}
+
+// Invalid self-trade prevention mode error
+func ErrInvalidSelfTradePrevention(codespace sdk.CodespaceType) sdk.Error {
+	return sdk.NewError(codespace, CodeInvalidSTPMode,
+		"self-trade prevention must be 'cancel-newest', 'cancel-oldest', 'cancel-both' or 'decrement-and-cancel'")
+}
+
+// Order in the other direction than the order book cannot be converted exactly error
+func ErrInexactInversion(codespace sdk.CodespaceType, amount sdk.Coin, price Price) sdk.Error {
+	return sdk.NewError(codespace, CodeInexactInversion,
+		fmt.Sprintf("total price of %v at %v must be a whole number to convert the order to the order book", amount,
+			price))
+}
+
+// Invalid display amount of an iceberg order error
+func ErrInvalidDisplayAmount(codespace sdk.CodespaceType) sdk.Error {
+	return sdk.NewError(codespace, CodeInvalidDisplay, "display amount must not be negative")
+}
+
+// Invalid expiry of an order error
+func ErrInvalidExpiry(codespace sdk.CodespaceType, msg string) sdk.Error {
+	return sdk.NewError(codespace, CodeInvalidExpiry, msg)
+}
+
+// Invalid client order id error
+func ErrInvalidClientOrderID(codespace sdk.CodespaceType) sdk.Error {
+	return sdk.NewError(codespace, CodeInvalidClientID,
+		fmt.Sprintf("client order id must not be longer than %v characters", MaxClientOrderIDLength))
+}
+
+// Client order id already used by the sender error
+func ErrDuplicateClientOrderID(codespace sdk.CodespaceType, clientOrderID string) sdk.Error {
+	return sdk.NewError(codespace, CodeDuplicateClientID,
+		fmt.Sprintf("client order id %v has already been used", clientOrderID))
+}
+
+// Client order id not found error
+func ErrClientOrderIDNotFound(codespace sdk.CodespaceType, clientOrderID string) sdk.Error {
+	return sdk.NewError(codespace, CodeClientIDNotFound, fmt.Sprintf("client order id %v not found", clientOrderID))
+}
+
+// Maximum number of open orders of an account reached error
+func ErrMaxOpenOrders(codespace sdk.CodespaceType, max int64) sdk.Error {
+	return sdk.NewError(codespace, CodeMaxOpenOrders, fmt.Sprintf("account must not have more than %v open orders", max))
+}
+
+// Maximum number of open orders of an account in a market reached error
+func ErrMaxOpenOrdersInMarket(codespace sdk.CodespaceType, max int64) sdk.Error {
+	return sdk.NewError(codespace, CodeMaxMarketOrders,
+		fmt.Sprintf("account must not have more than %v open orders in a market", max))
+}
+
+// Insufficient coins to pay the order deposit error
+func ErrInsufficientOrderDeposit(codespace sdk.CodespaceType, deposit sdk.Coin) sdk.Error {
+	return sdk.NewError(codespace, CodeMissingDeposit,
+		fmt.Sprintf("must have at least %v left to pay the order deposit", deposit))
+}
+
+// Invalid batch of creates and cancels error
+func ErrInvalidBatch(codespace sdk.CodespaceType, msg string) sdk.Error {
+	return sdk.NewError(codespace, CodeInvalidBatch, msg)
+}
+
+// Invalid one-cancels-other group error
+func ErrInvalidOCOGroup(codespace sdk.CodespaceType, msg string) sdk.Error {
+	return sdk.NewError(codespace, CodeInvalidOCOGroup, msg)
+}
+
+// Invalid trailing stop error
+func ErrInvalidTrailingStop(codespace sdk.CodespaceType, msg string) sdk.Error {
+	return sdk.NewError(codespace, CodeInvalidStop, msg)
+}
+
+// Trailing stop not found error
+func ErrTrailingStopNotFound(codespace sdk.CodespaceType, stopID int64) sdk.Error {
+	return sdk.NewError(codespace, CodeStopNotFound, fmt.Sprintf("trailing stop with id %v not found", stopID))
+}
+
+// No orders to fill a market order error
+func ErrNoLiquidity(codespace sdk.CodespaceType, amountDenom string, priceDenom string) sdk.Error {
+	return sdk.NewError(codespace, CodeNoLiquidity, fmt.Sprintf("no orders to fill a market order in market %v/%v",
+		amountDenom, priceDenom))
+}
+
+// Market halted error
+func ErrMarketHalted(codespace sdk.CodespaceType, amountDenom string, priceDenom string) sdk.Error {
+	return sdk.NewError(codespace, CodeMarketHalted, fmt.Sprintf("market %v/%v is halted", amountDenom, priceDenom))
+}
+
+// Price outside of the price band of the market error
+func ErrOutsidePriceBand(codespace sdk.CodespaceType, reference Price, priceBand int64) sdk.Error {
+	return sdk.NewError(codespace, CodeOutsidePriceBand, fmt.Sprintf(
+		"price must not be more than %v basis points away from the last trade price %v", priceBand, reference))
+}
+
+// Invalid market halt error
+func ErrInvalidHalt(codespace sdk.CodespaceType, msg string) sdk.Error {
+	return sdk.NewError(codespace, CodeInvalidHalt, msg)
+}
+
+// Inverted order does not fit the market error
+func ErrInvalidInversion(codespace sdk.CodespaceType, amountDenom string, priceDenom string) sdk.Error {
+	return sdk.NewError(codespace, CodeInvalidInversion, fmt.Sprintf(
+		"order is too small for the tick and lot size of market %v/%v once converted, place it in that direction instead",
+		amountDenom, priceDenom))
+}
@@ -0,0 +1,51 @@
+package exchange
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// EscrowTotals compares the coins held by the escrow account with the coins locked for open orders, both per denom.
+// The balance covers the locked coins as long as every locked coin has been moved to the escrow account. Coins sent to
+// the escrow account directly are not locked for anything, so the balance may exceed the locked coins
+type EscrowTotals struct {
+	Balance sdk.Coins `json:"balance"` // coins held by the escrow account
+	Locked  sdk.Coins `json:"locked"`  // coins locked for open and collected orders, trailing stops and listing deposits
+}
+
+// IsReconciled checks if the escrow account holds at least the coins locked for open orders, trailing stops and
+// listing deposits
+func (et EscrowTotals) IsReconciled() bool {
+	return et.Balance.IsGTE(et.Locked)
+}
+
+// GetEscrowTotals sums up the coins locked for the orders in the given order books, the orders collected for batch
+// auctions, the given trailing stops and the listing deposits of the given markets and compares them with the balance
+// of the escrow account
+func GetEscrowTotals(balance sdk.Coins, orderBooks []OrderBook, batchOrders []LimitOrder, stops []TrailingStop,
+	markets []Market) EscrowTotals {
+	locked := sdk.Coins{}
+
+	for _, orderBook := range orderBooks {
+		for _, order := range orderBook.Orders {
+			locked = locked.Plus(order.getEscrowedCoins())
+		}
+	}
+
+	for _, order := range batchOrders {
+		locked = locked.Plus(order.getEscrowedCoins())
+	}
+
+	for _, stop := range stops {
+		locked = locked.Plus(stop.Locked)
+	}
+
+	for _, market := range markets {
+		locked = locked.Plus(market.Deposit)
+	}
+
+	if balance == nil {
+		balance = sdk.Coins{}
+	}
+
+	return EscrowTotals{balance, locked}
+}
@@ -1,38 +1,308 @@
 package exchange
 
-import sdk "github.com/cosmos/cosmos-sdk/types"
+import (
+	"bytes"
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
 
 // GenesisState - all exchange state that must be provided at genesis
 type GenesisState struct {
-	StartingOrderID int64 `json:"starting_orderID"`
+	Params          Params      `json:"params"`
+	StartingOrderID int64       `json:"starting_orderID"`
+	OrderBooks      []OrderBook `json:"order_books"`
+	// LockedCoins is the sum of the coins and deposits locked for all open orders in the order books and the trailing
+	// stops and the listing deposits of the markets, which the escrow account holds. It is not stored, but used to
+	// validate that the locked coins can be reconstructed from the order books, trailing stops and markets
+	LockedCoins     sdk.Coins `json:"locked_coins"`
+	StartingTradeID int64     `json:"starting_tradeID"`
+	Trades          []Trade   `json:"trades"`
+	Candles         []Candle  `json:"candles"`
+	Markets         []Market  `json:"markets"`
+	// trailing stops that have not been submitted yet, they share the order ids
+	TrailingStops []TrailingStop `json:"trailing_stops"`
+	// client order ids of all orders, including the closed ones, which keep their client order ids used
+	ClientOrderIDs []ClientOrderID `json:"client_order_ids"`
+	// ids of the governance proposals that have halted or resumed a market and cannot be used again
+	UsedHaltProposalIDs []int64 `json:"used_halt_proposal_ids"`
+	// ids of the governance proposals that have listed a market and cannot be used again
+	UsedListingProposalIDs []int64 `json:"used_listing_proposal_ids"`
 }
 
 func NewGenesisState(startingOrderID int64) GenesisState {
 	return GenesisState{
+		Params:          DefaultParams(),
 		StartingOrderID: startingOrderID,
+		StartingTradeID: 1,
 	}
 }
 
 // get raw genesis raw message for testing
 func DefaultGenesisState() GenesisState {
 	return GenesisState{
+		Params:          DefaultParams(),
 		StartingOrderID: 1,
+		StartingTradeID: 1,
+	}
+}
+
+// ValidateGenesis checks that the order books are consistent and that the coins locked for their open orders and the
+// trailing stops and the listing deposits of the markets add up to the locked coins of the genesis state
+// nolint gocyclo
+func ValidateGenesis(data GenesisState) error {
+	err := ValidateParams(data.Params)
+	if err != nil {
+		return err
+	}
+
+	if data.StartingOrderID < 0 {
+		return fmt.Errorf("starting order id must not be negative, is %v", data.StartingOrderID)
+	}
+	if data.StartingTradeID < 0 {
+		return fmt.Errorf("starting trade id must not be negative, is %v", data.StartingTradeID)
+	}
+
+	// order ids by client order id key
+	usedClientOrderIDs := make(map[string]int64)
+	for _, entry := range data.ClientOrderIDs {
+		key := string(MakeKeyClientOrderID(entry.Sender, entry.ClientOrderID))
+		if _, ok := usedClientOrderIDs[key]; ok || len(entry.Sender) == 0 || entry.ClientOrderID == "" ||
+			len(entry.ClientOrderID) > MaxClientOrderIDLength {
+			return fmt.Errorf("invalid or duplicate client order id %v of order %v", entry.ClientOrderID,
+				entry.OrderID)
+		}
+		if entry.OrderID < 0 || entry.OrderID >= data.StartingOrderID {
+			return fmt.Errorf("order id %v of client order id %v must be below the starting order id %v",
+				entry.OrderID, entry.ClientOrderID, data.StartingOrderID)
+		}
+		usedClientOrderIDs[key] = entry.OrderID
+	}
+
+	orderIDs := make(map[int64]bool)
+	clientOrderIDs := make(map[string]bool)
+	lockedCoins := sdk.Coins{}
+
+	for _, ob := range data.OrderBooks {
+		if !bytes.Equal(ob.Key, MakeKeyOrderBook(ob.Kind, ob.AmountDenom, ob.PriceDenom)) {
+			return fmt.Errorf("invalid key of order book %v", ob.String())
+		}
+
+		for i, order := range ob.Orders {
+			if order.OrderID < 0 || order.OrderID >= data.StartingOrderID {
+				return fmt.Errorf("order id %v must be below the starting order id %v", order.OrderID,
+					data.StartingOrderID)
+			}
+			if orderIDs[order.OrderID] {
+				return fmt.Errorf("duplicate order id %v", order.OrderID)
+			}
+			orderIDs[order.OrderID] = true
+
+			if order.ClientOrderID != "" {
+				key := string(MakeKeyClientOrderID(order.Sender, order.ClientOrderID))
+				if clientOrderIDs[key] || len(order.ClientOrderID) > MaxClientOrderIDLength {
+					return fmt.Errorf("invalid or duplicate client order id of order %v", order.OrderID)
+				}
+				if orderID, ok := usedClientOrderIDs[key]; ok && orderID != order.OrderID {
+					return fmt.Errorf("client order id of order %v is used by order %v", order.OrderID, orderID)
+				}
+				clientOrderIDs[key] = true
+			}
+
+			if order.Kind != ob.Kind || order.Amount.Denom != ob.AmountDenom || order.Price.Denom != ob.PriceDenom {
+				return fmt.Errorf("order %v does not belong to order book %v", order.OrderID, string(ob.Key))
+			}
+			if !order.Amount.IsPositive() || !order.Price.IsPositive() {
+				return fmt.Errorf("order %v must have a positive amount and price", order.OrderID)
+			}
+			if order.ExpiresAtHeight < 0 {
+				return fmt.Errorf("order %v must not have a negative expiry height", order.OrderID)
+			}
+			if order.Iceberg != nil && (order.Iceberg.DisplayAmount.Sign() <= 0 || order.Iceberg.Reserve.Sign() < 0) {
+				return fmt.Errorf("iceberg order %v must have a positive display amount and no negative reserve",
+					order.OrderID)
+			}
+			if len(order.OCOGroup) > MaxOCOGroupLength {
+				return fmt.Errorf("order %v has a too long one-cancels-other group", order.OrderID)
+			}
+			if !order.Deposit.IsValid() || !order.Deposit.IsNotNegative() {
+				return fmt.Errorf("order %v must have a valid deposit", order.OrderID)
+			}
+			if len(order.Sender) == 0 {
+				return fmt.Errorf("order %v has no sender", order.OrderID)
+			}
+			if i > 0 && shouldInsertBefore(ob.Kind, order.Price, ob.Orders[i-1].Price) {
+				return fmt.Errorf("orders of order book %v are not sorted by price", string(ob.Key))
+			}
+
+			lockedCoins = lockedCoins.Plus(order.getEscrowedCoins())
+		}
+	}
+
+	markets := make(map[string]bool)
+	for _, market := range data.Markets {
+		err = validateMarket(market)
+		if err != nil {
+			return err
+		}
+		if markets[string(MakeKeyMarket(market.AmountDenom, market.PriceDenom))] {
+			return fmt.Errorf("duplicate market %v", market.String())
+		}
+		markets[string(MakeKeyMarket(market.AmountDenom, market.PriceDenom))] = true
+
+		if !market.Deposit.IsValid() || !market.Deposit.IsNotNegative() {
+			return fmt.Errorf("market %v must have a valid deposit", market.String())
+		}
+		if !market.Deposit.IsZero() && (!market.IsListed() || len(market.Lister) == 0) {
+			return fmt.Errorf("deposit of market %v must belong to the lister of a listed market", market.String())
+		}
+		lockedCoins = lockedCoins.Plus(market.Deposit)
+	}
+
+	for i, trade := range data.Trades {
+		if trade.TradeID < 1 || trade.TradeID >= data.StartingTradeID {
+			return fmt.Errorf("trade id %v must be between 1 and the starting trade id %v", trade.TradeID,
+				data.StartingTradeID)
+		}
+		if i > 0 && trade.TradeID <= data.Trades[i-1].TradeID {
+			return fmt.Errorf("trades must be sorted by ascending trade id, %v is not", trade.TradeID)
+		}
+	}
+
+	for _, proposalID := range data.UsedHaltProposalIDs {
+		if proposalID < 1 {
+			return fmt.Errorf("used halt proposal id %v must be positive", proposalID)
+		}
+	}
+	for _, proposalID := range data.UsedListingProposalIDs {
+		if proposalID < 1 {
+			return fmt.Errorf("used listing proposal id %v must be positive", proposalID)
+		}
+	}
+
+	for _, stop := range data.TrailingStops {
+		if stop.StopID < 0 || stop.StopID >= data.StartingOrderID || orderIDs[stop.StopID] {
+			return fmt.Errorf("trailing stop id %v must be unique and below the starting order id %v", stop.StopID,
+				data.StartingOrderID)
+		}
+		orderIDs[stop.StopID] = true
+
+		err = checkTrailingStop(DefaultCodespace, stop.Kind, stop.Amount, stop.PriceDenom, stop.TrailingOffset,
+			stop.TrailingRate, stop.Market, stop.LimitOffset)
+		if err != nil {
+			return fmt.Errorf("invalid trailing stop %v: %v", stop.StopID, err.Error())
+		}
+		if stop.ReferencePrice.Denom != stop.PriceDenom || !stop.ReferencePrice.IsPositive() {
+			return fmt.Errorf("trailing stop %v must have a positive reference price", stop.StopID)
+		}
+		if len(stop.Sender) == 0 {
+			return fmt.Errorf("trailing stop %v has no sender", stop.StopID)
+		}
+		if !stop.Locked.IsValid() || !stop.Locked.IsNotNegative() {
+			return fmt.Errorf("trailing stop %v must have valid locked coins", stop.StopID)
+		}
+		lockedCoins = lockedCoins.Plus(stop.Locked)
+	}
+
+	if !lockedCoins.IsEqual(data.LockedCoins) {
+		return fmt.Errorf("coins locked for the orders, trailing stops and markets (%v) do not match the locked "+
+			"coins (%v)", lockedCoins, data.LockedCoins)
 	}
+
+	return nil
 }
 
 // InitGenesis - store genesis parameters
 func InitGenesis(ctx sdk.Context, k Keeper, data GenesisState) {
-	err := k.setInitialOrderID(ctx, data.StartingOrderID)
+	err := ValidateGenesis(data)
 	if err != nil {
-		panic(err)
+		panic(ErrInvalidGenesis(k.codespace, err.Error()))
+	}
+
+	// the escrow account is imported with the other accounts before and must hold the locked coins
+	balance := k.bankKeeper.GetCoins(ctx, EscrowAddress)
+	if !balance.IsGTE(data.LockedCoins) {
+		panic(ErrInvalidGenesis(k.codespace, fmt.Sprintf("escrow account holds %v, less than the locked coins %v",
+			balance, data.LockedCoins)))
+	}
+
+	k.setParams(ctx, data.Params)
+
+	err2 := k.setInitialOrderID(ctx, data.StartingOrderID)
+	if err2 != nil {
+		panic(err2)
+	}
+
+	// the order books are stored as they are, the index entries of their open orders are reconstructed
+	for _, ob := range data.OrderBooks {
+		k.setOrderBook(ctx, ob)
+		for _, order := range ob.Orders {
+			k.indexLimitOrder(ctx, order, ob.Key)
+			k.setClientOrderID(ctx, order.Sender, order.ClientOrderID, order.OrderID)
+		}
+	}
+
+	// a missing starting trade id keeps the default of 1
+	if data.StartingTradeID > 0 {
+		k.setNextTradeID(ctx, data.StartingTradeID)
+	}
+	for _, trade := range data.Trades {
+		k.setTrade(ctx, trade)
+	}
+	for _, candle := range data.Candles {
+		k.setCandle(ctx, candle)
+	}
+	for _, market := range data.Markets {
+		k.setMarket(ctx, market)
+	}
+	for _, stop := range data.TrailingStops {
+		k.setTrailingStop(ctx, stop)
+		k.addOpenOrderCount(ctx, stop.Sender, stop.Amount.Denom, stop.PriceDenom, 1)
+	}
+	for _, entry := range data.ClientOrderIDs {
+		k.setClientOrderID(ctx, entry.Sender, entry.ClientOrderID, entry.OrderID)
+	}
+	for _, proposalID := range data.UsedHaltProposalIDs {
+		k.setHaltProposalUsed(ctx, proposalID)
+	}
+	for _, proposalID := range data.UsedListingProposalIDs {
+		k.setListingProposalUsed(ctx, proposalID)
 	}
 }
 
 // WriteGenesis - output genesis parameters
 func WriteGenesis(ctx sdk.Context, k Keeper) GenesisState {
-	startingOrderID, _ := k.getNewOrderID(ctx)
+	orderBooks := k.getAllOrderBooks(ctx)
+
+	lockedCoins := sdk.Coins{}
+	for _, ob := range orderBooks {
+		for _, order := range ob.Orders {
+			lockedCoins = lockedCoins.Plus(order.getEscrowedCoins())
+		}
+	}
+
+	stops := k.getAllTrailingStops(ctx)
+	for _, stop := range stops {
+		lockedCoins = lockedCoins.Plus(stop.Locked)
+	}
+
+	markets := k.getAllMarkets(ctx)
+	for _, market := range markets {
+		lockedCoins = lockedCoins.Plus(market.Deposit)
+	}
 
 	return GenesisState{
-		StartingOrderID: startingOrderID,
+		Params:                 k.getParams(ctx),
+		StartingOrderID:        k.getLastOrderID(ctx) + 1,
+		OrderBooks:             orderBooks,
+		LockedCoins:            lockedCoins,
+		StartingTradeID:        k.getNextTradeID(ctx),
+		Trades:                 k.getAllTrades(ctx),
+		Candles:                k.getAllCandles(ctx),
+		Markets:                markets,
+		TrailingStops:          stops,
+		ClientOrderIDs:         k.getAllClientOrderIDs(ctx),
+		UsedHaltProposalIDs:    k.getUsedHaltProposalIDs(ctx),
+		UsedListingProposalIDs: k.getUsedListingProposalIDs(ctx),
 	}
 }
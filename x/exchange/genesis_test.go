@@ -0,0 +1,225 @@
+package exchange
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/tendermint/abci/types"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/wire"
+	"github.com/cosmos/cosmos-sdk/x/auth"
+	"github.com/cosmos/cosmos-sdk/x/bank"
+	"github.com/cosmos/cosmos-sdk/x/gov"
+	"github.com/cosmos/cosmos-sdk/x/params"
+	"github.com/cosmos/cosmos-sdk/x/stake"
+)
+
+func setupGenesisState(t *testing.T) (sdk.Context, Keeper, sdk.AccAddress, sdk.AccAddress) {
+	ctx := setupContext(exchangeKey)
+	keeper, _, bankKeeper, buyer, seller := setupKeepers(exchangeKey, ctx)
+	bankKeeper.SetCoins(ctx, buyer, sdk.Coins{sdk.NewInt64Coin("RUNE", 2000)})
+	bankKeeper.SetCoins(ctx, seller, sdk.Coins{sdk.NewInt64Coin("ETH", 250)})
+	ctx = ctx.WithBlockHeight(3).WithBlockHeader(abci.Header{Time: time.Unix(1540000000, 0).UTC()})
+
+	expiresAt := time.Now().Add(time.Minute).UTC()
+
+	_, _, err := keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 10), NewInt64Price("RUNE", 5),
+		OrderOptions{ExpiresAt: expiresAt})
+	require.Nil(t, err)
+	_, _, err = keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("BTC", 20), NewInt64Price("RUNE", 3),
+		OrderOptions{ExpiresAt: expiresAt})
+	require.Nil(t, err)
+	_, _, err = keeper.processLimitOrder(ctx, seller, SellOrder, sdk.NewInt64Coin("ETH", 14), NewInt64Price("RUNE", 5),
+		OrderOptions{ExpiresAt: expiresAt})
+	require.Nil(t, err)
+	_, _, err = keeper.processLimitOrder(ctx, seller, SellOrder, sdk.NewInt64Coin("ETH", 30), NewInt64Price("RUNE", 9),
+		OrderOptions{ExpiresAt: expiresAt})
+	require.Nil(t, err)
+
+	market := keeper.getMarket(ctx, "BTC", "RUNE")
+	market.Mode = BatchAuctionMode
+	keeper.setMarket(ctx, market)
+
+	return ctx, keeper, buyer, seller
+}
+
+func TestWriteGenesis(t *testing.T) {
+	ctx, keeper, _, _ := setupGenesisState(t)
+
+	genesis := WriteGenesis(ctx, keeper)
+	require.Nil(t, ValidateGenesis(genesis))
+	require.Equal(t, int64(5), genesis.StartingOrderID)
+	require.Equal(t, int64(2), genesis.StartingTradeID)
+	require.Len(t, genesis.OrderBooks, 4)
+	require.Len(t, genesis.Trades, 1)
+	require.Len(t, genesis.Candles, len(CandleIntervals))
+	// 20BTC@3RUNE, 4ETH and 30ETH
+	require.Equal(t, "34ETH,60RUNE", genesis.LockedCoins.String())
+
+	// writing the genesis does not change the next order id
+	require.Equal(t, genesis, WriteGenesis(ctx, keeper))
+	orderID, err := keeper.getNewOrderID(ctx)
+	require.Nil(t, err)
+	require.Equal(t, int64(5), orderID)
+}
+
+// setupImportKeeper returns the keepers of a fresh store to import a genesis state into
+func setupImportKeeper() (sdk.Context, Keeper, bank.Keeper) {
+	importKey := sdk.NewKVStoreKey("importTestKey")
+	importCtx := setupContext(importKey)
+	cdc := wire.NewCodec()
+	auth.RegisterBaseAccount(cdc)
+	importBankKeeper := bank.NewKeeper(auth.NewAccountMapper(cdc, importKey, auth.ProtoBaseAccount))
+	importParamsKeeper := params.NewKeeper(cdc, importKey)
+	importStakeKeeper := stake.NewKeeper(cdc, importKey, importBankKeeper, stake.DefaultCodespace)
+	importGovKeeper := gov.NewKeeper(cdc, importKey, importParamsKeeper.Setter(), importBankKeeper, importStakeKeeper,
+		gov.DefaultCodespace)
+	importKeeper := NewKeeper(importKey, importBankKeeper, auth.NewFeeCollectionKeeper(cdc, importKey), importGovKeeper,
+		importParamsKeeper.Setter(), DefaultCodespace)
+	return importCtx, importKeeper, importBankKeeper
+}
+
+func TestGenesisRoundTrip(t *testing.T) {
+	ctx, keeper, buyer, seller := setupGenesisState(t)
+	genesis := WriteGenesis(ctx, keeper)
+
+	// import into a fresh store
+	importCtx, importKeeper, importBankKeeper := setupImportKeeper()
+	// the escrow account is imported with the other accounts before
+	importBankKeeper.SetCoins(importCtx, EscrowAddress, genesis.LockedCoins)
+	InitGenesis(importCtx, importKeeper, genesis)
+	require.Equal(t, genesis, WriteGenesis(importCtx, importKeeper))
+
+	// the indexes are reconstructed
+	require.Equal(t, keeper.getOpenLimitOrdersBySender(ctx, buyer), importKeeper.getOpenLimitOrdersBySender(importCtx, buyer))
+	require.Equal(t, keeper.getOpenLimitOrdersBySender(ctx, seller),
+		importKeeper.getOpenLimitOrdersBySender(importCtx, seller))
+	require.Equal(t, keeper.getTradesByAccount(ctx, buyer, 1, 10), importKeeper.getTradesByAccount(importCtx, buyer, 1, 10))
+	require.Equal(t, keeper.getTradesByPair(ctx, "ETH", "RUNE", 1, 10),
+		importKeeper.getTradesByPair(importCtx, "ETH", "RUNE", 1, 10))
+	for _, ob := range genesis.OrderBooks {
+		for _, order := range ob.Orders {
+			_, _, err := importKeeper.findLimitOrder(importCtx, order.OrderID)
+			require.Nil(t, err)
+		}
+	}
+
+	// the imported order can be cancelled and its coins are unlocked
+	openOrders := importKeeper.getOpenLimitOrdersBySender(importCtx, buyer)
+	_, _, err := importKeeper.cancelLimitOrder(importCtx, buyer, openOrders[0].Order.OrderID)
+	require.Nil(t, err)
+	require.Equal(t, sdk.Coins{openOrders[0].LockedCoins}, importBankKeeper.GetCoins(importCtx, buyer))
+}
+
+// Test if the import requires the escrow account to hold the locked coins, but tolerates coins sent to it directly
+func TestGenesisEscrowBalance(t *testing.T) {
+	ctx, keeper, _, _ := setupGenesisState(t)
+	genesis := WriteGenesis(ctx, keeper)
+	require.True(t, genesis.LockedCoins.IsPositive())
+
+	importCtx, importKeeper, importBankKeeper := setupImportKeeper()
+	require.Panics(t, func() {
+		InitGenesis(importCtx, importKeeper, genesis)
+	})
+
+	importCtx, importKeeper, importBankKeeper = setupImportKeeper()
+	surplus := genesis.LockedCoins.Plus(sdk.Coins{sdk.NewInt64Coin("RUNE", 1)})
+	importBankKeeper.SetCoins(importCtx, EscrowAddress, surplus)
+	InitGenesis(importCtx, importKeeper, genesis)
+
+	totals := importKeeper.getEscrowTotals(importCtx)
+	require.Equal(t, EscrowTotals{surplus, genesis.LockedCoins}, totals)
+	require.True(t, totals.IsReconciled())
+}
+
+// Test if the client order ids of closed orders are exported and stay used after the import
+func TestGenesisClientOrderIDs(t *testing.T) {
+	ctx, keeper, buyer, _ := setupGenesisState(t)
+	expiresAt := time.Now().Add(time.Minute).UTC()
+
+	closed, _, err := keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 10), NewInt64Price("RUNE", 2),
+		OrderOptions{ExpiresAt: expiresAt, ClientOrderID: "bot:1"})
+	require.Nil(t, err)
+	_, _, err = keeper.cancelLimitOrder(ctx, buyer, closed.OrderID)
+	require.Nil(t, err)
+	open, _, err := keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 10), NewInt64Price("RUNE", 2),
+		OrderOptions{ExpiresAt: expiresAt, ClientOrderID: "bot:2"})
+	require.Nil(t, err)
+
+	genesis := WriteGenesis(ctx, keeper)
+	require.Nil(t, ValidateGenesis(genesis))
+	require.Equal(t, []ClientOrderID{{buyer, "bot:1", closed.OrderID}, {buyer, "bot:2", open.OrderID}},
+		genesis.ClientOrderIDs)
+
+	importCtx, importKeeper, importBankKeeper := setupImportKeeper()
+	importBankKeeper.SetCoins(importCtx, EscrowAddress, genesis.LockedCoins)
+	InitGenesis(importCtx, importKeeper, genesis)
+	require.Equal(t, genesis, WriteGenesis(importCtx, importKeeper))
+
+	// the client order id of the closed order cannot be used again
+	importBankKeeper.SetCoins(importCtx, buyer, sdk.Coins{sdk.NewInt64Coin("RUNE", 100)})
+	_, _, err = importKeeper.processLimitOrder(importCtx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 10),
+		NewInt64Price("RUNE", 2), OrderOptions{ExpiresAt: expiresAt, ClientOrderID: "bot:1"})
+	require.Equal(t, CodeDuplicateClientID, err.Code())
+	orderID, err := importKeeper.getOrderIDByClientOrderID(importCtx, buyer, "bot:1")
+	require.Nil(t, err)
+	require.Equal(t, closed.OrderID, orderID)
+
+	// an open order must not use the client order id of another order
+	genesis.ClientOrderIDs[1].OrderID = closed.OrderID
+	require.NotNil(t, ValidateGenesis(genesis))
+	genesis.ClientOrderIDs[1] = genesis.ClientOrderIDs[0]
+	require.NotNil(t, ValidateGenesis(genesis))
+}
+
+func TestValidateGenesis(t *testing.T) {
+	ctx, keeper, _, _ := setupGenesisState(t)
+
+	genesis := WriteGenesis(ctx, keeper)
+	genesis.LockedCoins = sdk.Coins{sdk.NewInt64Coin("ETH", 34)}
+	require.NotNil(t, ValidateGenesis(genesis))
+
+	genesis = WriteGenesis(ctx, keeper)
+	genesis.StartingOrderID = 4
+	require.NotNil(t, ValidateGenesis(genesis))
+
+	genesis = WriteGenesis(ctx, keeper)
+	genesis.StartingTradeID = 1
+	require.NotNil(t, ValidateGenesis(genesis))
+
+	genesis = WriteGenesis(ctx, keeper)
+	genesis.OrderBooks[0].Orders[0].Price.Denom = "BTC"
+	require.NotNil(t, ValidateGenesis(genesis))
+
+	genesis = WriteGenesis(ctx, keeper)
+	genesis.OrderBooks = append(genesis.OrderBooks, genesis.OrderBooks[0])
+	require.NotNil(t, ValidateGenesis(genesis))
+
+	require.Panics(t, func() {
+		InitGenesis(setupContext(sdk.NewKVStoreKey("invalidTestKey")), keeper, NewGenesisState(-1))
+	})
+}
+
+func TestValidateGenesisParams(t *testing.T) {
+	genesis := DefaultGenesisState()
+	genesis.Params.MakerFeeRate = -1
+	require.NotNil(t, ValidateGenesis(genesis))
+
+	genesis = DefaultGenesisState()
+	genesis.Params.TakerFeeRate = 10001
+	require.NotNil(t, ValidateGenesis(genesis))
+
+	genesis = DefaultGenesisState()
+	genesis.Params = Params{MakerFeeRate: 10, TakerFeeRate: 20}
+	require.Nil(t, ValidateGenesis(genesis))
+
+	genesis = DefaultGenesisState()
+	genesis.Markets = []Market{NewMarket("ETH", "ETH", BatchAuctionMode)}
+	require.NotNil(t, ValidateGenesis(genesis))
+
+	genesis = DefaultGenesisState()
+	genesis.Markets = []Market{NewMarket("ETH", "RUNE", BatchAuctionMode), NewMarket("ETH", "RUNE", ContinuousMode)}
+	require.NotNil(t, ValidateGenesis(genesis))
+}
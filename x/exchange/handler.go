@@ -14,6 +14,26 @@ func NewHandler(keeper Keeper) sdk.Handler {
 		switch msg := msg.(type) {
 		case MsgCreateLimitOrder:
 			return handleMsgCreateLimitOrder(keeper, ctx, msg)
+		case MsgCancelLimitOrder:
+			return handleMsgCancelLimitOrder(keeper, ctx, msg)
+		case MsgReplaceLimitOrder:
+			return handleMsgReplaceLimitOrder(keeper, ctx, msg)
+		case MsgSetMarketMode:
+			return handleMsgSetMarketMode(keeper, ctx, msg)
+		case MsgSetMarketConfig:
+			return handleMsgSetMarketConfig(keeper, ctx, msg)
+		case MsgSetMarketHalt:
+			return handleMsgSetMarketHalt(keeper, ctx, msg)
+		case MsgListMarket:
+			return handleMsgListMarket(keeper, ctx, msg)
+		case MsgDelistMarket:
+			return handleMsgDelistMarket(keeper, ctx, msg)
+		case MsgBatchOrders:
+			return handleMsgBatchOrders(keeper, ctx, msg)
+		case MsgCreateTrailingStop:
+			return handleMsgCreateTrailingStop(keeper, ctx, msg)
+		case MsgCancelTrailingStop:
+			return handleMsgCancelTrailingStop(keeper, ctx, msg)
 		default:
 			errMsg := fmt.Sprintf("Unrecognized exchange msg type: %v", reflect.TypeOf(msg).Name())
 			return sdk.ErrUnknownRequest(errMsg).Result()
@@ -23,8 +43,7 @@ func NewHandler(keeper Keeper) sdk.Handler {
 
 // Handle eMsgCreateLimitOrder This is the engine of your module
 func handleMsgCreateLimitOrder(k Keeper, ctx sdk.Context, msg MsgCreateLimitOrder) sdk.Result {
-	processed, filled, err := k.processLimitOrder(ctx, msg.Sender, msg.Kind, msg.Amount, msg.Price, msg.ExpiresAt)
-
+	processed, filled, inverted, err := createLimitOrder(k, ctx, msg)
 	if err != nil {
 		return err.Result()
 	}
@@ -32,9 +51,249 @@ func handleMsgCreateLimitOrder(k Keeper, ctx sdk.Context, msg MsgCreateLimitOrde
 	type toJSON struct {
 		Processed ProcessedLimitOrder `json:"processed"`
 		Filled    []FilledLimitOrder  `json:"filled"`
+		Inverted  bool                `json:"inverted"` // the order is stored in the order book of the other direction
+	}
+
+	b, err2 := json.Marshal(toJSON{processed, filled, inverted})
+	if err2 != nil {
+		return sdk.ErrInternal(fmt.Sprintf("Error marshalling json: %v", err2)).Result()
+	}
+
+	resultLog := fmt.Sprintf("json%vjson", string(b))
+
+	tags, err := getOrderTags(processed, filled)
+	if err != nil {
+		return err.Result()
+	}
+
+	return sdk.Result{Log: resultLog, Tags: tags}
+}
+
+// createLimitOrder processes the order of a MsgCreateLimitOrder. Orders in the other direction than the order book
+// are converted to the order book and fitted to the tick and lot size of the market, their fills are reported in the
+// terms of the order. Returns whether the order has been converted
+func createLimitOrder(k Keeper, ctx sdk.Context, msg MsgCreateLimitOrder) (ProcessedLimitOrder, []FilledLimitOrder,
+	bool, sdk.Error) {
+	kind, amount, price, inverted, err := NormalizeOrder(msg.Kind, msg.Amount, msg.Price)
+	if err != nil {
+		return ProcessedLimitOrder{}, nil, false, err
+	}
+
+	// an unset display amount shows the whole amount
+	displayAmount := sdk.ZeroInt()
+	if msg.DisplayAmount != (sdk.Int{}) {
+		displayAmount, err = NormalizeDisplayAmount(msg.DisplayAmount, msg.Amount, msg.Price)
+		if err != nil {
+			return ProcessedLimitOrder{}, nil, false, err
+		}
+	}
+
+	// orders of unlisted markets are rejected by the market rules
+	if market := k.getMarket(ctx, amount.Denom, price.Denom); inverted && market.IsListed() {
+		amount, price, displayAmount, err = SnapInvertedOrder(kind, amount, price, displayAmount, market)
+		if err != nil {
+			return ProcessedLimitOrder{}, nil, false, err
+		}
+	}
+
+	processed, filled, err := k.processLimitOrder(ctx, msg.Sender, kind, amount, price, OrderOptions{
+		ExpiresAt:           msg.ExpiresAt,
+		ExpiresAtHeight:     msg.ExpiresAtHeight,
+		TimeInForce:         msg.TimeInForce,
+		SelfTradePrevention: msg.SelfTradePrevention,
+		DisplayAmount:       displayAmount,
+		ClientOrderID:       msg.ClientOrderID,
+		OCOGroup:            msg.OCOGroup,
+	})
+	if err != nil {
+		return ProcessedLimitOrder{}, nil, false, err
+	}
+
+	if inverted {
+		for i := range filled {
+			filled[i] = invertFilledLimitOrder(filled[i])
+		}
+	}
+
+	return processed, filled, inverted, nil
+}
+
+// getOrderTags returns the tags that publish every fill and every prevented self-trade of a processed order as events.
+// The client order ids of the filled orders are tagged on their own as well, so that the fills of an order can be
+// found by its client order id
+func getOrderTags(processed ProcessedLimitOrder, filled []FilledLimitOrder) (sdk.Tags, sdk.Error) {
+	tags := sdk.NewTags()
+	for _, f := range filled {
+		b, err := json.Marshal(OrderFill{processed.OrderID, f.OrderID, f.FilledAmount, f.FilledPrice, f.MakerFee,
+			f.TakerFee, processed.ClientOrderID, f.ClientOrderID})
+		if err != nil {
+			return nil, sdk.ErrInternal(fmt.Sprintf("Error marshalling json: %v", err))
+		}
+		tags = tags.AppendTag("fill", b)
+		if f.ClientOrderID != "" {
+			tags = tags.AppendTag("client_order_id", []byte(f.ClientOrderID))
+		}
+	}
+	if len(filled) > 0 && processed.ClientOrderID != "" {
+		tags = tags.AppendTag("client_order_id", []byte(processed.ClientOrderID))
+	}
+
+	selfTradeTags, err := getSelfTradeTags(processed.PreventedSelfTrades)
+	if err != nil {
+		return nil, err
+	}
+	return tags.AppendTags(selfTradeTags), nil
+}
+
+// getSelfTradeTags returns the tags that publish every prevented self-trade as an event
+func getSelfTradeTags(prevented []PreventedSelfTrade) (sdk.Tags, sdk.Error) {
+	tags := sdk.NewTags()
+	for _, p := range prevented {
+		b, err := json.Marshal(p)
+		if err != nil {
+			return nil, sdk.ErrInternal(fmt.Sprintf("Error marshalling json: %v", err))
+		}
+		tags = tags.AppendTag("self_trade_prevented", b)
+	}
+	return tags, nil
+}
+
+// Handle MsgCancelLimitOrder
+func handleMsgCancelLimitOrder(k Keeper, ctx sdk.Context, msg MsgCancelLimitOrder) sdk.Result {
+	cancelled, cancelledOCO, err := cancelLimitOrder(k, ctx, msg)
+	if err != nil {
+		return err.Result()
+	}
+
+	type toJSON struct {
+		Cancelled            LimitOrder `json:"cancelled"`
+		CancelledOCOOrderIDs []int64    `json:"cancelled_oco_orders,omitempty"`
+	}
+
+	b, err2 := json.Marshal(toJSON{cancelled, cancelledOCO})
+	if err2 != nil {
+		return sdk.ErrInternal(fmt.Sprintf("Error marshalling json: %v", err2)).Result()
+	}
+
+	resultLog := fmt.Sprintf("json%vjson", string(b))
+
+	return sdk.Result{Log: resultLog}
+}
+
+// cancelLimitOrder cancels the order of a MsgCancelLimitOrder, which is given by order id or by client order id, and
+// the other orders of its one-cancels-other group
+func cancelLimitOrder(k Keeper, ctx sdk.Context, msg MsgCancelLimitOrder) (LimitOrder, []int64, sdk.Error) {
+	orderID := msg.OrderID
+	if msg.ClientOrderID != "" {
+		var err sdk.Error
+		orderID, err = k.getOrderIDByClientOrderID(ctx, msg.Sender, msg.ClientOrderID)
+		if err != nil {
+			return LimitOrder{}, nil, err
+		}
+	}
+
+	return k.cancelLimitOrder(ctx, msg.Sender, orderID)
+}
+
+// Handle MsgReplaceLimitOrder
+func handleMsgReplaceLimitOrder(k Keeper, ctx sdk.Context, msg MsgReplaceLimitOrder) sdk.Result {
+	replaced, err := k.replaceLimitOrder(ctx, msg.Sender, msg.OrderID, msg.Amount, msg.Price)
+	if err != nil {
+		return err.Result()
+	}
+
+	type toJSON struct {
+		Replaced LimitOrder `json:"replaced"`
+	}
+
+	b, err2 := json.Marshal(toJSON{replaced})
+	if err2 != nil {
+		return sdk.ErrInternal(fmt.Sprintf("Error marshalling json: %v", err2)).Result()
+	}
+
+	resultLog := fmt.Sprintf("json%vjson", string(b))
+
+	return sdk.Result{Log: resultLog}
+}
+
+// Handle MsgCreateTrailingStop
+func handleMsgCreateTrailingStop(k Keeper, ctx sdk.Context, msg MsgCreateTrailingStop) sdk.Result {
+	stop, err := k.createTrailingStop(ctx, msg.Sender, msg.Kind, msg.Amount, msg.PriceDenom, msg.TrailingOffset,
+		msg.TrailingRate, msg.Market, msg.LimitOffset)
+	if err != nil {
+		return err.Result()
+	}
+
+	type toJSON struct {
+		Stop         TrailingStop `json:"stop"`
+		TriggerPrice Price        `json:"trigger_price"`
+	}
+
+	b, err2 := json.Marshal(toJSON{stop, stop.getTriggerPrice()})
+	if err2 != nil {
+		return sdk.ErrInternal(fmt.Sprintf("Error marshalling json: %v", err2)).Result()
+	}
+
+	resultLog := fmt.Sprintf("json%vjson", string(b))
+
+	return sdk.Result{Log: resultLog}
+}
+
+// Handle MsgCancelTrailingStop
+func handleMsgCancelTrailingStop(k Keeper, ctx sdk.Context, msg MsgCancelTrailingStop) sdk.Result {
+	cancelled, err := k.cancelTrailingStop(ctx, msg.Sender, msg.StopID)
+	if err != nil {
+		return err.Result()
+	}
+
+	type toJSON struct {
+		Cancelled TrailingStop `json:"cancelled"`
+	}
+
+	b, err2 := json.Marshal(toJSON{cancelled})
+	if err2 != nil {
+		return sdk.ErrInternal(fmt.Sprintf("Error marshalling json: %v", err2)).Result()
 	}
 
-	b, err2 := json.Marshal(toJSON{processed, filled})
+	resultLog := fmt.Sprintf("json%vjson", string(b))
+
+	return sdk.Result{Log: resultLog}
+}
+
+// Handle MsgSetMarketMode
+func handleMsgSetMarketMode(k Keeper, ctx sdk.Context, msg MsgSetMarketMode) sdk.Result {
+	market, err := k.setMarketMode(ctx, msg.Sender, msg.AmountDenom, msg.PriceDenom, msg.Mode)
+	if err != nil {
+		return err.Result()
+	}
+
+	type toJSON struct {
+		Market Market `json:"market"`
+	}
+
+	b, err2 := json.Marshal(toJSON{market})
+	if err2 != nil {
+		return sdk.ErrInternal(fmt.Sprintf("Error marshalling json: %v", err2)).Result()
+	}
+
+	resultLog := fmt.Sprintf("json%vjson", string(b))
+
+	return sdk.Result{Log: resultLog}
+}
+
+// Handle MsgSetMarketConfig
+func handleMsgSetMarketConfig(k Keeper, ctx sdk.Context, msg MsgSetMarketConfig) sdk.Result {
+	market, err := k.setMarketConfig(ctx, msg.Sender, msg.AmountDenom, msg.PriceDenom, msg.TickSize, msg.LotSize,
+		msg.MinNotional, msg.SelfTradePrevention, msg.PriceBand)
+	if err != nil {
+		return err.Result()
+	}
+
+	type toJSON struct {
+		Market Market `json:"market"`
+	}
+
+	b, err2 := json.Marshal(toJSON{market})
 	if err2 != nil {
 		return sdk.ErrInternal(fmt.Sprintf("Error marshalling json: %v", err2)).Result()
 	}
@@ -43,3 +302,141 @@ func handleMsgCreateLimitOrder(k Keeper, ctx sdk.Context, msg MsgCreateLimitOrde
 
 	return sdk.Result{Log: resultLog}
 }
+
+// Handle MsgSetMarketHalt
+func handleMsgSetMarketHalt(k Keeper, ctx sdk.Context, msg MsgSetMarketHalt) sdk.Result {
+	market, err := k.setMarketHalt(ctx, msg.Sender, msg.AmountDenom, msg.PriceDenom, msg.Halted, msg.ProposalID)
+	if err != nil {
+		return err.Result()
+	}
+
+	type toJSON struct {
+		Market Market `json:"market"`
+	}
+
+	b, err2 := json.Marshal(toJSON{market})
+	if err2 != nil {
+		return sdk.ErrInternal(fmt.Sprintf("Error marshalling json: %v", err2)).Result()
+	}
+
+	resultLog := fmt.Sprintf("json%vjson", string(b))
+
+	return sdk.Result{Log: resultLog}
+}
+
+// Handle MsgListMarket
+func handleMsgListMarket(k Keeper, ctx sdk.Context, msg MsgListMarket) sdk.Result {
+	market, err := k.listMarket(ctx, msg.Sender, msg.Listing, msg.ProposalID)
+	if err != nil {
+		return err.Result()
+	}
+
+	type toJSON struct {
+		Market Market `json:"market"`
+	}
+
+	b, err2 := json.Marshal(toJSON{market})
+	if err2 != nil {
+		return sdk.ErrInternal(fmt.Sprintf("Error marshalling json: %v", err2)).Result()
+	}
+
+	resultLog := fmt.Sprintf("json%vjson", string(b))
+
+	return sdk.Result{Log: resultLog}
+}
+
+// Handle MsgDelistMarket
+func handleMsgDelistMarket(k Keeper, ctx sdk.Context, msg MsgDelistMarket) sdk.Result {
+	market, err := k.delistMarket(ctx, msg.Sender, msg.AmountDenom, msg.PriceDenom)
+	if err != nil {
+		return err.Result()
+	}
+
+	type toJSON struct {
+		Market Market `json:"market"`
+	}
+
+	b, err2 := json.Marshal(toJSON{market})
+	if err2 != nil {
+		return sdk.ErrInternal(fmt.Sprintf("Error marshalling json: %v", err2)).Result()
+	}
+
+	resultLog := fmt.Sprintf("json%vjson", string(b))
+
+	return sdk.Result{Log: resultLog}
+}
+
+// gas charged for every item of a batch and every fill of its orders in addition to the gas of the store accesses, so
+// that the cost of a batch grows with the work it causes
+const (
+	batchItemGas sdk.Gas = 1000
+	batchFillGas sdk.Gas = 500
+)
+
+// Handle MsgBatchOrders. The items are applied in order, each in a cached context of its own, so that a failed item
+// leaves no state changes. In atomic mode, a failed item fails the whole batch, otherwise its error is reported in
+// its result
+func handleMsgBatchOrders(k Keeper, ctx sdk.Context, msg MsgBatchOrders) sdk.Result {
+	batchCtx, writeBatch := ctx.CacheContext()
+
+	results := make([]BatchOrderResult, len(msg.Items))
+	tags := sdk.NewTags()
+	for i, item := range msg.Items {
+		itemCtx, writeItem := batchCtx.CacheContext()
+		result, itemTags, err := applyBatchOrderItem(k, itemCtx, item)
+		if err != nil {
+			if msg.Atomic {
+				res := err.Result()
+				res.Log = fmt.Sprintf("item %v of the batch failed: %v", i, res.Log)
+				return res
+			}
+			results[i] = BatchOrderResult{Code: err.ABCICode(), Log: err.ABCILog()}
+			continue
+		}
+
+		writeItem()
+		results[i] = result
+		tags = tags.AppendTags(itemTags)
+	}
+
+	writeBatch()
+
+	type toJSON struct {
+		Results []BatchOrderResult `json:"results"`
+	}
+
+	b, err2 := json.Marshal(toJSON{results})
+	if err2 != nil {
+		return sdk.ErrInternal(fmt.Sprintf("Error marshalling json: %v", err2)).Result()
+	}
+
+	resultLog := fmt.Sprintf("json%vjson", string(b))
+
+	return sdk.Result{Log: resultLog, Tags: tags}
+}
+
+// applyBatchOrderItem creates or cancels the order of an item of a batch and charges the gas for it
+func applyBatchOrderItem(k Keeper, ctx sdk.Context, item BatchOrderItem) (BatchOrderResult, sdk.Tags, sdk.Error) {
+	ctx.GasMeter().ConsumeGas(batchItemGas, "batch order item")
+
+	if item.Cancel != nil {
+		cancelled, cancelledOCO, err := cancelLimitOrder(k, ctx, *item.Cancel)
+		if err != nil {
+			return BatchOrderResult{}, nil, err
+		}
+		return BatchOrderResult{Cancelled: &cancelled, CancelledOCOOrderIDs: cancelledOCO}, sdk.NewTags(), nil
+	}
+
+	processed, filled, inverted, err := createLimitOrder(k, ctx, *item.Create)
+	if err != nil {
+		return BatchOrderResult{}, nil, err
+	}
+	ctx.GasMeter().ConsumeGas(batchFillGas*sdk.Gas(len(filled)), "batch order fills")
+
+	tags, err := getOrderTags(processed, filled)
+	if err != nil {
+		return BatchOrderResult{}, nil, err
+	}
+
+	return BatchOrderResult{Processed: &processed, Filled: filled, Inverted: inverted}, tags, nil
+}
@@ -0,0 +1,103 @@
+package exchange
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+)
+
+// orderFillTestTag holds the fields of an OrderFill tag that are checked by the tests, prices cannot be parsed from
+// plain JSON
+type orderFillTestTag struct {
+	OrderID             int64    `json:"order_id"`
+	CounterpartyOrderID int64    `json:"counterparty_order_id"`
+	Amount              sdk.Coin `json:"amount"`
+	Price               struct {
+		Denom  string `json:"denom"`
+		Amount string `json:"amount"`
+	} `json:"price"`
+	MakerFee                  sdk.Coin `json:"maker_fee"`
+	TakerFee                  sdk.Coin `json:"taker_fee"`
+	ClientOrderID             string   `json:"client_order_id"`
+	CounterpartyClientOrderID string   `json:"counterparty_client_order_id"`
+}
+
+// Test if every fill of a created order is published as a tag
+func TestHandleMsgCreateLimitOrderFillTags(t *testing.T) {
+	ctx, keeper, _, buyer, _, limitSellOrder1, limitSellOrder2, _, _ := setupCreateBuyLimitOrderTest()
+	market := keeper.getMarket(ctx, "ETH", "RUNE")
+	market.MakerFeeRate = 100
+	market.TakerFeeRate = 200
+	keeper.setMarket(ctx, market)
+	handler := NewHandler(keeper)
+
+	msg := NewMsgCreateLimitOrder(buyer, BuyOrder, sdk.NewInt64Coin("ETH", 200), NewInt64Price("RUNE", 8),
+		time.Now().Add(time.Minute).UTC(), 0, GoodTillTime, MarketDefaultSTP, sdk.ZeroInt(), "", "")
+	res := handler(ctx, msg)
+	require.True(t, res.IsOK(), res.Log)
+
+	require.Len(t, res.Tags, 2)
+	fills := make([]orderFillTestTag, len(res.Tags))
+	for i, tag := range res.Tags {
+		require.Equal(t, "fill", string(tag.Key))
+		require.Nil(t, json.Unmarshal(tag.Value, &fills[i]))
+	}
+
+	// the maker fee is taken from the proceeds of the seller, the taker fee from the proceeds of the buyer
+	require.Equal(t, limitSellOrder1.OrderID, fills[0].CounterpartyOrderID)
+	require.Equal(t, sdk.NewInt64Coin("ETH", 120), fills[0].Amount)
+	require.Equal(t, "6", fills[0].Price.Amount)
+	require.Equal(t, sdk.NewInt64Coin("RUNE", 7), fills[0].MakerFee)
+	require.Equal(t, sdk.NewInt64Coin("ETH", 2), fills[0].TakerFee)
+	require.Equal(t, limitSellOrder2.OrderID, fills[1].CounterpartyOrderID)
+	require.Equal(t, sdk.NewInt64Coin("ETH", 80), fills[1].Amount)
+	require.Equal(t, "7", fills[1].Price.Amount)
+	require.Equal(t, sdk.NewInt64Coin("RUNE", 5), fills[1].MakerFee)
+	require.Equal(t, sdk.NewInt64Coin("ETH", 1), fills[1].TakerFee)
+	for _, fill := range fills {
+		require.Equal(t, keeper.getLastOrderID(ctx), fill.OrderID)
+		require.Equal(t, "RUNE", fill.Price.Denom)
+	}
+}
+
+// Test if the client order ids of the taker and the maker are published with every fill
+func TestHandleMsgCreateLimitOrderClientOrderIDTags(t *testing.T) {
+	ctx := setupContext(exchangeKey)
+	keeper, _, bankKeeper, buyer, seller := setupKeepers(exchangeKey, ctx)
+	bankKeeper.SetCoins(ctx, buyer, sdk.Coins{sdk.NewInt64Coin("RUNE", 100)})
+	bankKeeper.SetCoins(ctx, seller, sdk.Coins{sdk.NewInt64Coin("ETH", 20)})
+	handler := NewHandler(keeper)
+
+	maker, _, err := keeper.processLimitOrder(ctx, seller, SellOrder, sdk.NewInt64Coin("ETH", 10),
+		NewInt64Price("RUNE", 5), OrderOptions{TimeInForce: GoodTillCancelled, ClientOrderID: "maker-1"})
+	require.Nil(t, err)
+	_, _, err = keeper.processLimitOrder(ctx, seller, SellOrder, sdk.NewInt64Coin("ETH", 10), NewInt64Price("RUNE", 5),
+		OrderOptions{TimeInForce: GoodTillCancelled})
+	require.Nil(t, err)
+
+	msg := NewMsgCreateLimitOrder(buyer, BuyOrder, sdk.NewInt64Coin("ETH", 20), NewInt64Price("RUNE", 5), time.Time{},
+		0, GoodTillCancelled, MarketDefaultSTP, sdk.ZeroInt(), "taker-1", "")
+	res := handler(ctx, msg)
+	require.True(t, res.IsOK(), res.Log)
+
+	// the fill of the maker without client order id only carries the one of the taker
+	require.Len(t, res.Tags, 4)
+	var fills [2]orderFillTestTag
+	require.Equal(t, "fill", string(res.Tags[0].Key))
+	require.Nil(t, json.Unmarshal(res.Tags[0].Value, &fills[0]))
+	require.Equal(t, "client_order_id", string(res.Tags[1].Key))
+	require.Equal(t, "maker-1", string(res.Tags[1].Value))
+	require.Equal(t, "fill", string(res.Tags[2].Key))
+	require.Nil(t, json.Unmarshal(res.Tags[2].Value, &fills[1]))
+	require.Equal(t, "client_order_id", string(res.Tags[3].Key))
+	require.Equal(t, "taker-1", string(res.Tags[3].Value))
+
+	require.Equal(t, maker.OrderID, fills[0].CounterpartyOrderID)
+	require.Equal(t, "taker-1", fills[0].ClientOrderID)
+	require.Equal(t, "maker-1", fills[0].CounterpartyClientOrderID)
+	require.Equal(t, "taker-1", fills[1].ClientOrderID)
+	require.Equal(t, "", fills[1].CounterpartyClientOrderID)
+}
@@ -0,0 +1,98 @@
+package exchange
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// Iceberg is the hidden part of an iceberg order. The order book only shows a slice of at most the display amount,
+// which is refilled from the reserve at the back of the time queue once it is filled
+type Iceberg struct {
+	DisplayAmount sdk.Int `json:"display_amount"`
+	Reserve       sdk.Int `json:"reserve"` // amount that is not shown in the order book yet
+}
+
+// setDisplayAmount turns the order into an iceberg order that shows at most the display amount, the rest of the
+// amount is hidden in the reserve. A zero display amount shows the whole amount
+func (lo *LimitOrder) setDisplayAmount(displayAmount sdk.Int) {
+	if displayAmount.Sign() <= 0 {
+		return
+	}
+	lo.Iceberg = &Iceberg{displayAmount, sdk.ZeroInt()}
+	lo.hideReserve()
+}
+
+// getTotalAmount returns the amount of the order including the hidden reserve
+func (lo *LimitOrder) getTotalAmount() sdk.Coin {
+	if lo.Iceberg == nil {
+		return lo.Amount
+	}
+	return sdk.Coin{lo.Amount.Denom, lo.Amount.Amount.Add(lo.Iceberg.Reserve)}
+}
+
+// hideReserve moves the part of the amount of an iceberg order that exceeds its display amount into the reserve
+func (lo *LimitOrder) hideReserve() {
+	if lo.Iceberg == nil || !lo.Amount.Amount.GT(lo.Iceberg.DisplayAmount) {
+		return
+	}
+	excess := lo.Amount.Amount.Sub(lo.Iceberg.DisplayAmount)
+	lo.Amount = sdk.Coin{lo.Amount.Denom, lo.Iceberg.DisplayAmount}
+	lo.Iceberg = &Iceberg{lo.Iceberg.DisplayAmount, lo.Iceberg.Reserve.Add(excess)}
+}
+
+// showReserve moves the whole reserve of an iceberg order into its amount, e. g. to take part in a batch auction
+func (lo *LimitOrder) showReserve() {
+	if lo.Iceberg == nil {
+		return
+	}
+	lo.Amount = lo.getTotalAmount()
+	lo.Iceberg = &Iceberg{lo.Iceberg.DisplayAmount, sdk.ZeroInt()}
+}
+
+// refill shows the next slice of the reserve of an iceberg order whose amount is filled. Returns false if there is
+// nothing to refill
+func (lo *LimitOrder) refill() bool {
+	if lo.Iceberg == nil || !lo.Amount.IsZero() || lo.Iceberg.Reserve.Sign() <= 0 {
+		return false
+	}
+	lo.showReserve()
+	lo.hideReserve()
+	return true
+}
+
+// decrement reduces the amount of the order, the shown amount first, then the reserve
+func (lo *LimitOrder) decrement(amount sdk.Coin) {
+	if lo.Iceberg == nil || lo.Amount.IsGTE(amount) {
+		lo.Amount = lo.Amount.Minus(amount)
+		return
+	}
+	lo.Iceberg = &Iceberg{lo.Iceberg.DisplayAmount, lo.Iceberg.Reserve.Sub(amount.Amount.Sub(lo.Amount.Amount))}
+	lo.Amount = sdk.NewInt64Coin(lo.Amount.Denom, 0)
+}
+
+// refillIcebergOrder refills the iceberg order at index i of the order book if its amount is filled, moving it to
+// the back of the time queue at its price. Returns false if the order was not refilled
+func refillIcebergOrder(orderBook *OrderBook, i int) bool {
+	order := orderBook.Orders[i]
+	if !order.refill() {
+		return false
+	}
+
+	orderBook.RemoveLimitOrder(i)
+	err := orderBook.AddLimitOrder(order)
+	if err != nil {
+		panic(err)
+	}
+	return true
+}
+
+// GetPublicOrderBook returns the order book as it is shown to other traders, i. e. without the hidden part of
+// iceberg orders
+func GetPublicOrderBook(orderBook OrderBook) OrderBook {
+	public := orderBook
+	public.Orders = make([]LimitOrder, len(orderBook.Orders))
+	for i, order := range orderBook.Orders {
+		order.Iceberg = nil
+		public.Orders[i] = order
+	}
+	return public
+}
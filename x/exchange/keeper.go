@@ -1,12 +1,15 @@
 package exchange
 
 import (
+	"bytes"
 	"fmt"
-	"time"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/cosmos/cosmos-sdk/wire"
+	"github.com/cosmos/cosmos-sdk/x/auth"
 	"github.com/cosmos/cosmos-sdk/x/bank"
+	"github.com/cosmos/cosmos-sdk/x/gov"
+	"github.com/cosmos/cosmos-sdk/x/params"
 )
 
 // The exchange keeper contains one buy and one sell orderbook for each token pair.
@@ -17,16 +20,23 @@ type Keeper struct {
 
 	bankKeeper bank.Keeper
 
+	feeCollectionKeeper auth.FeeCollectionKeeper // fee pool of the chain that trading fees are added to
+
+	govKeeper gov.Keeper // proposals that list markets
+
+	params params.Setter // fee rates, authority and listing deposit
+
 	codespace sdk.CodespaceType
 
 	cdc *wire.Codec
 }
 
 // NewKeeper - Returns the Keeper
-func NewKeeper(key sdk.StoreKey, bankKeeper bank.Keeper, codespace sdk.CodespaceType) Keeper {
+func NewKeeper(key sdk.StoreKey, bankKeeper bank.Keeper, feeCollectionKeeper auth.FeeCollectionKeeper,
+	govKeeper gov.Keeper, params params.Setter, codespace sdk.CodespaceType) Keeper {
 	cdc := wire.NewCodec()
 	wire.RegisterCrypto(cdc)
-	return Keeper{key, bankKeeper, codespace, cdc}
+	return Keeper{key, bankKeeper, feeCollectionKeeper, govKeeper, params, codespace, cdc}
 }
 
 // getOrderBook returns the orderbook for the given token pair. If no order book exists for these tokens right now,
@@ -47,6 +57,23 @@ func (k Keeper) getOrderBook(ctx sdk.Context, kind OrderKind, amountDenom string
 	return *orderBook
 }
 
+// getAllOrderBooks returns all order books, sorted by key
+func (k Keeper) getAllOrderBooks(ctx sdk.Context) []OrderBook {
+	orderBooks := make([]OrderBook, 0)
+
+	store := ctx.KVStore(k.storeKey)
+	iter := sdk.KVStorePrefixIterator(store, orderBookSubspace)
+	defer iter.Close()
+
+	for ; iter.Valid(); iter.Next() {
+		orderBook := new(OrderBook)
+		k.cdc.MustUnmarshalBinary(iter.Value(), &orderBook)
+		orderBooks = append(orderBooks, *orderBook)
+	}
+
+	return orderBooks
+}
+
 // setOrderBook saves the order book to the store
 func (k Keeper) setOrderBook(ctx sdk.Context, orderBook OrderBook) {
 	store := ctx.KVStore(k.storeKey)
@@ -59,14 +86,31 @@ func (k Keeper) setOrderBook(ctx sdk.Context, orderBook OrderBook) {
 
 // processLimitOrder processes a limit order. After error checking, it tries to
 // execute the order with existing trades – if that is not possible, a new entry
-// for this limit order will be created in the corresponding order book.
+// for this limit order will be created in the corresponding order book. The time
+// in force decides whether the order may match at all (post-only), has to match
+// completely (fill-or-kill) and whether an unfilled part is stored (good-till-time)
+// or cancelled (immediate-or-cancel, fill-or-kill). The self-trade prevention mode
+// decides what happens if the order would match a stored order of the same sender.
+// A positive display amount makes a stored order an iceberg order that only shows
+// slices of the display amount in the order book. The order expires at the expiry time
+// or height, whichever comes first, a zero time or height is not used. A client order id
+// can only be used once per sender. Once an order of a one-cancels-other group is filled,
+// the other orders of the sender in the group are cancelled.
 // nolint gocyclo
 func (k Keeper) processLimitOrder(
-	ctx sdk.Context, sender sdk.AccAddress, kind OrderKind, amount sdk.Coin, price sdk.Coin,
-	expiresAt time.Time) (ProcessedLimitOrder, []FilledLimitOrder, sdk.Error) {
+	ctx sdk.Context, sender sdk.AccAddress, kind OrderKind, amount sdk.Coin, price Price, opts OrderOptions,
+) (ProcessedLimitOrder, []FilledLimitOrder, sdk.Error) {
+	// an unset display amount shows the whole amount
+	if opts.DisplayAmount == (sdk.Int{}) {
+		opts.DisplayAmount = sdk.ZeroInt()
+	}
 
-	// error if already expired
-	if expiresAt.Before(time.Now()) {
+	// error if expiry does not fit the time in force or is already reached
+	err := checkExpiry(k.codespace, opts.ExpiresAt, opts.ExpiresAtHeight, opts.TimeInForce)
+	if err != nil {
+		return ProcessedLimitOrder{}, []FilledLimitOrder{}, err
+	}
+	if isExpired(opts.ExpiresAt, opts.ExpiresAtHeight, ctx.BlockHeader().Time, ctx.BlockHeight()) {
 		return ProcessedLimitOrder{}, []FilledLimitOrder{}, ErrOrderExpired(k.codespace)
 	}
 
@@ -75,6 +119,16 @@ func (k Keeper) processLimitOrder(
 		return ProcessedLimitOrder{}, []FilledLimitOrder{}, ErrInvalidKind(k.codespace)
 	}
 
+	// error if time in force not supported
+	if !isValidTimeInForce(opts.TimeInForce) {
+		return ProcessedLimitOrder{}, []FilledLimitOrder{}, ErrInvalidTimeInForce(k.codespace)
+	}
+
+	// error if self-trade prevention mode not supported
+	if !isValidSelfTradePrevention(opts.SelfTradePrevention) {
+		return ProcessedLimitOrder{}, []FilledLimitOrder{}, ErrInvalidSelfTradePrevention(k.codespace)
+	}
+
 	// error if amount and price denom are the same
 	if amount.Denom == price.Denom {
 		return ProcessedLimitOrder{}, []FilledLimitOrder{}, ErrSameDenom(k.codespace)
@@ -90,6 +144,42 @@ func (k Keeper) processLimitOrder(
 		return ProcessedLimitOrder{}, []FilledLimitOrder{}, ErrPriceNotPositive(k.codespace)
 	}
 
+	// error if the market is not listed, or tick size, lot size or minimum notional of the market are violated
+	err = k.checkMarketRules(ctx, amount, price)
+	if err != nil {
+		return ProcessedLimitOrder{}, []FilledLimitOrder{}, err
+	}
+
+	// error if the display amount is negative or the slices of an iceberg order would violate the lot size
+	if opts.DisplayAmount.Sign() < 0 {
+		return ProcessedLimitOrder{}, []FilledLimitOrder{}, ErrInvalidDisplayAmount(k.codespace)
+	}
+	if lotSize := k.getMarket(ctx, amount.Denom, price.Denom).LotSize; !opts.DisplayAmount.Mod(lotSize).IsZero() {
+		return ProcessedLimitOrder{}, []FilledLimitOrder{}, ErrInvalidLotSize(k.codespace, lotSize)
+	}
+
+	// error if the client order id is too long or has been used by the sender before
+	err = k.checkClientOrderID(ctx, sender, opts.ClientOrderID)
+	if err != nil {
+		return ProcessedLimitOrder{}, []FilledLimitOrder{}, err
+	}
+
+	// error if the one-cancels-other group is too long
+	err = k.checkOCOGroup(opts.OCOGroup)
+	if err != nil {
+		return ProcessedLimitOrder{}, []FilledLimitOrder{}, err
+	}
+
+	// immediate-or-cancel and fill-or-kill orders never rest in an order book, so only the other orders count towards
+	// the open order limits of the sender and pay the order deposit
+	mayRest := opts.TimeInForce != ImmediateOrCancel && opts.TimeInForce != FillOrKill
+	if mayRest {
+		err = k.checkOpenOrderLimits(ctx, sender, amount.Denom, price.Denom)
+		if err != nil {
+			return ProcessedLimitOrder{}, []FilledLimitOrder{}, err
+		}
+	}
+
 	// check if enough coins to place order
 	totalPrice := getTotalPrice(amount, price)
 	if kind == BuyOrder && !k.bankKeeper.HasCoins(ctx, sender, sdk.Coins{totalPrice}) {
@@ -100,26 +190,100 @@ func (k Keeper) processLimitOrder(
 		return ProcessedLimitOrder{}, []FilledLimitOrder{}, sdk.ErrInsufficientCoins(fmt.Sprintf(
 			"Must have at least %v to place this sell limit order", amount))
 	}
+	if mayRest {
+		locked := amount
+		if kind == BuyOrder {
+			locked = totalPrice
+		}
+		err = k.checkOrderDeposit(ctx, sender, locked)
+		if err != nil {
+			return ProcessedLimitOrder{}, []FilledLimitOrder{}, err
+		}
+	}
+
+	// orders without a self-trade prevention mode are matched with the mode of the market, but keep following the
+	// market default while they are stored
+	matchSTP := k.getSelfTradePrevention(ctx, amount.Denom, price.Denom, opts.SelfTradePrevention)
+
+	// all state changes of the order are made in a cached context that is only written once the whole order has been
+	// processed, so that an order either commits all of its fills or none of them
+	cacheCtx, writeCache := ctx.CacheContext()
+
+	// orders of batch auction markets are collected and cleared at the end of the block. Collected orders cannot be
+	// cancelled by their one-cancels-other group, so groups are not supported
+	if k.getMarket(ctx, amount.Denom, price.Denom).Mode == BatchAuctionMode {
+		if opts.OCOGroup != "" {
+			return ProcessedLimitOrder{}, []FilledLimitOrder{}, ErrInvalidOCOGroup(k.codespace,
+				"one-cancels-other groups are not supported by batch auction markets")
+		}
+		processed, filled, err := k.processBatchLimitOrder(cacheCtx, sender, kind, amount, price, opts)
+		if err != nil {
+			return ProcessedLimitOrder{}, []FilledLimitOrder{}, err
+		}
+		writeCache()
+		return processed, filled, nil
+	}
+
+	// post-only orders must not match, not even an order of the same sender, and fill-or-kill orders must match
+	// completely, not counting orders of the same sender. Both are checked before any coins are moved
+	if opts.TimeInForce == PostOnly && k.getFillableAmount(ctx, nil, kind, amount, price, matchSTP).IsPositive() {
+		return ProcessedLimitOrder{}, []FilledLimitOrder{}, ErrOrderWouldMatch(k.codespace)
+	}
+	if opts.TimeInForce == FillOrKill && !k.getFillableAmount(ctx, sender, kind, amount, price, matchSTP).IsGTE(amount) {
+		return ProcessedLimitOrder{}, []FilledLimitOrder{}, ErrOrderNotFillable(k.codespace)
+	}
+
+	// the order id is assigned before filling so that trades can reference the taker order
+	orderID, err := k.getNewOrderID(cacheCtx)
+	if err != nil {
+		return ProcessedLimitOrder{}, []FilledLimitOrder{}, err
+	}
+	k.setClientOrderID(cacheCtx, sender, opts.ClientOrderID, orderID)
 
 	// fill order if possible
-	unfilledAmt, filledOrders, err := k.fillOrderIfPossible(ctx, sender, kind, amount, price)
+	unfilledAmt, filledOrders, prevented, cancelledOCO, err := k.fillOrderIfPossible(
+		cacheCtx, orderID, opts.ClientOrderID, sender, kind, amount, price, matchSTP)
 	if err != nil {
 		return ProcessedLimitOrder{}, []FilledLimitOrder{}, err
 	}
 
+	// a fill of the order cancels the other orders of its one-cancels-other group
+	if len(filledOrders) > 0 {
+		cancelledSiblings, err2 := k.cancelOCOSiblings(cacheCtx, sender, opts.OCOGroup, orderID)
+		if err2 != nil {
+			return ProcessedLimitOrder{}, []FilledLimitOrder{}, err2
+		}
+		cancelledOCO = append(cancelledOCO, cancelledSiblings...)
+	}
+
+	// immediate-or-cancel and fill-or-kill orders never rest in the orderbook, their unfilled part is cancelled
+	if opts.TimeInForce == ImmediateOrCancel || opts.TimeInForce == FillOrKill {
+		unfilledAmt = sdk.NewInt64Coin(amount.Denom, 0)
+	}
+
 	// store unfilled order
-	processedOrder, err := k.storeUnfilledLimitOrder(ctx, sender, kind, unfilledAmt, price, expiresAt)
+	processedOrder, err := k.storeUnfilledLimitOrder(cacheCtx, orderID, sender, kind, unfilledAmt, price, opts)
 	if err != nil {
 		return ProcessedLimitOrder{}, []FilledLimitOrder{}, err
 	}
+	processedOrder.PreventedSelfTrades = prevented
+	processedOrder.ClientOrderID = opts.ClientOrderID
+	if len(cancelledOCO) > 0 {
+		processedOrder.CancelledOCOOrderIDs = cancelledOCO
+	}
 
+	writeCache()
 	return processedOrder, filledOrders, nil
 }
 
 // fillOrderIfPossible tries to fill the order. Returns the amount that could not be filled and a slice of limit orders that have been filled
+// Every fill is persisted as a trade. Matches with stored orders of the sender are prevented instead and expired stored
+// orders are refunded. Stored orders that are filled, cancelled by self-trade prevention or expired cancel the other
+// orders of their one-cancels-other groups, whose ids are returned
 func (k Keeper) fillOrderIfPossible(
-	ctx sdk.Context, sender sdk.AccAddress, kind OrderKind, amount sdk.Coin, price sdk.Coin,
-) (sdk.Coin, []FilledLimitOrder, sdk.Error) {
+	ctx sdk.Context, orderID int64, clientOrderID string, sender sdk.AccAddress, kind OrderKind, amount sdk.Coin,
+	price Price, stp SelfTradePrevention,
+) (sdk.Coin, []FilledLimitOrder, []PreventedSelfTrade, []int64, sdk.Error) {
 	// get matching order book to fill the order
 	matchingKind := SellOrder
 	if kind == SellOrder {
@@ -133,9 +297,20 @@ func (k Keeper) fillOrderIfPossible(
 	// slice of filled orderIds, prices and amounts
 	filledOrders := make([]FilledLimitOrder, 0, 10)
 
+	// slice of prevented self-trades
+	prevented := make([]PreventedSelfTrade, 0)
+
+	// stored orders that cancel their one-cancels-other groups, by group
+	triggered := make(map[string]LimitOrder)
+	triggeredGroups := make([]string, 0)
+
 	var err sdk.Error
 
-	for i, storedOrder := range orderBook.Orders {
+	makerFeeRate, takerFeeRate := k.getFeeRates(ctx, k.getMarket(ctx, amount.Denom, price.Denom))
+
+	for i := 0; i < len(orderBook.Orders); i++ {
+		storedOrder := orderBook.Orders[i]
+
 		// end loop if unfilled amt is 0
 		if unfilledAmt.IsZero() {
 			break
@@ -148,44 +323,190 @@ func (k Keeper) fillOrderIfPossible(
 			break
 		}
 
-		fillTotalPrice := getTotalPrice(fillAmount, fillPrice)
+		// orders whose group has been cancelled by another order of the group are not matched anymore
+		group := getOCOGroupKey(storedOrder)
+		if trigger, ok := triggered[group]; ok && trigger.OrderID != storedOrder.OrderID {
+			continue
+		}
 
-		var coinsFromSenderToStoredSender, coinsToUnlockForSender sdk.Coin
+		// expired orders that have not been removed by the expiry queue yet are refunded instead of being filled and
+		// cancel their one-cancels-other groups
+		if isExpired(storedOrder.ExpiresAt, storedOrder.ExpiresAtHeight, ctx.BlockHeader().Time, ctx.BlockHeight()) {
+			err = k.expireStoredLimitOrder(ctx, &orderBook.Orders[i])
+			if err != nil {
+				break
+			}
+			if _, ok := triggered[group]; !ok && storedOrder.OCOGroup != "" {
+				triggered[group] = storedOrder
+				triggeredGroups = append(triggeredGroups, group)
+			}
+			continue
+		}
 
-		if kind == BuyOrder {
-			// send totalPrice from buyer to seller
-			coinsFromSenderToStoredSender = fillTotalPrice
+		if bytes.Equal(storedOrder.Sender, sender) {
+			var preventedSelfTrade PreventedSelfTrade
+			preventedSelfTrade, unfilledAmt, err = k.preventSelfTrade(ctx, &orderBook.Orders[i], orderID, unfilledAmt,
+				stp)
+			if err != nil {
+				break
+			}
+			prevented = append(prevented, preventedSelfTrade)
 
-			// give buyer locked coins from seller
-			coinsToUnlockForSender = fillAmount
+			if orderBook.Orders[i].getTotalAmount().IsZero() && storedOrder.OCOGroup != "" {
+				if _, ok := triggered[group]; !ok {
+					triggered[group] = storedOrder
+					triggeredGroups = append(triggeredGroups, group)
+				}
+			}
 		} else {
-			// send amount from seller to buyer
-			coinsFromSenderToStoredSender = fillAmount
+			fillTotalPrice := getTotalPrice(fillAmount, fillPrice)
+
+			var coinsFromSenderToStoredSender, coinsToUnlockForSender sdk.Coin
+
+			if kind == BuyOrder {
+				// send totalPrice from buyer to seller
+				coinsFromSenderToStoredSender = fillTotalPrice
+
+				// give buyer locked coins from seller
+				coinsToUnlockForSender = fillAmount
+			} else {
+				// send amount from seller to buyer
+				coinsFromSenderToStoredSender = fillAmount
+
+				// give seller locked coins from buyer
+				coinsToUnlockForSender = fillTotalPrice
+			}
+
+			// fees are taken from the proceeds of both sides
+			makerFee := getFee(coinsFromSenderToStoredSender, makerFeeRate)
+			takerFee := getFee(coinsToUnlockForSender, takerFeeRate)
+
+			err = k.sendAndUnlockCoins(
+				ctx, sender, storedOrder.Sender, coinsFromSenderToStoredSender, coinsToUnlockForSender, makerFee,
+				takerFee)
+			if err != nil {
+				break
+			}
+
+			filledOrders = append(filledOrders, FilledLimitOrder{storedOrder.OrderID, fillAmount, fillPrice, makerFee,
+				takerFee, storedOrder.ClientOrderID})
 
-			// give seller locked coins from buyer
-			coinsToUnlockForSender = fillTotalPrice
+			k.storeTrade(ctx, storedOrder, orderID, clientOrderID, sender, kind, fillAmount, fillPrice, makerFee,
+				takerFee)
+
+			// update unfilled amount
+			unfilledAmt = unfilledAmt.Minus(fillAmount)
+
+			// replace the amount of the stored order with the remaining part
+			orderBook.Orders[i].Amount = storedOrder.Amount.Minus(fillAmount)
+
+			if _, ok := triggered[group]; !ok && storedOrder.OCOGroup != "" {
+				triggered[group] = storedOrder
+				triggeredGroups = append(triggeredGroups, group)
+			}
+		}
+
+		// a filled iceberg order shows the next slice of its reserve at the back of the time queue at its price, so
+		// the order now at index i has not been matched yet
+		if refillIcebergOrder(&orderBook, i) {
+			i--
+			continue
+		}
+
+		if orderBook.Orders[i].Amount.IsZero() {
+			k.unindexLimitOrder(ctx, storedOrder)
+			err = k.releaseOrderDeposit(ctx, storedOrder)
+			if err != nil {
+				break
+			}
 		}
+	}
+
+	orderBook.RemoveFilledLimitOrders()
+
+	k.setOrderBook(ctx, orderBook)
 
-		err = k.sendAndUnlockCoins(
-			ctx, sender, storedOrder.Sender, coinsFromSenderToStoredSender, coinsToUnlockForSender)
+	if err != nil {
+		return unfilledAmt, filledOrders, prevented, nil, err
+	}
+
+	// the siblings are cancelled once the order book has been saved, as they may be stored in it
+	cancelledOCO := make([]int64, 0)
+	for _, group := range triggeredGroups {
+		trigger := triggered[group]
+		cancelled, err := k.cancelOCOSiblings(ctx, trigger.Sender, trigger.OCOGroup, trigger.OrderID)
 		if err != nil {
+			return unfilledAmt, filledOrders, prevented, nil, err
+		}
+		cancelledOCO = append(cancelledOCO, cancelled...)
+	}
+
+	return unfilledAmt, filledOrders, prevented, cancelledOCO, nil
+}
+
+// getFillableAmount returns the amount of the given order that could be filled immediately by the stored orders,
+// without changing any state. Stored orders of the sender are handled according to the self-trade prevention mode,
+// without a sender all stored orders count. Expired orders and orders of a one-cancels-other group whose sibling has
+// been filled, cancelled or has expired before do not count
+func (k Keeper) getFillableAmount(ctx sdk.Context, sender sdk.AccAddress, kind OrderKind, amount sdk.Coin,
+	price Price, stp SelfTradePrevention) sdk.Coin {
+	matchingKind := SellOrder
+	if kind == SellOrder {
+		matchingKind = BuyOrder
+	}
+	orderBook := k.getOrderBook(ctx, matchingKind, amount.Denom, price.Denom)
+
+	fillableAmt := sdk.NewInt64Coin(amount.Denom, 0)
+	unfilledAmt := amount
+	triggered := make(map[string]bool)
+
+	for _, storedOrder := range orderBook.Orders {
+		if unfilledAmt.IsZero() {
 			break
 		}
 
-		filledOrders = append(filledOrders, FilledLimitOrder{storedOrder.OrderID, fillAmount, fillPrice})
+		// the hidden reserve of iceberg orders is filled at the same price
+		storedOrder.Amount = storedOrder.getTotalAmount()
 
-		// update unfilled amount
-		unfilledAmt = unfilledAmt.Minus(fillAmount)
+		ok, fillAmount, _ := storedOrder.DoesFill(kind, unfilledAmt, price)
+		if !ok {
+			break
+		}
 
-		// replace the amount of the stored order with the remaining part
-		orderBook.Orders[i].Amount = storedOrder.Amount.Minus(fillAmount)
-	}
+		group := getOCOGroupKey(storedOrder)
+		if triggered[group] {
+			continue
+		}
 
-	orderBook.RemoveFilledLimitOrders()
+		if isExpired(storedOrder.ExpiresAt, storedOrder.ExpiresAtHeight, ctx.BlockHeader().Time, ctx.BlockHeight()) {
+			if group != "" {
+				triggered[group] = true
+			}
+			continue
+		}
 
-	k.setOrderBook(ctx, orderBook)
+		if len(sender) > 0 && bytes.Equal(storedOrder.Sender, sender) {
+			if stp == CancelNewest || stp == CancelBoth {
+				break
+			}
+			if stp == DecrementAndCancel {
+				unfilledAmt = unfilledAmt.Minus(fillAmount)
+			}
+			// the stored order is cancelled if it is reduced completely
+			if group != "" && (stp == CancelOldest || fillAmount.IsEqual(storedOrder.Amount)) {
+				triggered[group] = true
+			}
+			continue
+		}
+
+		if group != "" {
+			triggered[group] = true
+		}
+		fillableAmt = fillableAmt.Plus(fillAmount)
+		unfilledAmt = unfilledAmt.Minus(fillAmount)
+	}
 
-	return unfilledAmt, filledOrders, err
+	return fillableAmt
 }
 
 func (k Keeper) hasOrderSenderEnoughCoins(ctx sdk.Context, storedOrder LimitOrder, totalAmount, totalPrice sdk.Coin,
@@ -197,57 +518,70 @@ func (k Keeper) hasOrderSenderEnoughCoins(ctx sdk.Context, storedOrder LimitOrde
 	return k.bankKeeper.HasCoins(ctx, storedOrder.Sender, sdk.Coins{totalAmount})
 }
 
+// sendAndUnlockCoins settles a fill: b receives coinsFromAToB minus feeB and a receives the coins locked for b in the
+// escrow account minus feeA, both fees are added to the fee pool
 func (k Keeper) sendAndUnlockCoins(ctx sdk.Context, a, b sdk.AccAddress, coinsFromAToB, coinsToUnlockForA sdk.Coin,
-) sdk.Error {
-	_, err := k.bankKeeper.SendCoins(ctx, a, b, sdk.Coins{coinsFromAToB})
+	feeB, feeA sdk.Coin) sdk.Error {
+	_, err := k.bankKeeper.SendCoins(ctx, a, b, sdk.Coins{coinsFromAToB.Minus(feeB)})
+	if err != nil {
+		return err
+	}
+
+	err = k.collectFee(ctx, a, feeB)
+	if err != nil {
+		return err
+	}
+
+	err = k.releaseCoins(ctx, a, coinsToUnlockForA.Minus(feeA))
 	if err != nil {
 		return err
 	}
 
-	_, _, err = k.bankKeeper.AddCoins(ctx, a, sdk.Coins{coinsToUnlockForA})
-	return err
+	return k.collectFee(ctx, EscrowAddress, feeA)
 }
 
 // storeUnfilledLimitOrder creates a new limit order, finds the corresponding order book, adds the limit order
 // to the right place and saves the orderbook. Returns a ProcessedLimitOrder
 func (k Keeper) storeUnfilledLimitOrder(
-	ctx sdk.Context, sender sdk.AccAddress, kind OrderKind, amount sdk.Coin, price sdk.Coin, expiresAt time.Time,
+	ctx sdk.Context, orderID int64, sender sdk.AccAddress, kind OrderKind, amount sdk.Coin, price Price,
+	opts OrderOptions,
 ) (ProcessedLimitOrder, sdk.Error) {
-	// create a new limit order and then add it to the orderbook
-	newOrderID, err := k.getNewOrderID(ctx)
-	if err != nil {
-		return ProcessedLimitOrder{}, err
-	}
-
 	if amount.IsZero() {
-		return ProcessedLimitOrder{newOrderID, amount}, nil
+		return NewProcessedLimitOrder(orderID, amount), nil
 	}
 
 	// get orderbook
 	orderBook := k.getOrderBook(ctx, kind, amount.Denom, price.Denom)
 
-	limitOrder := NewLimitOrder(newOrderID, sender, kind, amount, price, expiresAt)
+	// create a new limit order and then add it to the orderbook
+	limitOrder := NewLimitOrder(orderID, sender, kind, amount, price, opts.ExpiresAt, opts.TimeInForce)
+	limitOrder.ExpiresAtHeight = opts.ExpiresAtHeight
+	limitOrder.SelfTradePrevention = opts.SelfTradePrevention
+	limitOrder.setDisplayAmount(opts.DisplayAmount)
+	limitOrder.ClientOrderID = opts.ClientOrderID
+	limitOrder.OCOGroup = opts.OCOGroup
+
+	// lock the order deposit, which is refunded when the order is closed
+	err := k.lockOrderDeposit(ctx, &limitOrder)
+	if err != nil {
+		return ProcessedLimitOrder{}, err
+	}
 
 	err = orderBook.AddLimitOrder(limitOrder)
 	if err != nil {
 		return ProcessedLimitOrder{}, err
 	}
 
-	// lock sender's coins to fill order in the future
-	var coinToLock sdk.Coin
-	if kind == BuyOrder {
-		coinToLock = getTotalPrice(amount, price)
-	} else {
-		coinToLock = amount
-	}
-	_, _, err = k.bankKeeper.SubtractCoins(ctx, sender, sdk.Coins{coinToLock})
+	// lock sender's coins in the escrow account to fill order in the future
+	err = k.lockCoins(ctx, sender, limitOrder.getLockedCoins())
 	if err != nil {
 		return ProcessedLimitOrder{}, err
 	}
 
 	k.setOrderBook(ctx, orderBook)
+	k.indexLimitOrder(ctx, limitOrder, orderBook.Key)
 
-	return ProcessedLimitOrder{newOrderID, amount}, nil
+	return NewProcessedLimitOrder(orderID, amount), nil
 }
 
 func (k Keeper) setInitialOrderID(ctx sdk.Context, orderID int64) sdk.Error {
@@ -285,62 +619,202 @@ func (k Keeper) getNewOrderID(ctx sdk.Context) (orderID int64, err sdk.Error) {
 	return orderID, nil
 }
 
+// refundExpiredLimitOrders removes expired orders from their order books and refunds the coins locked for them. The
+// orders are taken from the expiry indexes, earliest expiry time first, then lowest expiry height, and at most
+// MaxExpiriesPerBlock orders are removed per block. Expired orders beyond that are removed in the next blocks
 func (k Keeper) refundExpiredLimitOrders(ctx sdk.Context) {
-	// Iterate over all orderbooks
 	store := ctx.KVStore(k.storeKey)
-	iter := sdk.KVStorePrefixIterator(store, orderBookSubspace)
+	maxExpiries := k.MaxExpiriesPerBlock(ctx)
+
+	// all entries before these keys expired before the block time or at the current height
+	expiryKeys, orderIDs := k.getExpiryEntries(
+		store.Iterator(orderExpirySubspace, MakeKeyOrderExpiry(ctx.BlockHeader().Time, 0)), maxExpiries)
+	heightExpiryKeys, heightOrderIDs := k.getExpiryEntries(
+		store.Iterator(orderHeightExpirySubspace, MakeKeyOrderHeightExpiry(ctx.BlockHeight()+1, 0)),
+		maxExpiries-int64(len(orderIDs)))
+	expiryKeys = append(expiryKeys, heightExpiryKeys...)
+	orderIDs = append(orderIDs, heightOrderIDs...)
+
+	for i, orderID := range orderIDs {
+		orderBook, j, err := k.findLimitOrder(ctx, orderID)
+		if err != nil {
+			// the order is not open anymore, only its index entry was left
+			store.Delete(expiryKeys[i])
+			continue
+		}
 
-	now := time.Now()
-	obsToUpdate := make([]OrderBook, 0)
-	osToRefund := make([]LimitOrder, 0)
+		// an expired order cancels the other orders of its one-cancels-other group
+		order, err := k.removeOpenLimitOrder(ctx, orderBook, j)
+		if err != nil {
+			panic(err)
+		}
+		_, err = k.cancelOCOSiblings(ctx, order.Sender, order.OCOGroup, order.OrderID)
+		if err != nil {
+			panic(err)
+		}
+	}
+}
 
-	for ; iter.Valid(); iter.Next() {
-		ob := new(OrderBook)
-		k.cdc.UnmarshalBinary(iter.Value(), &ob)
-
-		containsExpired := false
-		newOrders := make([]LimitOrder, 0, len(ob.Orders))
-
-		// iterate over orders, only keeping not expired ones, and add expired ones to refund array
-		for _, order := range ob.Orders {
-			if order.ExpiresAt.Before(now) {
-				containsExpired = true
-				osToRefund = append(osToRefund, order)
-				continue
-			}
+// expireStoredLimitOrder refunds the coins and the deposit locked for an expired order that is still stored in an
+// order book and removes its index entries. The amount of the order is zeroed, so that it is removed from the order
+// book with the filled orders
+func (k Keeper) expireStoredLimitOrder(ctx sdk.Context, order *LimitOrder) sdk.Error {
+	err := k.releaseCoins(ctx, order.Sender, order.getLockedCoins())
+	if err != nil {
+		return err
+	}
+	err = k.releaseOrderDeposit(ctx, *order)
+	if err != nil {
+		return err
+	}
+	k.unindexLimitOrder(ctx, *order)
 
-			newOrders = append(newOrders, order)
-		}
+	order.Amount = sdk.NewInt64Coin(order.Amount.Denom, 0)
+	order.Iceberg = nil
+	return nil
+}
 
-		// if orderbook contains expired orders, add them to the slice we need to update
-		if containsExpired {
-			ob.Orders = newOrders
-			obsToUpdate = append(obsToUpdate, *ob)
-		}
+// getExpiryEntries returns the keys and order ids of at most max entries of an expiry index iterator and closes it
+func (k Keeper) getExpiryEntries(iter sdk.Iterator, max int64) ([][]byte, []int64) {
+	defer iter.Close()
+
+	keys := make([][]byte, 0)
+	orderIDs := make([]int64, 0)
+	for ; iter.Valid() && int64(len(orderIDs)) < max; iter.Next() {
+		var orderID int64
+		k.cdc.MustUnmarshalBinary(iter.Value(), &orderID)
+		keys = append(keys, iter.Key())
+		orderIDs = append(orderIDs, orderID)
 	}
 
-	iter.Close()
+	return keys, orderIDs
+}
 
-	// save the orderbooks that need to be updated
-	for _, ob := range obsToUpdate {
-		k.setOrderBook(ctx, ob)
+// indexLimitOrder saves the key of the order book an open order is stored in, both by order id and by sender, adds
+// the order to the expiry indexes of the expiry time and height it has and to its one-cancels-other group and counts
+// it as open order of the sender
+func (k Keeper) indexLimitOrder(ctx sdk.Context, order LimitOrder, orderBookKey []byte) {
+	store := ctx.KVStore(k.storeKey)
+	store.Set(MakeKeyOrderLocation(order.OrderID), orderBookKey)
+	store.Set(MakeKeyOrderBySender(order.Sender, order.OrderID), orderBookKey)
+	if !order.ExpiresAt.IsZero() {
+		store.Set(MakeKeyOrderExpiry(order.ExpiresAt, order.OrderID), k.cdc.MustMarshalBinary(order.OrderID))
+	}
+	if order.ExpiresAtHeight > 0 {
+		store.Set(MakeKeyOrderHeightExpiry(order.ExpiresAtHeight, order.OrderID),
+			k.cdc.MustMarshalBinary(order.OrderID))
 	}
+	if order.OCOGroup != "" {
+		store.Set(MakeKeyOCOGroupOrder(order.Sender, order.OCOGroup, order.OrderID),
+			k.cdc.MustMarshalBinary(order.OrderID))
+	}
+	k.addOpenOrderCount(ctx, order.Sender, order.Amount.Denom, order.Price.Denom, 1)
+}
 
-	// refund orders that were expired
-	for _, order := range osToRefund {
-		var coinsToUnlock sdk.Coin
-		if order.Kind == BuyOrder {
-			coinsToUnlock = getTotalPrice(order.Amount, order.Price)
-		} else {
-			coinsToUnlock = order.Amount
+// unindexLimitOrder removes the index entries of an order that is not open anymore
+func (k Keeper) unindexLimitOrder(ctx sdk.Context, order LimitOrder) {
+	store := ctx.KVStore(k.storeKey)
+	store.Delete(MakeKeyOrderLocation(order.OrderID))
+	store.Delete(MakeKeyOrderBySender(order.Sender, order.OrderID))
+	if !order.ExpiresAt.IsZero() {
+		store.Delete(MakeKeyOrderExpiry(order.ExpiresAt, order.OrderID))
+	}
+	if order.ExpiresAtHeight > 0 {
+		store.Delete(MakeKeyOrderHeightExpiry(order.ExpiresAtHeight, order.OrderID))
+	}
+	if order.OCOGroup != "" {
+		store.Delete(MakeKeyOCOGroupOrder(order.Sender, order.OCOGroup, order.OrderID))
+	}
+	k.addOpenOrderCount(ctx, order.Sender, order.Amount.Denom, order.Price.Denom, -1)
+}
+
+// getOpenLimitOrdersBySender returns all open orders of the sender across all token pairs, sorted by order id
+func (k Keeper) getOpenLimitOrdersBySender(ctx sdk.Context, sender sdk.AccAddress) []OpenLimitOrder {
+	store := ctx.KVStore(k.storeKey)
+	iter := sdk.KVStorePrefixIterator(store, MakeKeyOrdersBySenderSubspace(sender))
+	defer iter.Close()
+
+	orderBooks := make([]OrderBook, 0)
+	seen := make(map[string]bool)
+
+	for ; iter.Valid(); iter.Next() {
+		orderBookKey := iter.Value()
+		if seen[string(orderBookKey)] {
+			continue
 		}
-		_, _, err := k.bankKeeper.AddCoins(ctx, order.Sender, sdk.Coins{coinsToUnlock})
-		if err != nil {
-			panic(err)
+		seen[string(orderBookKey)] = true
+
+		orderBook := new(OrderBook)
+		k.cdc.MustUnmarshalBinary(store.Get(orderBookKey), &orderBook)
+		orderBooks = append(orderBooks, *orderBook)
+	}
+
+	return GetOpenLimitOrdersOfSender(sender, orderBooks)
+}
+
+// findLimitOrder returns the order book an open order is stored in and the index of the order in this book
+func (k Keeper) findLimitOrder(ctx sdk.Context, orderID int64) (OrderBook, int, sdk.Error) {
+	store := ctx.KVStore(k.storeKey)
+	orderBookKey := store.Get(MakeKeyOrderLocation(orderID))
+	if orderBookKey == nil {
+		return OrderBook{}, -1, ErrOrderNotFound(k.codespace, orderID)
+	}
+
+	orderBook := new(OrderBook)
+	k.cdc.MustUnmarshalBinary(store.Get(orderBookKey), &orderBook)
+
+	for i, order := range orderBook.Orders {
+		if order.OrderID == orderID {
+			return *orderBook, i, nil
 		}
 	}
+
+	return OrderBook{}, -1, ErrOrderNotFound(k.codespace, orderID)
+}
+
+// cancelLimitOrder removes an open order of the sender from its order book and refunds the coins locked for it from the
+// escrow account. The other orders of its one-cancels-other group are cancelled as well, their ids are returned
+func (k Keeper) cancelLimitOrder(ctx sdk.Context, sender sdk.AccAddress, orderID int64) (LimitOrder, []int64,
+	sdk.Error) {
+	orderBook, i, err := k.findLimitOrder(ctx, orderID)
+	if err != nil {
+		return LimitOrder{}, nil, err
+	}
+
+	if !bytes.Equal(orderBook.Orders[i].Sender, sender) {
+		return LimitOrder{}, nil, ErrNotOrderOwner(k.codespace, orderID)
+	}
+
+	order, err := k.removeOpenLimitOrder(ctx, orderBook, i)
+	if err != nil {
+		return LimitOrder{}, nil, err
+	}
+
+	cancelledOCO, err := k.cancelOCOSiblings(ctx, sender, order.OCOGroup, orderID)
+	if err != nil {
+		return LimitOrder{}, nil, err
+	}
+
+	return order, cancelledOCO, nil
 }
 
-func getTotalPrice(amt sdk.Coin, price sdk.Coin) sdk.Coin {
-	return sdk.Coin{price.Denom, amt.Amount.Mul(price.Amount)}
+// removeOpenLimitOrder removes the open order at the given index of the order book, refunds the coins and the deposit
+// locked for it and removes its index entries
+func (k Keeper) removeOpenLimitOrder(ctx sdk.Context, orderBook OrderBook, i int) (LimitOrder, sdk.Error) {
+	order := orderBook.Orders[i]
+
+	err := k.releaseCoins(ctx, order.Sender, order.getLockedCoins())
+	if err != nil {
+		return LimitOrder{}, err
+	}
+	err = k.releaseOrderDeposit(ctx, order)
+	if err != nil {
+		return LimitOrder{}, err
+	}
+
+	orderBook.RemoveLimitOrder(i)
+	k.setOrderBook(ctx, orderBook)
+	k.unindexLimitOrder(ctx, order)
+
+	return order, nil
 }
@@ -0,0 +1,441 @@
+package exchange
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// BatchAuctionResult is published as an event after the orders of a batch auction market have been cleared
+type BatchAuctionResult struct {
+	AmountDenom   string   `json:"amount_denom"`
+	PriceDenom    string   `json:"price_denom"`
+	ClearingPrice Price    `json:"clearing_price"`
+	Volume        sdk.Coin `json:"volume"`
+	Trades        []Trade  `json:"trades"`
+	// matches between orders of the same sender that have been prevented
+	PreventedSelfTrades []PreventedSelfTrade `json:"prevented_self_trades"`
+	// stored orders that have been cancelled because another order of their one-cancels-other group has been filled
+	// or cancelled in the auction
+	CancelledOCOOrderIDs []int64 `json:"cancelled_oco_orders,omitempty"`
+}
+
+var batchOrderSubspace = []byte("batchOrder:")
+
+// Key for getting all orders collected for the batch auctions of the current block from the store
+func MakeKeyBatchOrdersSubspace() []byte {
+	return batchOrderSubspace
+}
+
+// Key for getting an order collected for the batch auction of the current block from the store
+func MakeKeyBatchOrder(amountDenom string, priceDenom string, orderID int64) []byte {
+	return []byte(fmt.Sprintf("batchOrder:%v:%v:%020d", amountDenom, priceDenom, orderID))
+}
+
+// processBatchLimitOrder collects an order of a batch auction market. Post-only and fill-or-kill orders cannot be
+// checked before the auction is cleared and are rejected
+func (k Keeper) processBatchLimitOrder(
+	ctx sdk.Context, sender sdk.AccAddress, kind OrderKind, amount sdk.Coin, price Price, opts OrderOptions,
+) (ProcessedLimitOrder, []FilledLimitOrder, sdk.Error) {
+	if opts.TimeInForce == PostOnly || opts.TimeInForce == FillOrKill {
+		return ProcessedLimitOrder{}, []FilledLimitOrder{}, ErrTimeInForceNotSupported(k.codespace)
+	}
+
+	orderID, err := k.getNewOrderID(ctx)
+	if err != nil {
+		return ProcessedLimitOrder{}, []FilledLimitOrder{}, err
+	}
+
+	order := NewLimitOrder(orderID, sender, kind, amount, price, opts.ExpiresAt, opts.TimeInForce)
+	order.ExpiresAtHeight = opts.ExpiresAtHeight
+	order.SelfTradePrevention = opts.SelfTradePrevention
+	order.ClientOrderID = opts.ClientOrderID
+	k.setClientOrderID(ctx, sender, opts.ClientOrderID, orderID)
+
+	// the whole amount of an iceberg order takes part in the auction, only the rest is hidden in the order book
+	order.setDisplayAmount(opts.DisplayAmount)
+	order.showReserve()
+
+	// the order deposit is refunded when the order is closed, immediate-or-cancel orders never rest and pay none
+	if opts.TimeInForce != ImmediateOrCancel {
+		err = k.lockOrderDeposit(ctx, &order)
+		if err != nil {
+			return ProcessedLimitOrder{}, []FilledLimitOrder{}, err
+		}
+	}
+
+	err = k.collectBatchOrder(ctx, order)
+	if err != nil {
+		return ProcessedLimitOrder{}, []FilledLimitOrder{}, err
+	}
+
+	processed := NewProcessedLimitOrder(orderID, amount)
+	processed.ClientOrderID = opts.ClientOrderID
+	return processed, []FilledLimitOrder{}, nil
+}
+
+// collectBatchOrder locks the coins of an order of a batch auction market and stores it until the auction is cleared
+// at the end of the block. Until then, it counts as open order of the sender
+func (k Keeper) collectBatchOrder(ctx sdk.Context, order LimitOrder) sdk.Error {
+	err := k.lockCoins(ctx, order.Sender, order.getLockedCoins())
+	if err != nil {
+		return err
+	}
+
+	store := ctx.KVStore(k.storeKey)
+	store.Set(MakeKeyBatchOrder(order.Amount.Denom, order.Price.Denom, order.OrderID), k.cdc.MustMarshalBinary(order))
+	k.addOpenOrderCount(ctx, order.Sender, order.Amount.Denom, order.Price.Denom, 1)
+	return nil
+}
+
+// clearBatchAuctions clears the batch auctions of all markets that collected orders in this block
+func (k Keeper) clearBatchAuctions(ctx sdk.Context) []BatchAuctionResult {
+	store := ctx.KVStore(k.storeKey)
+	iter := sdk.KVStorePrefixIterator(store, batchOrderSubspace)
+
+	// orders are sorted by token pair, then order id
+	keys := make([][]byte, 0)
+	ordersByPair := make([][]LimitOrder, 0)
+	var lastPair string
+
+	for ; iter.Valid(); iter.Next() {
+		var order LimitOrder
+		k.cdc.MustUnmarshalBinary(iter.Value(), &order)
+		keys = append(keys, iter.Key())
+
+		pair := order.Amount.Denom + ":" + order.Price.Denom
+		if len(ordersByPair) == 0 || pair != lastPair {
+			ordersByPair = append(ordersByPair, make([]LimitOrder, 0))
+			lastPair = pair
+		}
+		ordersByPair[len(ordersByPair)-1] = append(ordersByPair[len(ordersByPair)-1], order)
+	}
+
+	iter.Close()
+
+	for _, key := range keys {
+		store.Delete(key)
+	}
+
+	results := make([]BatchAuctionResult, 0, len(ordersByPair))
+	for _, orders := range ordersByPair {
+		results = append(results, k.clearBatchAuction(ctx, orders[0].Amount.Denom, orders[0].Price.Denom, orders))
+	}
+
+	return results
+}
+
+// clearBatchAuction matches the collected orders and the orders stored in the order books of a token pair at the
+// uniform clearing price. Unfilled good-till-time orders are stored in the order books, the unfilled part of
+// immediate-or-cancel orders and expired stored orders are refunded. Matches between orders of the same sender are
+// prevented, so less than the volume at the clearing price may be filled. Stored orders of a one-cancels-other group
+// are not matched anymore once another order of the group has been filled, cancelled or has expired, and are
+// cancelled after the auction
+// nolint gocyclo
+func (k Keeper) clearBatchAuction(ctx sdk.Context, amountDenom string, priceDenom string, collected []LimitOrder,
+) BatchAuctionResult {
+	buyOrderBook := k.getOrderBook(ctx, BuyOrder, amountDenom, priceDenom)
+	sellOrderBook := k.getOrderBook(ctx, SellOrder, amountDenom, priceDenom)
+
+	// stored orders that have expired but have not been removed by the expiry queue yet are refunded instead of taking
+	// part in the auction
+	expired := make([]LimitOrder, 0)
+	buys := make([]LimitOrder, 0, len(buyOrderBook.Orders))
+	sells := make([]LimitOrder, 0, len(sellOrderBook.Orders))
+	for _, order := range append(append(make([]LimitOrder, 0), buyOrderBook.Orders...), sellOrderBook.Orders...) {
+		if isExpired(order.ExpiresAt, order.ExpiresAtHeight, ctx.BlockHeader().Time, ctx.BlockHeight()) {
+			expired = append(expired, order)
+			err := k.expireStoredLimitOrder(ctx, &order)
+			if err != nil {
+				panic(err)
+			}
+			continue
+		}
+		order.showReserve()
+		if order.Kind == BuyOrder {
+			buys = append(buys, order)
+		} else {
+			sells = append(sells, order)
+		}
+	}
+	isCollected := make(map[int64]bool)
+	for _, order := range collected {
+		isCollected[order.OrderID] = true
+		if order.Kind == BuyOrder {
+			buys = append(buys, order)
+		} else {
+			sells = append(sells, order)
+		}
+	}
+	sortBatchOrders(buys, sells)
+
+	market := k.getMarket(ctx, amountDenom, priceDenom)
+
+	// a market halted after orders have been collected in this block does not match them, they are stored in the order
+	// books or refunded like unfilled orders
+	clearingPrice, volume := getClearingPrice(buys, sells)
+	if market.Halted {
+		volume = sdk.ZeroInt()
+	}
+	// auctions without matches have no clearing price, a zero price keeps the result serializable
+	if volume.IsZero() {
+		clearingPrice = NewInt64Price(priceDenom, 0)
+	}
+	result := BatchAuctionResult{amountDenom, priceDenom, clearingPrice, sdk.Coin{amountDenom, volume},
+		make([]Trade, 0), make([]PreventedSelfTrade, 0), nil}
+
+	makerFeeRate, takerFeeRate := k.getFeeRates(ctx, market)
+
+	// stored orders that cancel their one-cancels-other groups, by group
+	triggered := make(map[string]LimitOrder)
+	triggeredGroups := make([]string, 0)
+	trigger := func(order LimitOrder) {
+		group := getOCOGroupKey(order)
+		if _, ok := triggered[group]; !ok && group != "" {
+			triggered[group] = order
+			triggeredGroups = append(triggeredGroups, group)
+		}
+	}
+	isCancelledByGroup := func(order LimitOrder) bool {
+		t, ok := triggered[getOCOGroupKey(order)]
+		return ok && t.OrderID != order.OrderID
+	}
+	for _, order := range expired {
+		trigger(order)
+	}
+
+	// fill the best buy and sell orders until the volume is reached, everything at the clearing price
+	remaining := volume
+	for i, j := 0, 0; remaining.Sign() > 0 && i < len(buys) && j < len(sells) &&
+		buys[i].Price.IsGTE(clearingPrice) && clearingPrice.IsGTE(sells[j].Price); {
+		if isCancelledByGroup(buys[i]) {
+			i++
+			continue
+		}
+		if isCancelledByGroup(sells[j]) {
+			j++
+			continue
+		}
+
+		if bytes.Equal(buys[i].Sender, sells[j].Sender) {
+			prevented := k.preventBatchSelfTrade(ctx, &buys[i], &sells[j], market.SelfTradePrevention)
+			result.PreventedSelfTrades = append(result.PreventedSelfTrades, prevented)
+
+			if buys[i].Amount.IsZero() {
+				trigger(buys[i])
+			}
+			if sells[j].Amount.IsZero() {
+				trigger(sells[j])
+			}
+		} else {
+			fillAmount := sdk.MinInt(remaining, sdk.MinInt(buys[i].Amount.Amount, sells[j].Amount.Amount))
+			fill := sdk.Coin{amountDenom, fillAmount}
+
+			trade := k.settleBatchFill(ctx, buys[i], sells[j], fill, clearingPrice, makerFeeRate, takerFeeRate)
+			result.Trades = append(result.Trades, trade)
+
+			remaining = remaining.Sub(fillAmount)
+			buys[i].Amount = buys[i].Amount.Minus(fill)
+			sells[j].Amount = sells[j].Amount.Minus(fill)
+
+			trigger(buys[i])
+			trigger(sells[j])
+		}
+
+		if buys[i].Amount.IsZero() {
+			i++
+		}
+		if sells[j].Amount.IsZero() {
+			j++
+		}
+	}
+
+	result.Volume = sdk.Coin{amountDenom, volume.Sub(remaining)}
+
+	buyOrderBook.Orders = k.restBatchOrders(ctx, buys, isCollected, buyOrderBook.Key)
+	sellOrderBook.Orders = k.restBatchOrders(ctx, sells, isCollected, sellOrderBook.Key)
+	k.setOrderBook(ctx, buyOrderBook)
+	k.setOrderBook(ctx, sellOrderBook)
+
+	// the siblings are cancelled once the order books have been saved, as they may be stored in them
+	for _, group := range triggeredGroups {
+		order := triggered[group]
+		cancelled, err := k.cancelOCOSiblings(ctx, order.Sender, order.OCOGroup, order.OrderID)
+		if err != nil {
+			panic(err)
+		}
+		result.CancelledOCOOrderIDs = append(result.CancelledOCOOrderIDs, cancelled...)
+	}
+
+	return result
+}
+
+// settleBatchFill settles a fill between a buy and a sell order at the clearing price. The older order is the maker.
+// The buyer is refunded the difference between its limit and the clearing price
+func (k Keeper) settleBatchFill(ctx sdk.Context, buy LimitOrder, sell LimitOrder, amount sdk.Coin,
+	clearingPrice Price, makerFeeRate int64, takerFeeRate int64) Trade {
+	maker, taker := sell, buy
+	buyerFeeRate, sellerFeeRate := takerFeeRate, makerFeeRate
+	if buy.OrderID < sell.OrderID {
+		maker, taker = buy, sell
+		buyerFeeRate, sellerFeeRate = makerFeeRate, takerFeeRate
+	}
+
+	totalPrice := getTotalPrice(amount, clearingPrice)
+	buyerFee := getFee(amount, buyerFeeRate)
+	sellerFee := getFee(totalPrice, sellerFeeRate)
+	refund := getTotalPrice(amount, buy.Price).Minus(totalPrice)
+
+	k.mustReleaseCoins(ctx, buy.Sender, amount.Minus(buyerFee))
+	k.mustReleaseCoins(ctx, buy.Sender, refund)
+	k.mustReleaseCoins(ctx, sell.Sender, totalPrice.Minus(sellerFee))
+	k.mustCollectFee(ctx, buyerFee)
+	k.mustCollectFee(ctx, sellerFee)
+
+	makerFee, takerFee := sellerFee, buyerFee
+	if maker.Kind == BuyOrder {
+		makerFee, takerFee = buyerFee, sellerFee
+	}
+
+	return k.storeTrade(ctx, maker, taker.OrderID, taker.ClientOrderID, taker.Sender, taker.Kind, amount,
+		clearingPrice, makerFee, takerFee)
+}
+
+// restBatchOrders returns the orders that stay in the order book after an auction and updates the indexes and
+// refunds accordingly
+func (k Keeper) restBatchOrders(ctx sdk.Context, orders []LimitOrder, isCollected map[int64]bool,
+	orderBookKey []byte) []LimitOrder {
+	rest := make([]LimitOrder, 0, len(orders))
+
+	for _, order := range orders {
+		// collected orders are counted as open orders again when they are indexed
+		if isCollected[order.OrderID] {
+			k.addOpenOrderCount(ctx, order.Sender, order.Amount.Denom, order.Price.Denom, -1)
+		}
+
+		if order.Amount.IsZero() {
+			if !isCollected[order.OrderID] {
+				k.unindexLimitOrder(ctx, order)
+			}
+			k.mustReleaseOrderDeposit(ctx, order)
+			continue
+		}
+
+		if isCollected[order.OrderID] {
+			if order.TimeInForce == ImmediateOrCancel {
+				k.mustReleaseCoins(ctx, order.Sender, order.getLockedCoins())
+				continue
+			}
+			k.indexLimitOrder(ctx, order, orderBookKey)
+		}
+
+		order.hideReserve()
+		rest = append(rest, order)
+	}
+
+	return rest
+}
+
+// mustReleaseOrderDeposit refunds the deposit of a closed order, errors cannot be handled at the end of a block
+func (k Keeper) mustReleaseOrderDeposit(ctx sdk.Context, order LimitOrder) {
+	err := k.releaseOrderDeposit(ctx, order)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// mustReleaseCoins moves locked coins from the escrow account to the given account, errors cannot be handled at the
+// end of a block
+func (k Keeper) mustReleaseCoins(ctx sdk.Context, addr sdk.AccAddress, coin sdk.Coin) {
+	err := k.releaseCoins(ctx, addr, coin)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// mustCollectFee moves a fee from the escrow account to the fee pool, errors cannot be handled at the end of a block
+func (k Keeper) mustCollectFee(ctx sdk.Context, fee sdk.Coin) {
+	err := k.collectFee(ctx, EscrowAddress, fee)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// sortBatchOrders sorts buy orders by highest and sell orders by lowest price, then by order id, which is the order
+// of the order books
+func sortBatchOrders(buys []LimitOrder, sells []LimitOrder) {
+	sort.SliceStable(buys, func(i, j int) bool {
+		if !buys[i].Price.IsEqual(buys[j].Price) {
+			return buys[j].Price.Amount.LT(buys[i].Price.Amount)
+		}
+		return buys[i].OrderID < buys[j].OrderID
+	})
+	sort.SliceStable(sells, func(i, j int) bool {
+		if !sells[i].Price.IsEqual(sells[j].Price) {
+			return sells[i].Price.Amount.LT(sells[j].Price.Amount)
+		}
+		return sells[i].OrderID < sells[j].OrderID
+	})
+}
+
+// getClearingPrice returns the price that maximises the volume that can be matched between the buy and sell orders,
+// and this volume. If several prices reach the maximum volume, the one with the smallest difference between demand
+// and supply is chosen, then the lowest price. The orders must be sorted by sortBatchOrders
+func getClearingPrice(buys []LimitOrder, sells []LimitOrder) (Price, sdk.Int) {
+	bestPrice := Price{}
+	bestVolume := sdk.ZeroInt()
+	bestImbalance := sdk.ZeroInt()
+
+	// the prices of all orders, lowest first, so that demand and supply can be accumulated in a single pass
+	candidates := make([]Price, 0, len(buys)+len(sells))
+	for _, order := range buys {
+		candidates = append(candidates, order.Price)
+	}
+	for _, order := range sells {
+		candidates = append(candidates, order.Price)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Amount.LT(candidates[j].Amount)
+	})
+
+	// demand starts with all buys and loses the buys below the price, supply gains the sells up to the price
+	demand := sdk.ZeroInt()
+	for _, order := range buys {
+		demand = demand.Add(order.Amount.Amount)
+	}
+	supply := sdk.ZeroInt()
+	nextBuy, nextSell := len(buys)-1, 0
+
+	for i, price := range candidates {
+		if i > 0 && price.IsEqual(candidates[i-1]) {
+			continue
+		}
+
+		for ; nextBuy >= 0 && price.Amount.GT(buys[nextBuy].Price.Amount); nextBuy-- {
+			demand = demand.Sub(buys[nextBuy].Amount.Amount)
+		}
+		for ; nextSell < len(sells) && price.IsGTE(sells[nextSell].Price); nextSell++ {
+			supply = supply.Add(sells[nextSell].Amount.Amount)
+		}
+
+		volume := sdk.MinInt(demand, supply)
+		imbalance := demand.Sub(supply)
+		if imbalance.Sign() < 0 {
+			imbalance = imbalance.Neg()
+		}
+
+		if volume.Sign() <= 0 {
+			continue
+		}
+
+		// candidates are visited lowest first, so the lowest price wins a tie
+		better := volume.GT(bestVolume) || (volume.Equal(bestVolume) && imbalance.LT(bestImbalance))
+		if better {
+			bestPrice, bestVolume, bestImbalance = price, volume, imbalance
+		}
+	}
+
+	return bestPrice, bestVolume
+}
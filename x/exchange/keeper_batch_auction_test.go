@@ -0,0 +1,246 @@
+package exchange
+
+import (
+	"encoding/json"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+func TestGetClearingPrice(t *testing.T) {
+	expiresAt := time.Now().Add(time.Minute).UTC()
+	buys := []LimitOrder{
+		NewLimitOrder(2, nil, BuyOrder, sdk.NewInt64Coin("ETH", 50), NewInt64Price("RUNE", 8), expiresAt,
+			GoodTillTime),
+		NewLimitOrder(1, nil, BuyOrder, sdk.NewInt64Coin("ETH", 100), NewInt64Price("RUNE", 10), expiresAt,
+			GoodTillTime),
+	}
+	sells := []LimitOrder{
+		NewLimitOrder(4, nil, SellOrder, sdk.NewInt64Coin("ETH", 60), NewInt64Price("RUNE", 9), expiresAt,
+			GoodTillTime),
+		NewLimitOrder(3, nil, SellOrder, sdk.NewInt64Coin("ETH", 80), NewInt64Price("RUNE", 7), expiresAt,
+			GoodTillTime),
+	}
+	sortBatchOrders(buys, sells)
+	require.Equal(t, int64(1), buys[0].OrderID)
+	require.Equal(t, int64(3), sells[0].OrderID)
+
+	// 9RUNE and 10RUNE both match 100ETH with the same imbalance, the lower price wins
+	price, volume := getClearingPrice(buys, sells)
+	require.Equal(t, NewInt64Price("RUNE", 9), price)
+	require.Equal(t, int64(100), volume.Int64())
+
+	// no overlap
+	_, volume = getClearingPrice(buys[1:], sells[1:])
+	require.True(t, volume.IsZero())
+}
+
+// Test if the clearing price matches the price found by trying every price against every order
+func TestGetClearingPriceMatchesAllPrices(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	for round := 0; round < 100; round++ {
+		buys, sells := make([]LimitOrder, 0), make([]LimitOrder, 0)
+		for i := 0; i < random.Intn(20); i++ {
+			buys = append(buys, NewLimitOrder(int64(i), nil, BuyOrder, sdk.NewInt64Coin("ETH", random.Int63n(50)+1),
+				NewInt64Price("RUNE", random.Int63n(10)+1), time.Time{}, GoodTillCancelled))
+		}
+		for i := 0; i < random.Intn(20); i++ {
+			sells = append(sells, NewLimitOrder(int64(100+i), nil, SellOrder, sdk.NewInt64Coin("ETH",
+				random.Int63n(50)+1), NewInt64Price("RUNE", random.Int63n(10)+1), time.Time{}, GoodTillCancelled))
+		}
+		sortBatchOrders(buys, sells)
+
+		bestPrice, bestVolume, bestImbalance := Price{}, sdk.ZeroInt(), sdk.ZeroInt()
+		isOrderPrice := make(map[int64]bool)
+		for _, order := range append(append([]LimitOrder{}, buys...), sells...) {
+			isOrderPrice[order.Price.Amount.Num().Int64()] = true
+		}
+		for price := int64(1); price <= 10; price++ {
+			if !isOrderPrice[price] {
+				continue
+			}
+			demand, supply := sdk.ZeroInt(), sdk.ZeroInt()
+			for _, order := range buys {
+				if order.Price.Amount.GTE(sdk.NewRat(price)) {
+					demand = demand.Add(order.Amount.Amount)
+				}
+			}
+			for _, order := range sells {
+				if sdk.NewRat(price).GTE(order.Price.Amount) {
+					supply = supply.Add(order.Amount.Amount)
+				}
+			}
+			volume, imbalance := sdk.MinInt(demand, supply), demand.Sub(supply)
+			if imbalance.Sign() < 0 {
+				imbalance = imbalance.Neg()
+			}
+			if volume.GT(bestVolume) || (volume.Sign() > 0 && volume.Equal(bestVolume) && imbalance.LT(bestImbalance)) {
+				bestPrice, bestVolume, bestImbalance = NewInt64Price("RUNE", price), volume, imbalance
+			}
+		}
+
+		price, volume := getClearingPrice(buys, sells)
+		require.Equal(t, bestVolume, volume)
+		if volume.Sign() > 0 {
+			require.True(t, bestPrice.IsEqual(price), "expected %v, got %v", bestPrice, price)
+		}
+	}
+}
+
+func TestKeeperSetMarketMode(t *testing.T) {
+	ctx := setupContext(exchangeKey)
+	keeper, _, _, authority, other := setupKeepers(exchangeKey, ctx)
+
+	// nobody may change markets without an authority
+	_, err := keeper.setMarketMode(ctx, authority, "ETH", "RUNE", BatchAuctionMode)
+	require.Equal(t, CodeUnauthorized, err.Code())
+
+	keeper.setParams(ctx, Params{Authority: authority.String()})
+	_, err = keeper.setMarketMode(ctx, other, "ETH", "RUNE", BatchAuctionMode)
+	require.Equal(t, CodeUnauthorized, err.Code())
+	_, err = keeper.setMarketMode(ctx, authority, "ETH", "RUNE", MarketMode(0x02))
+	require.Equal(t, CodeInvalidMarketMode, err.Code())
+
+	require.Equal(t, ContinuousMode, keeper.getMarket(ctx, "ETH", "RUNE").Mode)
+	market, err := keeper.setMarketMode(ctx, authority, "ETH", "RUNE", BatchAuctionMode)
+	require.Nil(t, err)
+	require.Equal(t, BatchAuctionMode, market.Mode)
+	require.Equal(t, market, keeper.getMarket(ctx, "ETH", "RUNE"))
+
+	// unlisted markets cannot be configured
+	_, err = keeper.setMarketMode(ctx, authority, "LTC", "RUNE", BatchAuctionMode)
+	require.Equal(t, CodeMarketNotListed, err.Code())
+}
+
+func TestKeeperBatchAuction(t *testing.T) {
+	ctx := setupContext(exchangeKey)
+	keeper, _, bankKeeper, buyer, seller := setupKeepers(exchangeKey, ctx)
+	market := newListedMarket("ETH", "RUNE")
+	market.Mode = BatchAuctionMode
+	keeper.setMarket(ctx, market)
+
+	bankKeeper.SetCoins(ctx, buyer, sdk.Coins{sdk.NewInt64Coin("RUNE", 2000)})
+	bankKeeper.SetCoins(ctx, seller, sdk.Coins{sdk.NewInt64Coin("ETH", 250)})
+	expiresAt := time.Now().Add(time.Minute).UTC()
+
+	// fill-or-kill and post-only orders are not supported
+	_, _, err := keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 10), NewInt64Price("RUNE", 10),
+		OrderOptions{ExpiresAt: expiresAt, TimeInForce: FillOrKill})
+	require.Equal(t, CodeInvalidTimeInForce, err.Code())
+
+	// orders are only collected
+	sell, filled, err := keeper.processLimitOrder(ctx, seller, SellOrder, sdk.NewInt64Coin("ETH", 100),
+		NewInt64Price("RUNE", 7), OrderOptions{ExpiresAt: expiresAt})
+	require.Nil(t, err)
+	require.Len(t, filled, 0)
+	require.Equal(t, sdk.NewInt64Coin("ETH", 100), sell.OpenAmount)
+	_, _, err = keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 60), NewInt64Price("RUNE", 10),
+		OrderOptions{ExpiresAt: expiresAt, TimeInForce: ImmediateOrCancel})
+	require.Nil(t, err)
+	buy, _, err := keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 50), NewInt64Price("RUNE", 8),
+		OrderOptions{ExpiresAt: expiresAt})
+	require.Nil(t, err)
+
+	require.Len(t, keeper.getOrderBook(ctx, SellOrder, "ETH", "RUNE").Orders, 0)
+	require.Equal(t, "1000RUNE", bankKeeper.GetCoins(ctx, buyer).String())
+	require.Equal(t, "150ETH", bankKeeper.GetCoins(ctx, seller).String())
+
+	// 7RUNE and 8RUNE both match 100ETH with the same imbalance, the lower price wins
+	tags := EndBlocker(ctx, keeper)
+	require.Len(t, tags, 1)
+	require.Equal(t, "batch_auction", string(tags[0].Key))
+
+	trades := keeper.getTradesByPair(ctx, "ETH", "RUNE", 1, 10)
+	require.Len(t, trades, 2)
+	for _, trade := range trades {
+		require.Equal(t, sell.OrderID, trade.MakerOrderID)
+		require.Equal(t, NewInt64Price("RUNE", 7), trade.Price)
+	}
+	require.Equal(t, sdk.NewInt64Coin("ETH", 40), trades[0].Amount)
+	require.Equal(t, sdk.NewInt64Coin("ETH", 60), trades[1].Amount)
+
+	// the buyer is refunded the difference to the clearing price, the rest of the good-till-time order stays open
+	require.Equal(t, "100ETH,1220RUNE", bankKeeper.GetCoins(ctx, buyer).String())
+	require.Equal(t, "150ETH,700RUNE", bankKeeper.GetCoins(ctx, seller).String())
+
+	buyOrderBook := keeper.getOrderBook(ctx, BuyOrder, "ETH", "RUNE")
+	require.Len(t, buyOrderBook.Orders, 1)
+	require.Equal(t, buy.OrderID, buyOrderBook.Orders[0].OrderID)
+	require.Equal(t, sdk.NewInt64Coin("ETH", 10), buyOrderBook.Orders[0].Amount)
+	require.Len(t, keeper.getOrderBook(ctx, SellOrder, "ETH", "RUNE").Orders, 0)
+	require.Len(t, keeper.getOpenLimitOrdersBySender(ctx, buyer), 1)
+
+	// nothing left to clear
+	tags = EndBlocker(ctx, keeper)
+	require.Len(t, tags, 0)
+}
+
+// Test if stored orders that expired but have not been removed yet do not take part in an auction
+func TestKeeperBatchAuctionSkipsExpiredOrders(t *testing.T) {
+	ctx := setupContext(exchangeKey).WithBlockHeight(10)
+	keeper, _, bankKeeper, buyer, seller := setupKeepers(exchangeKey, ctx)
+	bankKeeper.SetCoins(ctx, buyer, sdk.Coins{sdk.NewInt64Coin("RUNE", 100)})
+	bankKeeper.SetCoins(ctx, seller, sdk.Coins{sdk.NewInt64Coin("ETH", 10)})
+
+	// the sell order is stored while the market is continuous
+	_, _, err := keeper.processLimitOrder(ctx, seller, SellOrder, sdk.NewInt64Coin("ETH", 10), NewInt64Price("RUNE", 5),
+		OrderOptions{ExpiresAtHeight: 12})
+	require.Nil(t, err)
+
+	market := newListedMarket("ETH", "RUNE")
+	market.Mode = BatchAuctionMode
+	keeper.setMarket(ctx, market)
+
+	ctx = ctx.WithBlockHeight(12)
+	_, _, err = keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 10), NewInt64Price("RUNE", 5),
+		OrderOptions{ExpiresAtHeight: 20})
+	require.Nil(t, err)
+
+	EndBlocker(ctx, keeper)
+	require.Len(t, keeper.getTradesByPair(ctx, "ETH", "RUNE", 1, 10), 0)
+	require.Len(t, keeper.getOrderBook(ctx, SellOrder, "ETH", "RUNE").Orders, 0)
+	require.Len(t, keeper.getOrderBook(ctx, BuyOrder, "ETH", "RUNE").Orders, 1)
+	require.Equal(t, "10ETH", bankKeeper.GetCoins(ctx, seller).String())
+	require.Equal(t, "50RUNE", bankKeeper.GetCoins(ctx, buyer).String())
+}
+
+// Test if the auction of a market whose orders do not cross has a zero clearing price and can be reported as tag
+func TestKeeperBatchAuctionNoCross(t *testing.T) {
+	ctx := setupContext(exchangeKey)
+	keeper, _, bankKeeper, buyer, seller := setupKeepers(exchangeKey, ctx)
+	market := newListedMarket("ETH", "RUNE")
+	market.Mode = BatchAuctionMode
+	keeper.setMarket(ctx, market)
+
+	bankKeeper.SetCoins(ctx, buyer, sdk.Coins{sdk.NewInt64Coin("RUNE", 100)})
+	bankKeeper.SetCoins(ctx, seller, sdk.Coins{sdk.NewInt64Coin("ETH", 100)})
+	expiresAt := time.Now().Add(time.Minute).UTC()
+
+	_, _, err := keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 10), NewInt64Price("RUNE", 5),
+		OrderOptions{ExpiresAt: expiresAt})
+	require.Nil(t, err)
+	_, _, err = keeper.processLimitOrder(ctx, seller, SellOrder, sdk.NewInt64Coin("ETH", 10), NewInt64Price("RUNE", 6),
+		OrderOptions{ExpiresAt: expiresAt})
+	require.Nil(t, err)
+
+	tags := EndBlocker(ctx, keeper)
+	require.Len(t, tags, 1)
+
+	var result struct {
+		ClearingPrice struct {
+			Denom  string `json:"denom"`
+			Amount string `json:"amount"`
+		} `json:"clearing_price"`
+		Trades []Trade `json:"trades"`
+	}
+	require.Nil(t, json.Unmarshal(tags[0].Value, &result))
+	require.Equal(t, "RUNE", result.ClearingPrice.Denom)
+	require.Equal(t, "0", result.ClearingPrice.Amount)
+	require.Len(t, result.Trades, 0)
+	require.Len(t, keeper.getOrderBook(ctx, BuyOrder, "ETH", "RUNE").Orders, 1)
+	require.Len(t, keeper.getOrderBook(ctx, SellOrder, "ETH", "RUNE").Orders, 1)
+}
@@ -0,0 +1,71 @@
+package exchange
+
+import (
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// updateCandles adds a trade to the candles of all intervals of its token pair
+func (k Keeper) updateCandles(ctx sdk.Context, t time.Time, amount sdk.Coin, price Price) {
+	store := ctx.KVStore(k.storeKey)
+
+	for _, interval := range CandleIntervals {
+		key := MakeKeyCandle(amount.Denom, price.Denom, interval, getCandleStartTime(interval, t))
+
+		var candle Candle
+		bz := store.Get(key)
+		if bz == nil {
+			candle = NewCandle(interval, t, amount, price)
+		} else {
+			k.cdc.MustUnmarshalBinary(bz, &candle)
+			candle.AddTrade(amount, price)
+		}
+
+		k.setCandle(ctx, candle)
+	}
+}
+
+// setCandle saves a candle
+func (k Keeper) setCandle(ctx sdk.Context, candle Candle) {
+	store := ctx.KVStore(k.storeKey)
+	key := MakeKeyCandle(candle.AmountDenom, candle.PriceDenom, candle.Interval, candle.StartTime)
+	store.Set(key, k.cdc.MustMarshalBinary(candle))
+}
+
+// getAllCandles returns the candles of all token pairs and intervals
+func (k Keeper) getAllCandles(ctx sdk.Context) []Candle {
+	candles := make([]Candle, 0)
+
+	store := ctx.KVStore(k.storeKey)
+	iter := sdk.KVStorePrefixIterator(store, candleSubspace)
+	defer iter.Close()
+
+	for ; iter.Valid(); iter.Next() {
+		var candle Candle
+		k.cdc.MustUnmarshalBinary(iter.Value(), &candle)
+		candles = append(candles, candle)
+	}
+
+	return candles
+}
+
+// getCandles returns the candles of a token pair and interval that cover the time range from `from` to `to`, sorted
+// by start time. Intervals without trades have no candle
+func (k Keeper) getCandles(ctx sdk.Context, amountDenom string, priceDenom string, interval CandleInterval,
+	from time.Time, to time.Time) []Candle {
+	candles := make([]Candle, 0)
+
+	store := ctx.KVStore(k.storeKey)
+	start, end := MakeKeyCandleRange(amountDenom, priceDenom, interval, from, to)
+	iter := store.Iterator(start, end)
+	defer iter.Close()
+
+	for ; iter.Valid(); iter.Next() {
+		var candle Candle
+		k.cdc.MustUnmarshalBinary(iter.Value(), &candle)
+		candles = append(candles, candle)
+	}
+
+	return candles
+}
@@ -0,0 +1,94 @@
+package exchange
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/tendermint/abci/types"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/wire"
+)
+
+func TestKeeperUpdateCandles(t *testing.T) {
+	ctx, keeper, _, buyer, seller, _, _, _, _ := setupCreateBuyLimitOrderTest()
+
+	start := time.Date(2018, 10, 20, 10, 0, 0, 0, time.UTC)
+	expiresAt := time.Now().Add(time.Minute).UTC()
+
+	// 2 trades at 10:00:10 (120ETH@6 and 80ETH@7)
+	ctx = ctx.WithBlockHeader(abci.Header{Time: start.Add(10 * time.Second)})
+	_, _, err := keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 200), NewInt64Price("RUNE", 8),
+		OrderOptions{ExpiresAt: expiresAt})
+	require.Nil(t, err)
+
+	// 1 trade at 10:02:00 (30ETH@4)
+	ctx = ctx.WithBlockHeader(abci.Header{Time: start.Add(2 * time.Minute)})
+	_, _, err = keeper.processLimitOrder(ctx, seller, SellOrder, sdk.NewInt64Coin("ETH", 30), NewInt64Price("RUNE", 4),
+		OrderOptions{ExpiresAt: expiresAt})
+	require.Nil(t, err)
+
+	// one minute candles
+	candles := keeper.getCandles(ctx, "ETH", "RUNE", OneMinute, start, start.Add(time.Hour))
+	require.Equal(t, []Candle{
+		{"ETH", "RUNE", OneMinute, start, NewInt64Price("RUNE", 6), NewInt64Price("RUNE", 7),
+			NewInt64Price("RUNE", 6), NewInt64Price("RUNE", 7), sdk.NewInt64Coin("ETH", 200), 2},
+		{"ETH", "RUNE", OneMinute, start.Add(2 * time.Minute), NewInt64Price("RUNE", 4),
+			NewInt64Price("RUNE", 4), NewInt64Price("RUNE", 4), NewInt64Price("RUNE", 4),
+			sdk.NewInt64Coin("ETH", 30), 1},
+	}, candles)
+
+	// time range is applied to the start time of the candles, the candle containing `from` is included
+	candles = keeper.getCandles(ctx, "ETH", "RUNE", OneMinute, start.Add(30*time.Second), start.Add(time.Minute))
+	require.Len(t, candles, 1)
+	require.Equal(t, start, candles[0].StartTime)
+	candles = keeper.getCandles(ctx, "ETH", "RUNE", OneMinute, start.Add(time.Minute), start.Add(2*time.Minute))
+	require.Len(t, candles, 1)
+	require.Equal(t, start.Add(2*time.Minute), candles[0].StartTime)
+
+	// all trades are in the same five minute, hour and day candle
+	for _, interval := range []CandleInterval{FiveMinutes, OneHour, OneDay} {
+		candles = keeper.getCandles(ctx, "ETH", "RUNE", interval, start, start.Add(time.Hour))
+		require.Equal(t, []Candle{
+			{"ETH", "RUNE", interval, start.Truncate(interval.Duration()), NewInt64Price("RUNE", 6),
+				NewInt64Price("RUNE", 7), NewInt64Price("RUNE", 4), NewInt64Price("RUNE", 4),
+				sdk.NewInt64Coin("ETH", 230), 3},
+		}, candles)
+	}
+
+	// no candles for other pairs
+	require.Empty(t, keeper.getCandles(ctx, "RUNE", "ETH", OneMinute, start, start.Add(time.Hour)))
+}
+
+func TestParseCandleInterval(t *testing.T) {
+	for _, interval := range CandleIntervals {
+		parsed, err := ParseCandleInterval(string(interval))
+		require.Nil(t, err)
+		require.Equal(t, interval, parsed)
+		require.True(t, interval.Duration() > 0)
+	}
+
+	_, err := ParseCandleInterval("2m")
+	require.NotNil(t, err)
+}
+
+func TestGetCandlesInRange(t *testing.T) {
+	cdc := wire.NewCodec()
+	start := time.Date(2018, 10, 20, 10, 0, 0, 0, time.UTC)
+
+	entries := make([]sdk.KVPair, 0)
+	for i := 0; i < 3; i++ {
+		candle := NewCandle(OneHour, start.Add(time.Duration(i)*time.Hour), sdk.NewInt64Coin("ETH", 10),
+			NewInt64Price("RUNE", 5))
+		entries = append(entries, sdk.KVPair{
+			Key:   MakeKeyCandle("ETH", "RUNE", OneHour, candle.StartTime),
+			Value: cdc.MustMarshalBinary(candle),
+		})
+	}
+
+	candles := GetCandlesInRange(cdc, entries, "ETH", "RUNE", OneHour, start.Add(30*time.Minute), start.Add(time.Hour))
+	require.Len(t, candles, 2)
+	require.Equal(t, start, candles[0].StartTime)
+	require.Equal(t, start.Add(time.Hour), candles[1].StartTime)
+}
@@ -0,0 +1,77 @@
+package exchange
+
+import (
+	"fmt"
+	"strings"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// checkClientOrderID checks that a client order id is not too long and has not been used by the sender before. An
+// empty client order id is always valid
+func (k Keeper) checkClientOrderID(ctx sdk.Context, sender sdk.AccAddress, clientOrderID string) sdk.Error {
+	if clientOrderID == "" {
+		return nil
+	}
+
+	if len(clientOrderID) > MaxClientOrderIDLength {
+		return ErrInvalidClientOrderID(k.codespace)
+	}
+
+	store := ctx.KVStore(k.storeKey)
+	if store.Has(MakeKeyClientOrderID(sender, clientOrderID)) {
+		return ErrDuplicateClientOrderID(k.codespace, clientOrderID)
+	}
+
+	return nil
+}
+
+// setClientOrderID saves the id of the order a sender placed with a client order id. The entry is kept after the
+// order is closed, so that a client order id can never be used twice, e. g. when an order is sent again after its
+// broadcast timed out
+func (k Keeper) setClientOrderID(ctx sdk.Context, sender sdk.AccAddress, clientOrderID string, orderID int64) {
+	if clientOrderID == "" {
+		return
+	}
+
+	store := ctx.KVStore(k.storeKey)
+	store.Set(MakeKeyClientOrderID(sender, clientOrderID), k.cdc.MustMarshalBinary(orderID))
+}
+
+// getOrderIDByClientOrderID returns the id of the order a sender placed with a client order id
+func (k Keeper) getOrderIDByClientOrderID(ctx sdk.Context, sender sdk.AccAddress, clientOrderID string) (int64,
+	sdk.Error) {
+	store := ctx.KVStore(k.storeKey)
+	bz := store.Get(MakeKeyClientOrderID(sender, clientOrderID))
+	if bz == nil {
+		return 0, ErrClientOrderIDNotFound(k.codespace, clientOrderID)
+	}
+
+	var orderID int64
+	k.cdc.MustUnmarshalBinary(bz, &orderID)
+	return orderID, nil
+}
+
+// getAllClientOrderIDs returns the client order ids of all orders, including the closed ones, sorted by key
+func (k Keeper) getAllClientOrderIDs(ctx sdk.Context) []ClientOrderID {
+	clientOrderIDs := make([]ClientOrderID, 0)
+
+	store := ctx.KVStore(k.storeKey)
+	iter := sdk.KVStorePrefixIterator(store, clientOrderIDSubspace)
+	defer iter.Close()
+
+	for ; iter.Valid(); iter.Next() {
+		// the key is the bech32 sender, which has no colon, and the client order id, which may have one
+		parts := strings.SplitN(string(iter.Key()[len(clientOrderIDSubspace):]), ":", 2)
+		sender, err := sdk.AccAddressFromBech32(parts[0])
+		if err != nil || len(parts) != 2 {
+			panic(fmt.Sprintf("invalid client order id key %v", string(iter.Key())))
+		}
+
+		var orderID int64
+		k.cdc.MustUnmarshalBinary(iter.Value(), &orderID)
+		clientOrderIDs = append(clientOrderIDs, ClientOrderID{sender, parts[1], orderID})
+	}
+
+	return clientOrderIDs
+}
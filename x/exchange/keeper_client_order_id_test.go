@@ -0,0 +1,84 @@
+package exchange
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+)
+
+// Test if client order ids are unique per sender and echoed in fills and trades
+func TestKeeperClientOrderID(t *testing.T) {
+	ctx, keeper, _, buyer, seller, _, _, _, _ := setupCreateBuyLimitOrderTest()
+
+	expiresAt := time.Now().Add(time.Minute).UTC()
+
+	processed, _, err := keeper.processLimitOrder(ctx, seller, SellOrder, sdk.NewInt64Coin("ETH", 10),
+		NewInt64Price("RUNE", 5), OrderOptions{ExpiresAt: expiresAt, ClientOrderID: "bot-1"})
+	require.Nil(t, err)
+	require.Equal(t, "bot-1", processed.ClientOrderID)
+
+	orderID, err := keeper.getOrderIDByClientOrderID(ctx, seller, "bot-1")
+	require.Nil(t, err)
+	require.Equal(t, processed.OrderID, orderID)
+
+	// the same id of another sender is fine, the same id of the same sender is rejected
+	_, err = keeper.getOrderIDByClientOrderID(ctx, buyer, "bot-1")
+	require.Equal(t, CodeClientIDNotFound, err.Code())
+
+	_, _, err = keeper.processLimitOrder(ctx, seller, SellOrder, sdk.NewInt64Coin("ETH", 10), NewInt64Price("RUNE", 5),
+		OrderOptions{ExpiresAt: expiresAt, ClientOrderID: "bot-1"})
+	require.EqualError(t, err, ErrDuplicateClientOrderID(keeper.codespace, "bot-1").Error())
+
+	_, _, err = keeper.processLimitOrder(ctx, seller, SellOrder, sdk.NewInt64Coin("ETH", 10), NewInt64Price("RUNE", 5),
+		OrderOptions{ExpiresAt: expiresAt, ClientOrderID: strings.Repeat("x", MaxClientOrderIDLength+1)})
+	require.EqualError(t, err, ErrInvalidClientOrderID(keeper.codespace).Error())
+
+	taker, filled, err := keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 10),
+		NewInt64Price("RUNE", 5), OrderOptions{ExpiresAt: expiresAt, ClientOrderID: "bot-1"})
+	require.Nil(t, err)
+	require.Len(t, filled, 1)
+	require.Equal(t, processed.OrderID, filled[0].OrderID)
+	require.Equal(t, "bot-1", filled[0].ClientOrderID)
+
+	trades := keeper.getAllTrades(ctx)
+	require.Len(t, trades, 1)
+	require.Equal(t, taker.OrderID, trades[0].TakerOrderID)
+	require.Equal(t, "bot-1", trades[0].MakerClientOrderID)
+	require.Equal(t, "bot-1", trades[0].TakerClientOrderID)
+
+	// a filled order still blocks its client order id, so that sending it again does not place it twice
+	_, _, err = keeper.processLimitOrder(ctx, seller, SellOrder, sdk.NewInt64Coin("ETH", 10), NewInt64Price("RUNE", 5),
+		OrderOptions{ExpiresAt: expiresAt, ClientOrderID: "bot-1"})
+	require.Equal(t, CodeDuplicateClientID, err.Code())
+}
+
+// Test if orders can be cancelled by client order id
+func TestHandleCancelLimitOrderByClientOrderID(t *testing.T) {
+	ctx := setupContext(exchangeKey)
+	keeper, _, bankKeeper, buyer, seller := setupKeepers(exchangeKey, ctx)
+	bankKeeper.SetCoins(ctx, seller, sdk.Coins{sdk.NewInt64Coin("ETH", 100)})
+	handler := NewHandler(keeper)
+
+	res := handler(ctx, NewMsgCreateLimitOrder(seller, SellOrder, sdk.NewInt64Coin("ETH", 50),
+		NewInt64Price("RUNE", 5), time.Now().Add(time.Minute).UTC(), 0, GoodTillTime, MarketDefaultSTP, sdk.ZeroInt(),
+		"order-a", ""))
+	require.True(t, res.IsOK(), res.Log)
+	require.Equal(t, "50ETH", bankKeeper.GetCoins(ctx, seller).String())
+
+	msg := NewMsgCancelLimitOrderByClientOrderID(buyer, "order-a")
+	require.Nil(t, msg.ValidateBasic())
+	res = handler(ctx, msg)
+	require.Equal(t, ErrClientOrderIDNotFound(keeper.codespace, "order-a").Result().Code, res.Code)
+
+	msg = NewMsgCancelLimitOrderByClientOrderID(seller, "order-a")
+	msg.OrderID = 1
+	require.NotNil(t, msg.ValidateBasic())
+
+	res = handler(ctx, NewMsgCancelLimitOrderByClientOrderID(seller, "order-a"))
+	require.True(t, res.IsOK(), res.Log)
+	require.Equal(t, "100ETH", bankKeeper.GetCoins(ctx, seller).String())
+	require.Len(t, keeper.getOpenLimitOrdersBySender(ctx, seller), 0)
+}
@@ -0,0 +1,66 @@
+package exchange
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// lockCoins moves the coins locked for an order from the sender to the escrow account
+func (k Keeper) lockCoins(ctx sdk.Context, sender sdk.AccAddress, coin sdk.Coin) sdk.Error {
+	if !coin.IsPositive() {
+		return nil
+	}
+
+	_, err := k.bankKeeper.SendCoins(ctx, sender, EscrowAddress, sdk.Coins{coin})
+	return err
+}
+
+// releaseCoins moves locked coins from the escrow account to the given account, which is the owner of the order for
+// refunds or its counterparty for fills
+func (k Keeper) releaseCoins(ctx sdk.Context, addr sdk.AccAddress, coin sdk.Coin) sdk.Error {
+	if !coin.IsPositive() {
+		return nil
+	}
+
+	_, err := k.bankKeeper.SendCoins(ctx, EscrowAddress, addr, sdk.Coins{coin})
+	return err
+}
+
+// collectFee moves a fee from the given account to the fee pool of the chain, which is distributed like the fees of
+// transactions
+func (k Keeper) collectFee(ctx sdk.Context, addr sdk.AccAddress, fee sdk.Coin) sdk.Error {
+	if !fee.IsPositive() {
+		return nil
+	}
+
+	_, _, err := k.bankKeeper.SubtractCoins(ctx, addr, sdk.Coins{fee})
+	if err != nil {
+		return err
+	}
+
+	k.feeCollectionKeeper.AddCollectedFees(ctx, sdk.Coins{fee})
+	return nil
+}
+
+// getBatchOrders returns the orders collected for the batch auctions of the current block
+func (k Keeper) getBatchOrders(ctx sdk.Context) []LimitOrder {
+	orders := make([]LimitOrder, 0)
+
+	store := ctx.KVStore(k.storeKey)
+	iter := sdk.KVStorePrefixIterator(store, batchOrderSubspace)
+	defer iter.Close()
+
+	for ; iter.Valid(); iter.Next() {
+		var order LimitOrder
+		k.cdc.MustUnmarshalBinary(iter.Value(), &order)
+		orders = append(orders, order)
+	}
+
+	return orders
+}
+
+// getEscrowTotals returns the balance of the escrow account and the coins locked for open and collected orders,
+// trailing stops and listed markets
+func (k Keeper) getEscrowTotals(ctx sdk.Context) EscrowTotals {
+	return GetEscrowTotals(k.bankKeeper.GetCoins(ctx, EscrowAddress), k.getAllOrderBooks(ctx), k.getBatchOrders(ctx),
+		k.getAllTrailingStops(ctx), k.getAllMarkets(ctx))
+}
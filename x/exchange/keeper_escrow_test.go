@@ -0,0 +1,68 @@
+package exchange
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+func TestKeeperEscrow(t *testing.T) {
+	ctx := setupContext(exchangeKey)
+	keeper, _, bankKeeper, buyer, seller := setupKeepers(exchangeKey, ctx)
+	bankKeeper.SetCoins(ctx, buyer, sdk.Coins{sdk.NewInt64Coin("RUNE", 2000)})
+	bankKeeper.SetCoins(ctx, seller, sdk.Coins{sdk.NewInt64Coin("ETH", 250)})
+	expiresAt := time.Now().Add(time.Minute).UTC()
+
+	// the total supply of every denom stays the same, locked coins are held by the escrow account
+	requireSupply := func() {
+		supply := bankKeeper.GetCoins(ctx, buyer).Plus(bankKeeper.GetCoins(ctx, seller)).
+			Plus(bankKeeper.GetCoins(ctx, EscrowAddress)).Plus(keeper.feeCollectionKeeper.GetCollectedFees(ctx))
+		require.Equal(t, "250ETH,2000RUNE", supply.String())
+		require.True(t, keeper.getEscrowTotals(ctx).IsReconciled())
+	}
+
+	processed, _, err := keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 100),
+		NewInt64Price("RUNE", 5), OrderOptions{ExpiresAt: expiresAt})
+	require.Nil(t, err)
+	_, _, err = keeper.processLimitOrder(ctx, seller, SellOrder, sdk.NewInt64Coin("ETH", 50), NewInt64Price("RUNE", 9),
+		OrderOptions{ExpiresAt: expiresAt})
+	require.Nil(t, err)
+	require.Equal(t, EscrowTotals{sdk.Coins{sdk.NewInt64Coin("ETH", 50), sdk.NewInt64Coin("RUNE", 500)},
+		sdk.Coins{sdk.NewInt64Coin("ETH", 50), sdk.NewInt64Coin("RUNE", 500)}}, keeper.getEscrowTotals(ctx))
+	requireSupply()
+
+	// fills are settled from the escrow account
+	_, _, err = keeper.processLimitOrder(ctx, seller, SellOrder, sdk.NewInt64Coin("ETH", 60), NewInt64Price("RUNE", 5),
+		OrderOptions{ExpiresAt: expiresAt})
+	require.Nil(t, err)
+	require.Equal(t, "50ETH,200RUNE", bankKeeper.GetCoins(ctx, EscrowAddress).String())
+	requireSupply()
+
+	_, err = keeper.replaceLimitOrder(ctx, buyer, processed.OrderID, sdk.NewInt64Coin("ETH", 40),
+		NewInt64Price("RUNE", 4))
+	require.Nil(t, err)
+	require.Equal(t, "50ETH,160RUNE", bankKeeper.GetCoins(ctx, EscrowAddress).String())
+	requireSupply()
+
+	_, _, err = keeper.cancelLimitOrder(ctx, buyer, processed.OrderID)
+	require.Nil(t, err)
+	require.Equal(t, "50ETH", bankKeeper.GetCoins(ctx, EscrowAddress).String())
+	requireSupply()
+
+	// coins locked for orders collected for a batch auction are held by the escrow account, too
+	market := keeper.getMarket(ctx, "ETH", "RUNE")
+	market.Mode = BatchAuctionMode
+	keeper.setMarket(ctx, market)
+	_, _, err = keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 30), NewInt64Price("RUNE", 10),
+		OrderOptions{ExpiresAt: expiresAt, TimeInForce: ImmediateOrCancel})
+	require.Nil(t, err)
+	require.Equal(t, "50ETH,300RUNE", bankKeeper.GetCoins(ctx, EscrowAddress).String())
+	requireSupply()
+
+	keeper.clearBatchAuctions(ctx)
+	require.Equal(t, "20ETH", bankKeeper.GetCoins(ctx, EscrowAddress).String())
+	requireSupply()
+}
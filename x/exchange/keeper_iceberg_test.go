@@ -0,0 +1,110 @@
+package exchange
+
+import (
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+)
+
+// Test if an iceberg order shows slices of its display amount, locks its whole amount and refills at the back of the
+// time queue at its price
+func TestKeeperCreateIcebergOrder(t *testing.T) {
+	ctx, keeper, bankKeeper, buyer, seller, limitSellOrder1, limitSellOrder2, _, _ := setupCreateBuyLimitOrderTest()
+
+	expiresAt := time.Now().Add(time.Minute).UTC()
+
+	iceberg, _, err := keeper.processLimitOrder(ctx, seller, SellOrder, sdk.NewInt64Coin("ETH", 100),
+		NewInt64Price("RUNE", 6), OrderOptions{ExpiresAt: expiresAt, DisplayAmount: sdk.NewInt(30)})
+	require.Nil(t, err)
+	other, _, err := keeper.processLimitOrder(ctx, seller, SellOrder, sdk.NewInt64Coin("ETH", 50),
+		NewInt64Price("RUNE", 6), OrderOptions{ExpiresAt: expiresAt})
+	require.Nil(t, err)
+
+	// the whole amount is locked, only the display amount is shown
+	require.Equal(t, "100ETH", bankKeeper.GetCoins(ctx, seller).String())
+	sellOrderBook := keeper.getOrderBook(ctx, SellOrder, "ETH", "RUNE")
+	require.Len(t, sellOrderBook.Orders, 4)
+	require.Equal(t, iceberg.OrderID, sellOrderBook.Orders[1].OrderID)
+	require.Equal(t, sdk.NewInt64Coin("ETH", 30), sellOrderBook.Orders[1].Amount)
+	require.Equal(t, &Iceberg{sdk.NewInt(30), sdk.NewInt(70)}, sellOrderBook.Orders[1].Iceberg)
+
+	public := GetPublicOrderBook(sellOrderBook)
+	require.Nil(t, public.Orders[1].Iceberg)
+	require.NotNil(t, sellOrderBook.Orders[1].Iceberg)
+
+	depth := GetOrderBookDepth(keeper.getOrderBook(ctx, BuyOrder, "ETH", "RUNE"), sellOrderBook, 10)
+	require.Equal(t, sdk.NewInt64Coin("ETH", 200), depth.Asks[0].Amount)
+	require.Equal(t, 3, depth.Asks[0].OrderCount)
+
+	// filling the shown slice refills it behind the other order at the same price
+	_, filled, err := keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 150),
+		NewInt64Price("RUNE", 6), OrderOptions{ExpiresAt: expiresAt, TimeInForce: ImmediateOrCancel})
+	require.Nil(t, err)
+	require.Len(t, filled, 2)
+	require.Equal(t, limitSellOrder1.OrderID, filled[0].OrderID)
+	require.Equal(t, iceberg.OrderID, filled[1].OrderID)
+	require.Equal(t, sdk.NewInt64Coin("ETH", 30), filled[1].FilledAmount)
+
+	sellOrderBook = keeper.getOrderBook(ctx, SellOrder, "ETH", "RUNE")
+	require.Len(t, sellOrderBook.Orders, 3)
+	require.Equal(t, other.OrderID, sellOrderBook.Orders[0].OrderID)
+	require.Equal(t, iceberg.OrderID, sellOrderBook.Orders[1].OrderID)
+	require.Equal(t, sdk.NewInt64Coin("ETH", 30), sellOrderBook.Orders[1].Amount)
+	require.Equal(t, &Iceberg{sdk.NewInt(30), sdk.NewInt(40)}, sellOrderBook.Orders[1].Iceberg)
+	require.Equal(t, limitSellOrder2, sellOrderBook.Orders[2])
+
+	// a fill larger than the shown slice continues with the refilled slice at the same price
+	_, filled, err = keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 110), NewInt64Price("RUNE", 6),
+		OrderOptions{ExpiresAt: expiresAt, TimeInForce: ImmediateOrCancel})
+	require.Nil(t, err)
+	require.Len(t, filled, 3)
+	require.Equal(t, other.OrderID, filled[0].OrderID)
+	require.Equal(t, iceberg.OrderID, filled[1].OrderID)
+	require.Equal(t, sdk.NewInt64Coin("ETH", 30), filled[1].FilledAmount)
+	require.Equal(t, iceberg.OrderID, filled[2].OrderID)
+	require.Equal(t, sdk.NewInt64Coin("ETH", 30), filled[2].FilledAmount)
+	require.Len(t, keeper.getAllTrades(ctx), 5)
+
+	sellOrderBook = keeper.getOrderBook(ctx, SellOrder, "ETH", "RUNE")
+	require.Len(t, sellOrderBook.Orders, 2)
+	require.Equal(t, sdk.NewInt64Coin("ETH", 10), sellOrderBook.Orders[0].Amount)
+	require.Equal(t, &Iceberg{sdk.NewInt(30), sdk.ZeroInt()}, sellOrderBook.Orders[0].Iceberg)
+
+	require.Equal(t, "260ETH,440RUNE", bankKeeper.GetCoins(ctx, buyer).String())
+	require.Equal(t, "100ETH,1560RUNE", bankKeeper.GetCoins(ctx, seller).String())
+
+	// cancelling refunds the rest of the order
+	_, _, err = keeper.cancelLimitOrder(ctx, seller, iceberg.OrderID)
+	require.Nil(t, err)
+	require.Equal(t, "110ETH,1560RUNE", bankKeeper.GetCoins(ctx, seller).String())
+}
+
+// Test if the hidden reserve counts for fill-or-kill orders and the display amount is validated
+func TestKeeperCreateIcebergOrderHiddenReserve(t *testing.T) {
+	ctx, keeper, _, buyer, seller, _, _, _, _ := setupCreateBuyLimitOrderTest()
+
+	expiresAt := time.Now().Add(time.Minute).UTC()
+
+	_, _, err := keeper.processLimitOrder(ctx, seller, SellOrder, sdk.NewInt64Coin("ETH", 100), NewInt64Price("RUNE", 5),
+		OrderOptions{ExpiresAt: expiresAt, DisplayAmount: sdk.NewInt(-1)})
+	require.EqualError(t, err, ErrInvalidDisplayAmount(keeper.codespace).Error())
+
+	iceberg, _, err := keeper.processLimitOrder(ctx, seller, SellOrder, sdk.NewInt64Coin("ETH", 100),
+		NewInt64Price("RUNE", 5), OrderOptions{ExpiresAt: expiresAt, DisplayAmount: sdk.NewInt(10)})
+	require.Nil(t, err)
+
+	processed, filled, err := keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 100),
+		NewInt64Price("RUNE", 5), OrderOptions{ExpiresAt: expiresAt, TimeInForce: FillOrKill})
+	require.Nil(t, err)
+	require.True(t, processed.OpenAmount.IsZero())
+	require.Len(t, filled, 10)
+	for _, fill := range filled {
+		require.Equal(t, iceberg.OrderID, fill.OrderID)
+		require.Equal(t, sdk.NewInt64Coin("ETH", 10), fill.FilledAmount)
+	}
+
+	_, _, err = keeper.findLimitOrder(ctx, iceberg.OrderID)
+	require.EqualError(t, err, ErrOrderNotFound(keeper.codespace, iceberg.OrderID).Error())
+}
@@ -1,6 +1,70 @@
 package exchange
 
+import (
+	"fmt"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
 // Key for getting the next available orderID from the store
 var (
 	KeyNextOrderID = []byte("nextOrderID")
 )
+
+// Key for getting the key of the order book an open order is stored in
+func MakeKeyOrderLocation(orderID int64) []byte {
+	return []byte(fmt.Sprintf("orderLocation:%v", orderID))
+}
+
+// Prefix of the keys of all order book locations of the open orders of a sender, sorted by order id
+func MakeKeyOrdersBySenderSubspace(sender sdk.AccAddress) []byte {
+	return []byte(fmt.Sprintf("orderBySender:%v:", sender.String()))
+}
+
+// Key for getting the key of the order book an open order of a sender is stored in
+func MakeKeyOrderBySender(sender sdk.AccAddress, orderID int64) []byte {
+	return []byte(fmt.Sprintf("orderBySender:%v:%020d", sender.String(), orderID))
+}
+
+var clientOrderIDSubspace = []byte("clientOrderID:")
+
+// Key for getting the id of the order a sender placed with a client order id
+func MakeKeyClientOrderID(sender sdk.AccAddress, clientOrderID string) []byte {
+	return []byte(fmt.Sprintf("clientOrderID:%v:%v", sender.String(), clientOrderID))
+}
+
+// Key for getting the number of open orders of a sender
+func MakeKeyOpenOrderCount(sender sdk.AccAddress) []byte {
+	return []byte(fmt.Sprintf("openOrderCount:%v", sender.String()))
+}
+
+// Key for getting the number of open orders of a sender in the market of the given amount and price denoms
+func MakeKeyOpenOrderCountByMarket(sender sdk.AccAddress, amountDenom string, priceDenom string) []byte {
+	return []byte(fmt.Sprintf("openOrderCountByMarket:%v:%v:%v", sender.String(), amountDenom, priceDenom))
+}
+
+// Prefix of the keys of the open orders of the one-cancels-other group of a sender, sorted by order id
+func MakeKeyOCOGroupSubspace(sender sdk.AccAddress, ocoGroup string) []byte {
+	return []byte(fmt.Sprintf("ocoGroup:%v:%v:", sender.String(), ocoGroup))
+}
+
+// Key for the entry of an open order in its one-cancels-other group. The value is the order id
+func MakeKeyOCOGroupOrder(sender sdk.AccAddress, ocoGroup string, orderID int64) []byte {
+	return []byte(fmt.Sprintf("ocoGroup:%v:%v:%020d", sender.String(), ocoGroup, orderID))
+}
+
+var orderExpirySubspace = []byte("orderExpiry:")
+
+// Key for the expiry index entry of an open order, sorted by expiry time, then order id. The value is the order id
+func MakeKeyOrderExpiry(expiresAt time.Time, orderID int64) []byte {
+	return []byte(fmt.Sprintf("orderExpiry:%020d:%020d", expiresAt.UnixNano(), orderID))
+}
+
+var orderHeightExpirySubspace = []byte("orderHeightExpiry:")
+
+// Key for the expiry index entry of an open order that expires at a block height, sorted by height, then order id.
+// The value is the order id
+func MakeKeyOrderHeightExpiry(expiresAtHeight int64, orderID int64) []byte {
+	return []byte(fmt.Sprintf("orderHeightExpiry:%020d:%020d", expiresAtHeight, orderID))
+}
@@ -0,0 +1,350 @@
+package exchange
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/x/gov"
+)
+
+// getMarket returns the market of the given token pair. If no market is stored for this pair, a continuous market
+// is returned
+func (k Keeper) getMarket(ctx sdk.Context, amountDenom string, priceDenom string) Market {
+	store := ctx.KVStore(k.storeKey)
+	bz := store.Get(MakeKeyMarket(amountDenom, priceDenom))
+	if bz == nil {
+		return NewMarket(amountDenom, priceDenom, ContinuousMode)
+	}
+
+	var market Market
+	k.cdc.MustUnmarshalBinary(bz, &market)
+	return market
+}
+
+// setMarket saves the market to the store
+func (k Keeper) setMarket(ctx sdk.Context, market Market) {
+	store := ctx.KVStore(k.storeKey)
+	store.Set(MakeKeyMarket(market.AmountDenom, market.PriceDenom), k.cdc.MustMarshalBinary(market))
+}
+
+// getAllMarkets returns all stored markets, sorted by key
+func (k Keeper) getAllMarkets(ctx sdk.Context) []Market {
+	markets := make([]Market, 0)
+
+	store := ctx.KVStore(k.storeKey)
+	iter := sdk.KVStorePrefixIterator(store, marketSubspace)
+	defer iter.Close()
+
+	for ; iter.Valid(); iter.Next() {
+		var market Market
+		k.cdc.MustUnmarshalBinary(iter.Value(), &market)
+		markets = append(markets, market)
+	}
+
+	return markets
+}
+
+// setMarketMode switches a market between continuous and batch auction mode. Orders that are already collected for
+// the batch auction of the current block are still cleared at the end of the block
+func (k Keeper) setMarketMode(ctx sdk.Context, sender sdk.AccAddress, amountDenom string, priceDenom string,
+	mode MarketMode) (Market, sdk.Error) {
+	if !k.isAuthority(ctx, sender) {
+		return Market{}, ErrUnauthorized(k.codespace)
+	}
+
+	if !isValidMarketMode(mode) {
+		return Market{}, ErrInvalidMarketMode(k.codespace)
+	}
+
+	market := k.getMarket(ctx, amountDenom, priceDenom)
+	if !market.IsListed() {
+		return Market{}, ErrMarketNotListed(k.codespace, amountDenom, priceDenom)
+	}
+
+	market.Mode = mode
+	k.setMarket(ctx, market)
+
+	return market, nil
+}
+
+// setMarketConfig changes the tick size, lot size, minimum notional, default self-trade prevention mode and price band
+// of a market. Open orders are not affected
+func (k Keeper) setMarketConfig(ctx sdk.Context, sender sdk.AccAddress, amountDenom string, priceDenom string,
+	tickSize sdk.Rat, lotSize sdk.Int, minNotional sdk.Int, stp SelfTradePrevention, priceBand int64) (Market,
+	sdk.Error) {
+	if !k.isAuthority(ctx, sender) {
+		return Market{}, ErrUnauthorized(k.codespace)
+	}
+
+	market := k.getMarket(ctx, amountDenom, priceDenom)
+	if !market.IsListed() {
+		return Market{}, ErrMarketNotListed(k.codespace, amountDenom, priceDenom)
+	}
+
+	market.TickSize = tickSize
+	market.LotSize = lotSize
+	market.MinNotional = minNotional
+	market.SelfTradePrevention = stp
+	market.PriceBand = priceBand
+
+	err := validateMarket(market)
+	if err != nil {
+		return Market{}, ErrInvalidMarket(k.codespace, err.Error())
+	}
+
+	k.setMarket(ctx, market)
+
+	return market, nil
+}
+
+// setMarketHalt halts or resumes a market. The authority can do so at any time, anyone else needs a passed text
+// proposal with the halt proposal description of the market halt, which can only be used once. Open orders stay in
+// the order books of a halted market and can be cancelled
+func (k Keeper) setMarketHalt(ctx sdk.Context, sender sdk.AccAddress, amountDenom string, priceDenom string,
+	halted bool, proposalID int64) (Market, sdk.Error) {
+	if proposalID > 0 {
+		err := k.checkHaltProposal(ctx, NewMarketHalt(amountDenom, priceDenom, halted), proposalID)
+		if err != nil {
+			return Market{}, err
+		}
+	} else if !k.isAuthority(ctx, sender) {
+		return Market{}, ErrUnauthorized(k.codespace)
+	}
+
+	market := k.getMarket(ctx, amountDenom, priceDenom)
+	if !market.IsListed() {
+		return Market{}, ErrMarketNotListed(k.codespace, amountDenom, priceDenom)
+	}
+
+	market.Halted = halted
+	k.setMarket(ctx, market)
+	if proposalID > 0 {
+		k.setHaltProposalUsed(ctx, proposalID)
+	}
+
+	return market, nil
+}
+
+// checkHaltProposal checks that the proposal is a passed text proposal that halts or resumes the market and has not
+// been used before
+func (k Keeper) checkHaltProposal(ctx sdk.Context, halt MarketHalt, proposalID int64) sdk.Error {
+	if k.isHaltProposalUsed(ctx, proposalID) {
+		return ErrInvalidHalt(k.codespace, fmt.Sprintf("proposal %v has already been used", proposalID))
+	}
+
+	proposal := k.govKeeper.GetProposal(ctx, proposalID)
+	if proposal == nil {
+		return ErrInvalidHalt(k.codespace, fmt.Sprintf("proposal %v not found", proposalID))
+	}
+
+	if proposal.GetProposalType() != gov.ProposalTypeText || proposal.GetStatus() != gov.StatusPassed {
+		return ErrInvalidHalt(k.codespace, fmt.Sprintf("proposal %v must be a passed text proposal", proposalID))
+	}
+
+	if proposal.GetDescription() != GetHaltProposalDescription(halt) {
+		return ErrInvalidHalt(k.codespace, fmt.Sprintf("proposal %v does not halt or resume this market",
+			proposalID))
+	}
+
+	return nil
+}
+
+// isHaltProposalUsed checks if the proposal has already halted or resumed its market
+func (k Keeper) isHaltProposalUsed(ctx sdk.Context, proposalID int64) bool {
+	store := ctx.KVStore(k.storeKey)
+	return store.Has(MakeKeyUsedHaltProposal(proposalID))
+}
+
+func (k Keeper) setHaltProposalUsed(ctx sdk.Context, proposalID int64) {
+	store := ctx.KVStore(k.storeKey)
+	store.Set(MakeKeyUsedHaltProposal(proposalID), []byte{})
+}
+
+// getUsedHaltProposalIDs returns the ids of all used halt proposals, sorted by id
+func (k Keeper) getUsedHaltProposalIDs(ctx sdk.Context) []int64 {
+	return k.getUsedProposalIDs(ctx, usedHaltProposalSubspace)
+}
+
+// getUsedProposalIDs returns the ids of all proposals marked as used in the subspace, sorted by id
+func (k Keeper) getUsedProposalIDs(ctx sdk.Context, subspace []byte) []int64 {
+	proposalIDs := make([]int64, 0)
+
+	store := ctx.KVStore(k.storeKey)
+	iter := sdk.KVStorePrefixIterator(store, subspace)
+	defer iter.Close()
+
+	for ; iter.Valid(); iter.Next() {
+		proposalID, err := strconv.ParseInt(string(iter.Key()[len(subspace):]), 10, 64)
+		if err != nil {
+			panic(err)
+		}
+		proposalIDs = append(proposalIDs, proposalID)
+	}
+
+	return proposalIDs
+}
+
+// checkMarketRules checks that the market is listed and not halted, the price is a multiple of the tick size and
+// within the price band around the last trade price, the amount a multiple of the lot size and the total price at
+// least the minimum notional of the market
+func (k Keeper) checkMarketRules(ctx sdk.Context, amount sdk.Coin, price Price) sdk.Error {
+	market := k.getMarket(ctx, amount.Denom, price.Denom)
+
+	if !market.IsListed() {
+		return ErrMarketNotListed(k.codespace, amount.Denom, price.Denom)
+	}
+
+	if market.Halted {
+		return ErrMarketHalted(k.codespace, amount.Denom, price.Denom)
+	}
+
+	if !price.IsMultipleOf(market.TickSize) {
+		return ErrInvalidTickSize(k.codespace, market.TickSize)
+	}
+
+	// markets without trades have no reference price yet
+	if lastTrade, ok := k.getLastTrade(ctx, amount.Denom, price.Denom); ok &&
+		!market.isInPriceBand(price, lastTrade.Price) {
+		return ErrOutsidePriceBand(k.codespace, lastTrade.Price, market.PriceBand)
+	}
+
+	if !amount.Amount.Mod(market.LotSize).IsZero() {
+		return ErrInvalidLotSize(k.codespace, market.LotSize)
+	}
+
+	minNotional := sdk.Coin{price.Denom, market.MinNotional}
+	if !getTotalPrice(amount, price).IsGTE(minNotional) {
+		return ErrBelowMinNotional(k.codespace, minNotional)
+	}
+
+	return nil
+}
+
+// listMarket lists a market. With a proposal id, the proposal must be a passed text proposal with the listing proposal
+// description of the listing, which can only be used once. Without, the sender pays the listing deposit, which is
+// locked in the escrow account until the market is delisted, and cannot override fee rates
+func (k Keeper) listMarket(ctx sdk.Context, sender sdk.AccAddress, listing MarketListing, proposalID int64) (Market,
+	sdk.Error) {
+	market := k.getMarket(ctx, listing.AmountDenom, listing.PriceDenom)
+	if market.IsListed() {
+		return Market{}, ErrInvalidListing(k.codespace, fmt.Sprintf("market %v/%v is already listed",
+			listing.AmountDenom, listing.PriceDenom))
+	}
+
+	// the mode of a previously configured market is kept
+	mode := market.Mode
+	market = listing.toMarket()
+	market.Mode = mode
+
+	err := validateMarket(market)
+	if err != nil {
+		return Market{}, ErrInvalidMarket(k.codespace, err.Error())
+	}
+
+	if proposalID > 0 {
+		err2 := k.checkListingProposal(ctx, listing, proposalID)
+		if err2 != nil {
+			return Market{}, err2
+		}
+	} else {
+		if listing.HasFeeOverride() {
+			return Market{}, ErrInvalidListing(k.codespace, "fee rates can only be overridden by a governance proposal")
+		}
+
+		deposit := k.ListingDeposit(ctx)
+		if !deposit.IsPositive() {
+			return Market{}, ErrInvalidListing(k.codespace, "listing by deposit is disabled")
+		}
+
+		err2 := k.lockCoins(ctx, sender, deposit)
+		if err2 != nil {
+			return Market{}, err2
+		}
+
+		market.Lister = sender
+		market.Deposit = sdk.Coins{deposit}
+	}
+
+	k.setMarket(ctx, market)
+	if proposalID > 0 {
+		k.setListingProposalUsed(ctx, proposalID)
+	}
+
+	return market, nil
+}
+
+// delistMarket unlists a market, which then rejects new orders. The lister of a market listed by deposit or the
+// authority may delist it, the deposit is refunded to the lister. Open orders stay in the order books until they are
+// cancelled or expire
+func (k Keeper) delistMarket(ctx sdk.Context, sender sdk.AccAddress, amountDenom string, priceDenom string) (Market,
+	sdk.Error) {
+	market := k.getMarket(ctx, amountDenom, priceDenom)
+	if !market.IsListed() {
+		return Market{}, ErrMarketNotListed(k.codespace, amountDenom, priceDenom)
+	}
+
+	isLister := len(market.Lister) > 0 && bytes.Equal(market.Lister, sender)
+	if !isLister && !k.isAuthority(ctx, sender) {
+		return Market{}, ErrUnauthorized(k.codespace)
+	}
+
+	for _, deposit := range market.Deposit {
+		err := k.releaseCoins(ctx, market.Lister, deposit)
+		if err != nil {
+			return Market{}, err
+		}
+	}
+
+	market.Status = UnlistedStatus
+	market.Lister = nil
+	market.Deposit = nil
+	k.setMarket(ctx, market)
+
+	return market, nil
+}
+
+// checkListingProposal checks that the proposal is a passed text proposal that lists the market and has not been used
+// before
+func (k Keeper) checkListingProposal(ctx sdk.Context, listing MarketListing, proposalID int64) sdk.Error {
+	if k.isListingProposalUsed(ctx, proposalID) {
+		return ErrInvalidListing(k.codespace, fmt.Sprintf("proposal %v has already been used", proposalID))
+	}
+
+	proposal := k.govKeeper.GetProposal(ctx, proposalID)
+	if proposal == nil {
+		return ErrInvalidListing(k.codespace, fmt.Sprintf("proposal %v not found", proposalID))
+	}
+
+	if proposal.GetProposalType() != gov.ProposalTypeText || proposal.GetStatus() != gov.StatusPassed {
+		return ErrInvalidListing(k.codespace, fmt.Sprintf("proposal %v must be a passed text proposal", proposalID))
+	}
+
+	if proposal.GetDescription() != GetListingProposalDescription(listing) {
+		return ErrInvalidListing(k.codespace, fmt.Sprintf("proposal %v does not list this market", proposalID))
+	}
+
+	return nil
+}
+
+// isListingProposalUsed checks if the proposal has already listed its market
+func (k Keeper) isListingProposalUsed(ctx sdk.Context, proposalID int64) bool {
+	store := ctx.KVStore(k.storeKey)
+	return store.Has(MakeKeyUsedListingProposal(proposalID))
+}
+
+func (k Keeper) setListingProposalUsed(ctx sdk.Context, proposalID int64) {
+	store := ctx.KVStore(k.storeKey)
+	store.Set(MakeKeyUsedListingProposal(proposalID), []byte{})
+}
+
+// getUsedListingProposalIDs returns the ids of all used listing proposals, sorted by id
+func (k Keeper) getUsedListingProposalIDs(ctx sdk.Context) []int64 {
+	return k.getUsedProposalIDs(ctx, usedListingProposalSubspace)
+}
+
+// getListedMarkets returns all listed markets, sorted by key
+func (k Keeper) getListedMarkets(ctx sdk.Context) []Market {
+	return GetListedMarkets(k.getAllMarkets(ctx))
+}
@@ -0,0 +1,142 @@
+package exchange
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/x/gov"
+)
+
+// Test if only the authority or a passed proposal halt and resume a market, and if a halted market rejects new
+// orders, replacements and trailing stops, but still accepts cancels
+func TestKeeperMarketHalt(t *testing.T) {
+	ctx := setupContext(exchangeKey)
+	keeper, _, bankKeeper, authority, trader := setupKeepers(exchangeKey, ctx)
+	keeper.setParams(ctx, Params{Authority: authority.String()})
+	gov.InitGenesis(ctx, keeper.govKeeper, gov.DefaultGenesisState())
+	bankKeeper.SetCoins(ctx, trader, sdk.Coins{sdk.NewInt64Coin("RUNE", 100)})
+	expiresAt := time.Now().Add(time.Minute).UTC()
+
+	processed, _, err := keeper.processLimitOrder(ctx, trader, BuyOrder, sdk.NewInt64Coin("ETH", 10),
+		NewInt64Price("RUNE", 5), OrderOptions{ExpiresAt: expiresAt})
+	require.Nil(t, err)
+
+	_, err = keeper.setMarketHalt(ctx, trader, "ETH", "RUNE", true, 0)
+	require.Equal(t, CodeUnauthorized, err.Code())
+	_, err = keeper.setMarketHalt(ctx, authority, "LTC", "RUNE", true, 0)
+	require.Equal(t, CodeMarketNotListed, err.Code())
+
+	market, err := keeper.setMarketHalt(ctx, authority, "ETH", "RUNE", true, 0)
+	require.Nil(t, err)
+	require.True(t, market.Halted)
+
+	_, _, err = keeper.processLimitOrder(ctx, trader, BuyOrder, sdk.NewInt64Coin("ETH", 10), NewInt64Price("RUNE", 4),
+		OrderOptions{ExpiresAt: expiresAt})
+	require.Equal(t, CodeMarketHalted, err.Code())
+	_, err = keeper.replaceLimitOrder(ctx, trader, processed.OrderID, sdk.NewInt64Coin("ETH", 5),
+		NewInt64Price("RUNE", 5))
+	require.Equal(t, CodeMarketHalted, err.Code())
+	_, err = keeper.createTrailingStop(ctx, trader, SellOrder, sdk.NewInt64Coin("ETH", 10), "RUNE", sdk.OneRat(), 0,
+		false, sdk.ZeroRat())
+	require.Equal(t, CodeMarketHalted, err.Code())
+
+	// other markets are not affected
+	_, _, err = keeper.processLimitOrder(ctx, trader, BuyOrder, sdk.NewInt64Coin("BTC", 1), NewInt64Price("RUNE", 10),
+		OrderOptions{ExpiresAt: expiresAt})
+	require.Nil(t, err)
+
+	_, _, err = keeper.cancelLimitOrder(ctx, trader, processed.OrderID)
+	require.Nil(t, err)
+	require.Equal(t, "90RUNE", bankKeeper.GetCoins(ctx, trader).String())
+
+	// resuming by proposal needs a passed proposal that resumes this market
+	proposal := keeper.govKeeper.NewTextProposal(ctx, "Resume ETH/RUNE",
+		GetHaltProposalDescription(NewMarketHalt("ETH", "RUNE", false)), gov.ProposalTypeText)
+	_, err = keeper.setMarketHalt(ctx, trader, "ETH", "RUNE", false, proposal.GetProposalID())
+	require.Equal(t, CodeInvalidHalt, err.Code())
+	proposal.SetStatus(gov.StatusPassed)
+	keeper.govKeeper.SetProposal(ctx, proposal)
+	_, err = keeper.setMarketHalt(ctx, trader, "ETH", "RUNE", true, proposal.GetProposalID())
+	require.Equal(t, CodeInvalidHalt, err.Code())
+
+	market, err = keeper.setMarketHalt(ctx, trader, "ETH", "RUNE", false, proposal.GetProposalID())
+	require.Nil(t, err)
+	require.False(t, market.Halted)
+	_, _, err = keeper.processLimitOrder(ctx, trader, BuyOrder, sdk.NewInt64Coin("ETH", 10), NewInt64Price("RUNE", 4),
+		OrderOptions{ExpiresAt: expiresAt})
+	require.Nil(t, err)
+
+	// a used proposal cannot resume the market again once the authority has halted it
+	_, err = keeper.setMarketHalt(ctx, authority, "ETH", "RUNE", true, 0)
+	require.Nil(t, err)
+	_, err = keeper.setMarketHalt(ctx, trader, "ETH", "RUNE", false, proposal.GetProposalID())
+	require.Equal(t, CodeInvalidHalt, err.Code())
+	require.True(t, keeper.getMarket(ctx, "ETH", "RUNE").Halted)
+
+	// used proposals are exported
+	require.Equal(t, []int64{proposal.GetProposalID()}, WriteGenesis(ctx, keeper).UsedHaltProposalIDs)
+}
+
+// Test if the batch auction of a market that is halted after orders have been collected does not match them
+func TestKeeperMarketHaltBatchAuction(t *testing.T) {
+	ctx := setupContext(exchangeKey)
+	keeper, _, bankKeeper, buyer, seller := setupKeepers(exchangeKey, ctx)
+	bankKeeper.SetCoins(ctx, buyer, sdk.Coins{sdk.NewInt64Coin("RUNE", 100)})
+	bankKeeper.SetCoins(ctx, seller, sdk.Coins{sdk.NewInt64Coin("ETH", 100)})
+	market := newListedMarket("ETH", "RUNE")
+	market.Mode = BatchAuctionMode
+	keeper.setMarket(ctx, market)
+
+	_, _, err := keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 10), NewInt64Price("RUNE", 5),
+		OrderOptions{TimeInForce: GoodTillCancelled})
+	require.Nil(t, err)
+	_, _, err = keeper.processLimitOrder(ctx, seller, SellOrder, sdk.NewInt64Coin("ETH", 10), NewInt64Price("RUNE", 5),
+		OrderOptions{ExpiresAt: time.Now().Add(time.Minute).UTC(), TimeInForce: ImmediateOrCancel})
+	require.Nil(t, err)
+
+	market.Halted = true
+	keeper.setMarket(ctx, market)
+
+	results := keeper.clearBatchAuctions(ctx)
+	require.Len(t, results, 1)
+	require.Len(t, results[0].Trades, 0)
+	require.True(t, results[0].Volume.IsZero())
+
+	// the good-till-cancelled order rests in the order book, the immediate-or-cancel order is refunded
+	require.Len(t, keeper.getOrderBook(ctx, BuyOrder, "ETH", "RUNE").Orders, 1)
+	require.Equal(t, "100ETH", bankKeeper.GetCoins(ctx, seller).String())
+	require.True(t, keeper.getEscrowTotals(ctx).IsReconciled())
+}
+
+// Test if orders priced outside of the price band around the last trade price are rejected
+func TestKeeperPriceBand(t *testing.T) {
+	ctx := setupContext(exchangeKey)
+	keeper, _, bankKeeper, buyer, seller := setupKeepers(exchangeKey, ctx)
+	bankKeeper.SetCoins(ctx, buyer, sdk.Coins{sdk.NewInt64Coin("RUNE", 1000)})
+	bankKeeper.SetCoins(ctx, seller, sdk.Coins{sdk.NewInt64Coin("ETH", 100)})
+	market := newListedMarket("ETH", "RUNE")
+	market.PriceBand = 1000
+	keeper.setMarket(ctx, market)
+	expiresAt := time.Now().Add(time.Minute).UTC()
+
+	order := func(sender sdk.AccAddress, kind OrderKind, price int64) sdk.Error {
+		_, _, err := keeper.processLimitOrder(ctx, sender, kind, sdk.NewInt64Coin("ETH", 10), NewInt64Price("RUNE", price),
+			OrderOptions{ExpiresAt: expiresAt})
+		return err
+	}
+
+	// without a last trade, there is no reference price
+	require.Nil(t, order(seller, SellOrder, 20))
+	require.Nil(t, order(buyer, BuyOrder, 20))
+
+	require.Equal(t, CodeOutsidePriceBand, order(seller, SellOrder, 23).Code())
+	require.Equal(t, CodeOutsidePriceBand, order(buyer, BuyOrder, 17).Code())
+	require.Nil(t, order(seller, SellOrder, 22))
+	require.Nil(t, order(buyer, BuyOrder, 18))
+
+	market.PriceBand = priceBandDenominator
+	require.NotNil(t, validateMarket(market))
+}
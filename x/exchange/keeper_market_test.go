@@ -0,0 +1,186 @@
+package exchange
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/x/gov"
+)
+
+func TestKeeperSetMarketConfig(t *testing.T) {
+	ctx := setupContext(exchangeKey)
+	keeper, _, _, authority, other := setupKeepers(exchangeKey, ctx)
+	keeper.setParams(ctx, Params{Authority: authority.String()})
+
+	_, err := keeper.setMarketConfig(ctx, other, "ETH", "RUNE", sdk.NewRat(1, 100), sdk.NewInt(100), sdk.NewInt(10),
+		CancelNewest, 0)
+	require.Equal(t, CodeUnauthorized, err.Code())
+
+	// lot size times tick size must be a whole number
+	_, err = keeper.setMarketConfig(ctx, authority, "ETH", "RUNE", sdk.NewRat(1, 100), sdk.NewInt(10), sdk.NewInt(10),
+		CancelNewest, 0)
+	require.Equal(t, CodeInvalidMarket, err.Code())
+	_, err = keeper.setMarketConfig(ctx, authority, "ETH", "RUNE", sdk.ZeroRat(), sdk.NewInt(10), sdk.NewInt(10),
+		CancelNewest, 0)
+	require.Equal(t, CodeInvalidMarket, err.Code())
+
+	market, err := keeper.setMarketConfig(ctx, authority, "ETH", "RUNE", sdk.NewRat(1, 100), sdk.NewInt(100),
+		sdk.NewInt(10), CancelNewest, 0)
+	require.Nil(t, err)
+	require.Equal(t, "1/100", market.TickSize.String())
+
+	// switching the mode keeps the configuration
+	_, err = keeper.setMarketMode(ctx, authority, "ETH", "RUNE", BatchAuctionMode)
+	require.Nil(t, err)
+	market = keeper.getMarket(ctx, "ETH", "RUNE")
+	require.Equal(t, BatchAuctionMode, market.Mode)
+	require.Equal(t, "1/100", market.TickSize.String())
+	require.Equal(t, "100", market.LotSize.String())
+	require.Equal(t, "10", market.MinNotional.String())
+}
+
+func TestKeeperMarketRules(t *testing.T) {
+	ctx := setupContext(exchangeKey)
+	keeper, _, bankKeeper, buyer, seller := setupKeepers(exchangeKey, ctx)
+	bankKeeper.SetCoins(ctx, buyer, sdk.Coins{sdk.NewInt64Coin("RUNE", 2000)})
+	bankKeeper.SetCoins(ctx, seller, sdk.Coins{sdk.NewInt64Coin("ETH", 250)})
+	market := newListedMarket("ETH", "RUNE")
+	market.TickSize, market.LotSize, market.MinNotional = sdk.NewRat(1, 100), sdk.NewInt(100), sdk.NewInt(5)
+	keeper.setMarket(ctx, market)
+	expiresAt := time.Now().Add(time.Minute).UTC()
+
+	_, _, err := keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 100),
+		NewPrice("RUNE", sdk.NewRat(1, 1000)), OrderOptions{ExpiresAt: expiresAt})
+	require.Equal(t, CodeInvalidTickSize, err.Code())
+
+	_, _, err = keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 150),
+		NewPrice("RUNE", sdk.NewRat(1, 100)), OrderOptions{ExpiresAt: expiresAt})
+	require.Equal(t, CodeInvalidLotSize, err.Code())
+
+	_, _, err = keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 200),
+		NewPrice("RUNE", sdk.NewRat(1, 100)), OrderOptions{ExpiresAt: expiresAt})
+	require.Equal(t, CodeBelowMinNotional, err.Code())
+
+	// prices below one unit of the price denom
+	_, _, err = keeper.processLimitOrder(ctx, seller, SellOrder, sdk.NewInt64Coin("ETH", 100),
+		NewPrice("RUNE", sdk.NewRat(3, 4)), OrderOptions{ExpiresAt: expiresAt})
+	require.Nil(t, err)
+	_, filled, err := keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 100),
+		NewPrice("RUNE", sdk.NewRat(4, 5)), OrderOptions{ExpiresAt: expiresAt, TimeInForce: ImmediateOrCancel})
+	require.Nil(t, err)
+	require.Len(t, filled, 1)
+	require.Equal(t, "0.75RUNE", filled[0].FilledPrice.String())
+
+	require.Equal(t, "100ETH,1925RUNE", bankKeeper.GetCoins(ctx, buyer).String())
+	require.Equal(t, "150ETH,75RUNE", bankKeeper.GetCoins(ctx, seller).String())
+}
+
+func TestKeeperListMarketByDeposit(t *testing.T) {
+	ctx := setupContext(exchangeKey)
+	keeper, _, bankKeeper, lister, other := setupKeepers(exchangeKey, ctx)
+	bankKeeper.SetCoins(ctx, lister, sdk.Coins{sdk.NewInt64Coin("RUNE", 1500)})
+	expiresAt := time.Now().Add(time.Minute).UTC()
+
+	// orders of unlisted markets are rejected
+	_, _, err := keeper.processLimitOrder(ctx, lister, BuyOrder, sdk.NewInt64Coin("LTC", 1), NewInt64Price("RUNE", 1),
+		OrderOptions{ExpiresAt: expiresAt})
+	require.Equal(t, CodeMarketNotListed, err.Code())
+
+	// fee rates can only be overridden by governance
+	listing := NewMarketListing("LTC", "RUNE", sdk.OneRat(), sdk.OneInt(), sdk.ZeroInt(), 0, noFeeRateOverride)
+	_, err = keeper.listMarket(ctx, lister, listing, 0)
+	require.Equal(t, CodeInvalidListing, err.Code())
+
+	listing = NewMarketListing("LTC", "RUNE", sdk.OneRat(), sdk.OneInt(), sdk.ZeroInt(), noFeeRateOverride,
+		noFeeRateOverride)
+	market, err := keeper.listMarket(ctx, lister, listing, 0)
+	require.Nil(t, err)
+	require.True(t, market.IsListed())
+	require.Equal(t, lister, market.Lister)
+	require.Equal(t, sdk.Coins{sdk.NewInt64Coin("RUNE", 1000)}, market.Deposit)
+	require.Equal(t, sdk.NewInt64Coin("RUNE", 500), bankKeeper.GetCoins(ctx, lister)[0])
+
+	// the escrow account holds the deposit
+	totals := keeper.getEscrowTotals(ctx)
+	require.Equal(t, sdk.Coins{sdk.NewInt64Coin("RUNE", 1000)}, totals.Balance)
+	require.True(t, totals.IsReconciled())
+
+	_, err = keeper.listMarket(ctx, lister, listing, 0)
+	require.Equal(t, CodeInvalidListing, err.Code())
+
+	processed, _, err := keeper.processLimitOrder(ctx, lister, BuyOrder, sdk.NewInt64Coin("LTC", 1),
+		NewInt64Price("RUNE", 1), OrderOptions{ExpiresAt: expiresAt})
+	require.Nil(t, err)
+
+	// only the lister or the authority may delist the market
+	_, err = keeper.delistMarket(ctx, other, "LTC", "RUNE")
+	require.Equal(t, CodeUnauthorized, err.Code())
+
+	market, err = keeper.delistMarket(ctx, lister, "LTC", "RUNE")
+	require.Nil(t, err)
+	require.False(t, market.IsListed())
+	require.Empty(t, market.Deposit)
+	require.Equal(t, "1499RUNE", bankKeeper.GetCoins(ctx, lister).String())
+	require.True(t, keeper.getEscrowTotals(ctx).IsReconciled())
+
+	_, err = keeper.delistMarket(ctx, lister, "LTC", "RUNE")
+	require.Equal(t, CodeMarketNotListed, err.Code())
+
+	// new orders are rejected, open orders can still be cancelled
+	_, _, err = keeper.processLimitOrder(ctx, lister, BuyOrder, sdk.NewInt64Coin("LTC", 1), NewInt64Price("RUNE", 1),
+		OrderOptions{ExpiresAt: expiresAt})
+	require.Equal(t, CodeMarketNotListed, err.Code())
+	_, _, err = keeper.cancelLimitOrder(ctx, lister, processed.OrderID)
+	require.Nil(t, err)
+	require.Equal(t, "1500RUNE", bankKeeper.GetCoins(ctx, lister).String())
+}
+
+func TestKeeperListMarketByProposal(t *testing.T) {
+	ctx := setupContext(exchangeKey)
+	keeper, _, _, lister, _ := setupKeepers(exchangeKey, ctx)
+	gov.InitGenesis(ctx, keeper.govKeeper, gov.DefaultGenesisState())
+
+	listing := NewMarketListing("LTC", "RUNE", sdk.NewRat(1, 100), sdk.NewInt(100), sdk.ZeroInt(), 5, 10)
+	proposal := keeper.govKeeper.NewTextProposal(ctx, "List LTC/RUNE", GetListingProposalDescription(listing),
+		gov.ProposalTypeText)
+
+	// the proposal must have passed
+	_, err := keeper.listMarket(ctx, lister, listing, proposal.GetProposalID())
+	require.Equal(t, CodeInvalidListing, err.Code())
+
+	proposal.SetStatus(gov.StatusPassed)
+	keeper.govKeeper.SetProposal(ctx, proposal)
+
+	// the listing must match the proposal
+	other := NewMarketListing("LTC", "RUNE", sdk.NewRat(1, 100), sdk.NewInt(100), sdk.ZeroInt(), 0, 0)
+	_, err = keeper.listMarket(ctx, lister, other, proposal.GetProposalID())
+	require.Equal(t, CodeInvalidListing, err.Code())
+
+	market, err := keeper.listMarket(ctx, lister, listing, proposal.GetProposalID())
+	require.Nil(t, err)
+	require.True(t, market.IsListed())
+	require.Empty(t, market.Deposit)
+
+	makerFeeRate, takerFeeRate := keeper.getFeeRates(ctx, market)
+	require.Equal(t, int64(5), makerFeeRate)
+	require.Equal(t, int64(10), takerFeeRate)
+	require.Len(t, keeper.getListedMarkets(ctx), 4)
+
+	// a used proposal cannot list the market again once it has been delisted
+	keeper.setParams(ctx, Params{Authority: lister.String()})
+	_, err = keeper.delistMarket(ctx, lister, "LTC", "RUNE")
+	require.Nil(t, err)
+	_, err = keeper.listMarket(ctx, lister, listing, proposal.GetProposalID())
+	require.Equal(t, CodeInvalidListing, err.Code())
+	require.False(t, keeper.getMarket(ctx, "LTC", "RUNE").IsListed())
+
+	// used proposals are exported and stay used after the import
+	genesis := WriteGenesis(ctx, keeper)
+	require.Equal(t, []int64{proposal.GetProposalID()}, genesis.UsedListingProposalIDs)
+	importCtx, importKeeper, _ := setupImportKeeper()
+	InitGenesis(importCtx, importKeeper, genesis)
+	require.True(t, importKeeper.isListingProposalUsed(importCtx, proposal.GetProposalID()))
+}
@@ -0,0 +1,77 @@
+package exchange
+
+import (
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// checkOCOGroup checks that a one-cancels-other group is not too long. An empty group is always valid
+func (k Keeper) checkOCOGroup(ocoGroup string) sdk.Error {
+	if len(ocoGroup) > MaxOCOGroupLength {
+		return ErrInvalidOCOGroup(k.codespace,
+			fmt.Sprintf("one-cancels-other group must not be longer than %v characters", MaxOCOGroupLength))
+	}
+	return nil
+}
+
+// getOCOGroupKey returns a key that identifies the one-cancels-other group of an order across senders, empty if the
+// order is not part of a group
+func getOCOGroupKey(order LimitOrder) string {
+	if order.OCOGroup == "" {
+		return ""
+	}
+	return string(MakeKeyOCOGroupSubspace(order.Sender, order.OCOGroup))
+}
+
+// getOCOGroupOrderIDs returns the ids of the open orders of the one-cancels-other group of a sender
+func (k Keeper) getOCOGroupOrderIDs(ctx sdk.Context, sender sdk.AccAddress, ocoGroup string) []int64 {
+	store := ctx.KVStore(k.storeKey)
+	prefix := MakeKeyOCOGroupSubspace(sender, ocoGroup)
+	iter := sdk.KVStorePrefixIterator(store, prefix)
+	defer iter.Close()
+
+	orderIDs := make([]int64, 0)
+	for ; iter.Valid(); iter.Next() {
+		// the prefix also matches groups that start with the group and a colon, whose keys are longer
+		if len(iter.Key()) != len(prefix)+20 {
+			continue
+		}
+
+		var orderID int64
+		k.cdc.MustUnmarshalBinary(iter.Value(), &orderID)
+		orderIDs = append(orderIDs, orderID)
+	}
+
+	return orderIDs
+}
+
+// cancelOCOSiblings cancels the open orders of the one-cancels-other group of a sender except for the order with the
+// given id, which has been filled or cancelled, and refunds their locked coins and deposits. Cancelling the siblings
+// does not cancel any further orders. Returns the ids of the cancelled orders
+func (k Keeper) cancelOCOSiblings(ctx sdk.Context, sender sdk.AccAddress, ocoGroup string, orderID int64) ([]int64,
+	sdk.Error) {
+	cancelled := make([]int64, 0)
+	if ocoGroup == "" {
+		return cancelled, nil
+	}
+
+	for _, siblingID := range k.getOCOGroupOrderIDs(ctx, sender, ocoGroup) {
+		if siblingID == orderID {
+			continue
+		}
+
+		orderBook, i, err := k.findLimitOrder(ctx, siblingID)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = k.removeOpenLimitOrder(ctx, orderBook, i)
+		if err != nil {
+			return nil, err
+		}
+		cancelled = append(cancelled, siblingID)
+	}
+
+	return cancelled, nil
+}
@@ -0,0 +1,151 @@
+package exchange
+
+import (
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+)
+
+// Test if a partial fill of an order cancels the other orders of its group, also in other markets, and refunds them
+func TestKeeperOCOGroupFill(t *testing.T) {
+	ctx := setupContext(exchangeKey)
+	keeper, _, bankKeeper, buyer, seller := setupKeepers(exchangeKey, ctx)
+	bankKeeper.SetCoins(ctx, buyer, sdk.Coins{sdk.NewInt64Coin("RUNE", 100)})
+	bankKeeper.SetCoins(ctx, seller, sdk.Coins{sdk.NewInt64Coin("ETH", 100)})
+
+	expiresAt := time.Now().Add(time.Minute).UTC()
+	sell := func(amount sdk.Coin, price Price, ocoGroup string) ProcessedLimitOrder {
+		processed, _, err := keeper.processLimitOrder(ctx, seller, SellOrder, amount, price,
+			OrderOptions{ExpiresAt: expiresAt, OCOGroup: ocoGroup})
+		require.Nil(t, err)
+		return processed
+	}
+
+	first := sell(sdk.NewInt64Coin("ETH", 10), NewInt64Price("RUNE", 5), "exit")
+	second := sell(sdk.NewInt64Coin("ETH", 10), NewInt64Price("RUNE", 9), "exit")
+	third := sell(sdk.NewInt64Coin("ETH", 10), NewInt64Price("BTC", 1), "exit")
+	other := sell(sdk.NewInt64Coin("ETH", 10), NewInt64Price("RUNE", 8), "exit:other")
+	require.Equal(t, "60ETH", bankKeeper.GetCoins(ctx, seller).String())
+
+	processed, filled, err := keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 4),
+		NewInt64Price("RUNE", 5), OrderOptions{ExpiresAt: expiresAt, TimeInForce: ImmediateOrCancel})
+	require.Nil(t, err)
+	require.Len(t, filled, 1)
+	require.Equal(t, []int64{second.OrderID, third.OrderID}, processed.CancelledOCOOrderIDs)
+
+	// the filled order keeps its remaining amount, the others of the group are refunded
+	orderBook, i, err := keeper.findLimitOrder(ctx, first.OrderID)
+	require.Nil(t, err)
+	require.Equal(t, sdk.NewInt64Coin("ETH", 6), orderBook.Orders[i].Amount)
+	for _, orderID := range []int64{second.OrderID, third.OrderID} {
+		_, _, err = keeper.findLimitOrder(ctx, orderID)
+		require.EqualError(t, err, ErrOrderNotFound(keeper.codespace, orderID).Error())
+	}
+	require.Equal(t, []int64{first.OrderID}, keeper.getOCOGroupOrderIDs(ctx, seller, "exit"))
+
+	// a group that only starts with the name of the group is not affected
+	_, _, err = keeper.findLimitOrder(ctx, other.OrderID)
+	require.Nil(t, err)
+
+	require.Equal(t, "80ETH,20RUNE", bankKeeper.GetCoins(ctx, seller).String())
+	require.True(t, keeper.getEscrowTotals(ctx).IsReconciled())
+}
+
+// Test if a taker does not match an order after another order of its group has been filled, and if a fill of the
+// taker cancels its own group
+func TestKeeperOCOGroupTaker(t *testing.T) {
+	ctx := setupContext(exchangeKey)
+	keeper, _, bankKeeper, buyer, seller := setupKeepers(exchangeKey, ctx)
+	bankKeeper.SetCoins(ctx, buyer, sdk.Coins{sdk.NewInt64Coin("RUNE", 200)})
+	bankKeeper.SetCoins(ctx, seller, sdk.Coins{sdk.NewInt64Coin("ETH", 100)})
+
+	expiresAt := time.Now().Add(time.Minute).UTC()
+	first, _, err := keeper.processLimitOrder(ctx, seller, SellOrder, sdk.NewInt64Coin("ETH", 10),
+		NewInt64Price("RUNE", 5), OrderOptions{ExpiresAt: expiresAt, OCOGroup: "exit"})
+	require.Nil(t, err)
+	second, _, err := keeper.processLimitOrder(ctx, seller, SellOrder, sdk.NewInt64Coin("ETH", 10),
+		NewInt64Price("RUNE", 6), OrderOptions{ExpiresAt: expiresAt, OCOGroup: "exit"})
+	require.Nil(t, err)
+
+	resting, _, err := keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 10),
+		NewInt64Price("RUNE", 2), OrderOptions{ExpiresAt: expiresAt, OCOGroup: "entry"})
+	require.Nil(t, err)
+	require.Equal(t, "180RUNE", bankKeeper.GetCoins(ctx, buyer).String())
+
+	// the second order of the seller is skipped once the first one has been filled
+	processed, filled, err := keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 20),
+		NewInt64Price("RUNE", 6), OrderOptions{ExpiresAt: expiresAt, TimeInForce: ImmediateOrCancel, OCOGroup: "entry"})
+	require.Nil(t, err)
+	require.Len(t, filled, 1)
+	require.Equal(t, first.OrderID, filled[0].OrderID)
+	require.Equal(t, []int64{second.OrderID, resting.OrderID}, processed.CancelledOCOOrderIDs)
+
+	require.Len(t, keeper.getOrderBook(ctx, SellOrder, "ETH", "RUNE").Orders, 0)
+	require.Len(t, keeper.getOrderBook(ctx, BuyOrder, "ETH", "RUNE").Orders, 0)
+	require.Equal(t, "10ETH,150RUNE", bankKeeper.GetCoins(ctx, buyer).String())
+	require.Equal(t, "90ETH,50RUNE", bankKeeper.GetCoins(ctx, seller).String())
+	require.True(t, keeper.getEscrowTotals(ctx).IsReconciled())
+}
+
+// Test if cancelling or expiring an order cancels the other orders of its group
+func TestKeeperOCOGroupCancelAndExpiry(t *testing.T) {
+	ctx := setupContext(exchangeKey).WithBlockHeight(10)
+	keeper, _, bankKeeper, _, seller := setupKeepers(exchangeKey, ctx)
+	bankKeeper.SetCoins(ctx, seller, sdk.Coins{sdk.NewInt64Coin("ETH", 100)})
+
+	sell := func(price Price, expiresAtHeight int64, timeInForce TimeInForce, ocoGroup string) ProcessedLimitOrder {
+		processed, _, err := keeper.processLimitOrder(ctx, seller, SellOrder, sdk.NewInt64Coin("ETH", 10), price,
+			OrderOptions{ExpiresAtHeight: expiresAtHeight, TimeInForce: timeInForce, OCOGroup: ocoGroup})
+		require.Nil(t, err)
+		return processed
+	}
+
+	cancelled := sell(NewInt64Price("RUNE", 5), 0, GoodTillCancelled, "a")
+	sibling := sell(NewInt64Price("RUNE", 6), 0, GoodTillCancelled, "a")
+	_, cancelledOCO, err := keeper.cancelLimitOrder(ctx, seller, cancelled.OrderID)
+	require.Nil(t, err)
+	require.Equal(t, []int64{sibling.OrderID}, cancelledOCO)
+	require.Len(t, keeper.getOrderBook(ctx, SellOrder, "ETH", "RUNE").Orders, 0)
+	require.Equal(t, "100ETH", bankKeeper.GetCoins(ctx, seller).String())
+
+	expiring := sell(NewInt64Price("RUNE", 5), 12, GoodTillTime, "b")
+	sibling = sell(NewInt64Price("RUNE", 6), 0, GoodTillCancelled, "b")
+	unrelated := sell(NewInt64Price("RUNE", 7), 0, GoodTillCancelled, "")
+	keeper.refundExpiredLimitOrders(ctx.WithBlockHeight(12))
+
+	orderBook := keeper.getOrderBook(ctx, SellOrder, "ETH", "RUNE")
+	require.Len(t, orderBook.Orders, 1)
+	require.Equal(t, unrelated.OrderID, orderBook.Orders[0].OrderID)
+	_, _, err = keeper.findLimitOrder(ctx, expiring.OrderID)
+	require.NotNil(t, err)
+	require.Equal(t, "90ETH", bankKeeper.GetCoins(ctx, seller).String())
+	require.Len(t, keeper.getOCOGroupOrderIDs(ctx, seller, "b"), 0)
+	require.True(t, keeper.getEscrowTotals(ctx).IsReconciled())
+}
+
+// Test if too long groups and groups in batch auction markets are rejected
+func TestKeeperOCOGroupInvalid(t *testing.T) {
+	ctx := setupContext(exchangeKey)
+	keeper, _, bankKeeper, _, seller := setupKeepers(exchangeKey, ctx)
+	bankKeeper.SetCoins(ctx, seller, sdk.Coins{sdk.NewInt64Coin("ETH", 100)})
+	expiresAt := time.Now().Add(time.Minute).UTC()
+
+	tooLong := string(make([]byte, MaxOCOGroupLength+1))
+	_, _, err := keeper.processLimitOrder(ctx, seller, SellOrder, sdk.NewInt64Coin("ETH", 10), NewInt64Price("RUNE", 5),
+		OrderOptions{ExpiresAt: expiresAt, OCOGroup: tooLong})
+	require.Equal(t, CodeInvalidOCOGroup, err.Code())
+
+	msg := NewMsgCreateLimitOrder(seller, SellOrder, sdk.NewInt64Coin("ETH", 10), NewInt64Price("RUNE", 5),
+		expiresAt, 0, GoodTillTime, MarketDefaultSTP, sdk.ZeroInt(), "", tooLong)
+	require.Equal(t, CodeInvalidOCOGroup, msg.ValidateBasic().Code())
+
+	market := newListedMarket("ETH", "RUNE")
+	market.Mode = BatchAuctionMode
+	keeper.setMarket(ctx, market)
+	_, _, err = keeper.processLimitOrder(ctx, seller, SellOrder, sdk.NewInt64Coin("ETH", 10), NewInt64Price("RUNE", 5),
+		OrderOptions{ExpiresAt: expiresAt, OCOGroup: "exit"})
+	require.Equal(t, CodeInvalidOCOGroup, err.Code())
+	require.Equal(t, "100ETH", bankKeeper.GetCoins(ctx, seller).String())
+}
@@ -0,0 +1,94 @@
+package exchange
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// checkOpenOrderLimits checks that the sender has neither reached the maximum number of open orders nor the maximum
+// number of open orders in the market of the given denoms
+func (k Keeper) checkOpenOrderLimits(ctx sdk.Context, sender sdk.AccAddress, amountDenom string,
+	priceDenom string) sdk.Error {
+	if max := k.MaxOpenOrders(ctx); k.getOpenOrderCount(ctx, MakeKeyOpenOrderCount(sender)) >= max {
+		return ErrMaxOpenOrders(k.codespace, max)
+	}
+
+	max := k.MaxOpenOrdersPerMarket(ctx)
+	if k.getOpenOrderCount(ctx, MakeKeyOpenOrderCountByMarket(sender, amountDenom, priceDenom)) >= max {
+		return ErrMaxOpenOrdersInMarket(k.codespace, max)
+	}
+
+	return nil
+}
+
+// checkOrderDeposit checks that the sender can pay the order deposit in addition to the coins locked for the order
+func (k Keeper) checkOrderDeposit(ctx sdk.Context, sender sdk.AccAddress, locked sdk.Coin) sdk.Error {
+	deposit := k.OrderDeposit(ctx)
+	if !deposit.IsPositive() {
+		return nil
+	}
+
+	if !k.bankKeeper.HasCoins(ctx, sender, sdk.Coins{locked}.Plus(sdk.Coins{deposit})) {
+		return ErrInsufficientOrderDeposit(k.codespace, deposit)
+	}
+
+	return nil
+}
+
+// getOpenOrderCount returns the number of open orders counted under the given key
+func (k Keeper) getOpenOrderCount(ctx sdk.Context, key []byte) int64 {
+	store := ctx.KVStore(k.storeKey)
+	bz := store.Get(key)
+	if bz == nil {
+		return 0
+	}
+
+	var count int64
+	k.cdc.MustUnmarshalBinary(bz, &count)
+	return count
+}
+
+// addOpenOrderCount adds delta to the number of open orders of the sender, both in total and in the market of the given
+// denoms
+func (k Keeper) addOpenOrderCount(ctx sdk.Context, sender sdk.AccAddress, amountDenom string, priceDenom string,
+	delta int64) {
+	store := ctx.KVStore(k.storeKey)
+
+	keys := [][]byte{MakeKeyOpenOrderCount(sender), MakeKeyOpenOrderCountByMarket(sender, amountDenom, priceDenom)}
+	for _, key := range keys {
+		count := k.getOpenOrderCount(ctx, key) + delta
+		if count <= 0 {
+			store.Delete(key)
+			continue
+		}
+		store.Set(key, k.cdc.MustMarshalBinary(count))
+	}
+}
+
+// lockOrderDeposit locks the order deposit for an order that rests in an order book. The deposit is kept with the
+// order, so that the deposit refunded is the one paid, even if the order deposit changes in the meantime
+func (k Keeper) lockOrderDeposit(ctx sdk.Context, order *LimitOrder) sdk.Error {
+	deposit := k.OrderDeposit(ctx)
+	if !deposit.IsPositive() {
+		return nil
+	}
+
+	err := k.lockCoins(ctx, order.Sender, deposit)
+	if err != nil {
+		return ErrInsufficientOrderDeposit(k.codespace, deposit)
+	}
+
+	order.Deposit = sdk.Coins{deposit}
+	return nil
+}
+
+// releaseOrderDeposit refunds the deposit of an order that has been closed
+func (k Keeper) releaseOrderDeposit(ctx sdk.Context, order LimitOrder) sdk.Error {
+	for _, coin := range order.Deposit {
+		err := k.releaseCoins(ctx, order.Sender, coin)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package exchange
+
+import (
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+)
+
+// Test if the open orders of an account are limited in total and per market, not counting orders that cannot rest
+func TestKeeperOpenOrderLimits(t *testing.T) {
+	ctx := setupContext(exchangeKey)
+	keeper, _, bankKeeper, _, seller := setupKeepers(exchangeKey, ctx)
+	bankKeeper.SetCoins(ctx, seller, sdk.Coins{sdk.NewInt64Coin("BTC", 100), sdk.NewInt64Coin("ETH", 100)})
+
+	params := keeper.getParams(ctx)
+	params.MaxOpenOrders = 3
+	params.MaxOpenOrdersPerMarket = 2
+	keeper.setParams(ctx, params)
+
+	expiresAt := time.Now().Add(time.Minute).UTC()
+	sell := func(amount sdk.Coin, price Price, timeInForce TimeInForce) (ProcessedLimitOrder, sdk.Error) {
+		processed, _, err := keeper.processLimitOrder(ctx, seller, SellOrder, amount, price,
+			OrderOptions{ExpiresAt: expiresAt, TimeInForce: timeInForce})
+		return processed, err
+	}
+
+	first, err := sell(sdk.NewInt64Coin("ETH", 10), NewInt64Price("RUNE", 5), GoodTillTime)
+	require.Nil(t, err)
+	_, err = sell(sdk.NewInt64Coin("ETH", 10), NewInt64Price("RUNE", 6), GoodTillTime)
+	require.Nil(t, err)
+	_, err = sell(sdk.NewInt64Coin("ETH", 10), NewInt64Price("RUNE", 7), GoodTillTime)
+	require.EqualError(t, err, ErrMaxOpenOrdersInMarket(keeper.codespace, 2).Error())
+
+	// immediate-or-cancel orders never rest in the order book and are not limited
+	_, err = sell(sdk.NewInt64Coin("ETH", 10), NewInt64Price("RUNE", 7), ImmediateOrCancel)
+	require.Nil(t, err)
+
+	_, err = sell(sdk.NewInt64Coin("BTC", 10), NewInt64Price("RUNE", 5), GoodTillTime)
+	require.Nil(t, err)
+	_, err = sell(sdk.NewInt64Coin("ETH", 10), NewInt64Price("BTC", 5), GoodTillTime)
+	require.EqualError(t, err, ErrMaxOpenOrders(keeper.codespace, 3).Error())
+
+	// a closed order does not count anymore
+	_, _, err = keeper.cancelLimitOrder(ctx, seller, first.OrderID)
+	require.Nil(t, err)
+	_, err = sell(sdk.NewInt64Coin("ETH", 10), NewInt64Price("BTC", 5), GoodTillTime)
+	require.Nil(t, err)
+	require.Equal(t, int64(3), keeper.getOpenOrderCount(ctx, MakeKeyOpenOrderCount(seller)))
+	require.Equal(t, int64(1), keeper.getOpenOrderCount(ctx, MakeKeyOpenOrderCountByMarket(seller, "ETH", "RUNE")))
+}
+
+// Test if resting orders lock the order deposit and get it refunded when they are filled or cancelled
+func TestKeeperOrderDeposit(t *testing.T) {
+	ctx, keeper, bankKeeper, buyer, seller, _, _, _, _ := setupCreateBuyLimitOrderTest()
+
+	params := keeper.getParams(ctx)
+	params.OrderDeposit = sdk.NewInt64Coin("RUNE", 10)
+	keeper.setParams(ctx, params)
+
+	expiresAt := time.Now().Add(time.Minute).UTC()
+
+	buy, _, err := keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 50), NewInt64Price("RUNE", 5),
+		OrderOptions{ExpiresAt: expiresAt})
+	require.Nil(t, err)
+	require.Equal(t, "1740RUNE", bankKeeper.GetCoins(ctx, buyer).String())
+	require.True(t, keeper.getEscrowTotals(ctx).IsReconciled())
+
+	// the seller has no coins to pay the deposit, but immediate-or-cancel orders do not pay one
+	_, _, err = keeper.processLimitOrder(ctx, seller, SellOrder, sdk.NewInt64Coin("ETH", 10), NewInt64Price("RUNE", 9),
+		OrderOptions{ExpiresAt: expiresAt})
+	require.EqualError(t, err, ErrInsufficientOrderDeposit(keeper.codespace, params.OrderDeposit).Error())
+	_, _, err = keeper.processLimitOrder(ctx, seller, SellOrder, sdk.NewInt64Coin("ETH", 10), NewInt64Price("RUNE", 5),
+		OrderOptions{ExpiresAt: expiresAt, TimeInForce: ImmediateOrCancel})
+	require.Nil(t, err)
+	require.Equal(t, "240ETH,50RUNE", bankKeeper.GetCoins(ctx, seller).String())
+
+	sell, _, err := keeper.processLimitOrder(ctx, seller, SellOrder, sdk.NewInt64Coin("ETH", 10), NewInt64Price("RUNE", 9),
+		OrderOptions{ExpiresAt: expiresAt})
+	require.Nil(t, err)
+	require.Equal(t, "230ETH,40RUNE", bankKeeper.GetCoins(ctx, seller).String())
+
+	// the deposit is refunded once the order is filled completely, at the amount that was paid
+	params.OrderDeposit = sdk.NewInt64Coin("RUNE", 20)
+	keeper.setParams(ctx, params)
+	_, _, err = keeper.processLimitOrder(ctx, seller, SellOrder, sdk.NewInt64Coin("ETH", 40), NewInt64Price("RUNE", 5),
+		OrderOptions{ExpiresAt: expiresAt, TimeInForce: ImmediateOrCancel})
+	require.Nil(t, err)
+	_, _, err = keeper.findLimitOrder(ctx, buy.OrderID)
+	require.NotNil(t, err)
+	require.Equal(t, "50ETH,1750RUNE", bankKeeper.GetCoins(ctx, buyer).String())
+
+	// cancelling refunds the deposit as well
+	_, _, err = keeper.cancelLimitOrder(ctx, seller, sell.OrderID)
+	require.Nil(t, err)
+	require.Equal(t, "200ETH,250RUNE", bankKeeper.GetCoins(ctx, seller).String())
+	require.True(t, keeper.getEscrowTotals(ctx).IsReconciled())
+}
@@ -0,0 +1,88 @@
+package exchange
+
+import (
+	"bytes"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// replaceLimitOrder changes price and/or amount of an open order in one step. A decreased amount at the same price
+// keeps the time priority of the order, any other change moves the order to the back of the queue at its (new)
+// price. Locked coins are only adjusted by the difference between the old and the new order. A replaced order must
+// not match any stored order, crossing the book needs a new order. The amount of an iceberg order is its total
+// amount, which is shown in slices of the same display amount as before.
+// nolint gocyclo
+func (k Keeper) replaceLimitOrder(ctx sdk.Context, sender sdk.AccAddress, orderID int64, amount sdk.Coin,
+	price Price) (LimitOrder, sdk.Error) {
+	orderBook, i, err := k.findLimitOrder(ctx, orderID)
+	if err != nil {
+		return LimitOrder{}, err
+	}
+
+	oldOrder := orderBook.Orders[i]
+	if !bytes.Equal(oldOrder.Sender, sender) {
+		return LimitOrder{}, ErrNotOrderOwner(k.codespace, orderID)
+	}
+
+	// error if denoms would move the order to another order book
+	if amount.Denom != orderBook.AmountDenom || price.Denom != orderBook.PriceDenom {
+		return LimitOrder{}, ErrDenomMismatch(k.codespace)
+	}
+
+	// error if amount negative
+	if !amount.IsPositive() {
+		return LimitOrder{}, ErrAmountNotPositive(k.codespace)
+	}
+
+	// error if price negative
+	if !price.IsPositive() {
+		return LimitOrder{}, ErrPriceNotPositive(k.codespace)
+	}
+
+	// error if the market is not listed, or tick size, lot size or minimum notional of the market are violated
+	err = k.checkMarketRules(ctx, amount, price)
+	if err != nil {
+		return LimitOrder{}, err
+	}
+
+	// error if the replaced order would match
+	if k.getFillableAmount(ctx, nil, oldOrder.Kind, amount, price, MarketDefaultSTP).IsPositive() {
+		return LimitOrder{}, ErrOrderWouldMatch(k.codespace)
+	}
+
+	newOrder := oldOrder
+	newOrder.Amount = amount
+	newOrder.Price = price
+	if oldOrder.Iceberg != nil {
+		newOrder.Iceberg = &Iceberg{oldOrder.Iceberg.DisplayAmount, sdk.ZeroInt()}
+		newOrder.hideReserve()
+	}
+
+	// lock or unlock the difference of the locked coins
+	oldLocked := oldOrder.getLockedCoins()
+	newLocked := newOrder.getLockedCoins()
+	if newLocked.Amount.GT(oldLocked.Amount) {
+		err = k.lockCoins(ctx, sender, newLocked.Minus(oldLocked))
+	} else if oldLocked.Amount.GT(newLocked.Amount) {
+		err = k.releaseCoins(ctx, sender, oldLocked.Minus(newLocked))
+	}
+	if err != nil {
+		return LimitOrder{}, err
+	}
+
+	keepsPriority := price.IsEqual(oldOrder.Price) && oldOrder.getTotalAmount().IsGTE(amount) &&
+		oldOrder.Amount.IsGTE(newOrder.Amount)
+	if keepsPriority {
+		orderBook.Orders[i] = newOrder
+	} else {
+		orderBook.RemoveLimitOrder(i)
+		err = orderBook.AddLimitOrder(newOrder)
+		if err != nil {
+			return LimitOrder{}, err
+		}
+	}
+
+	k.setOrderBook(ctx, orderBook)
+
+	return newOrder, nil
+}
@@ -0,0 +1,106 @@
+package exchange
+
+import (
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+)
+
+// Test if replacing keeps time priority only for size decreases at the same price and adjusts locked coins
+func TestKeeperReplaceLimitOrder(t *testing.T) {
+	ctx, keeper, bankKeeper, buyer, _, _, _, limitBuyOrder1, limitBuyOrder2 := setupCreateBuyLimitOrderTest()
+
+	expiresAt := time.Now().Add(time.Minute).UTC()
+
+	processedA, _, err := keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 100),
+		NewInt64Price("RUNE", 3), OrderOptions{ExpiresAt: expiresAt})
+	require.Nil(t, err)
+	processedB, _, err := keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 50),
+		NewInt64Price("RUNE", 3), OrderOptions{ExpiresAt: expiresAt})
+	require.Nil(t, err)
+	require.Equal(t, "1550RUNE", bankKeeper.GetCoins(ctx, buyer).String())
+
+	// decrease at same price keeps priority
+	replaced, err := keeper.replaceLimitOrder(
+		ctx, buyer, processedA.OrderID, sdk.NewInt64Coin("ETH", 60), NewInt64Price("RUNE", 3))
+	require.Nil(t, err)
+	require.Equal(t, processedA.OrderID, replaced.OrderID)
+	require.Equal(t, sdk.NewInt64Coin("ETH", 60), replaced.Amount)
+
+	buyOrderBook := keeper.getOrderBook(ctx, BuyOrder, "ETH", "RUNE")
+	require.Len(t, buyOrderBook.Orders, 4)
+	require.Equal(t, limitBuyOrder1, buyOrderBook.Orders[0])
+	require.Equal(t, replaced, buyOrderBook.Orders[1])
+	require.Equal(t, processedB.OrderID, buyOrderBook.Orders[2].OrderID)
+	require.Equal(t, limitBuyOrder2, buyOrderBook.Orders[3])
+	require.Equal(t, "1670RUNE", bankKeeper.GetCoins(ctx, buyer).String())
+
+	// increase at same price loses priority
+	replaced, err = keeper.replaceLimitOrder(
+		ctx, buyer, processedA.OrderID, sdk.NewInt64Coin("ETH", 120), NewInt64Price("RUNE", 3))
+	require.Nil(t, err)
+
+	buyOrderBook = keeper.getOrderBook(ctx, BuyOrder, "ETH", "RUNE")
+	require.Len(t, buyOrderBook.Orders, 4)
+	require.Equal(t, limitBuyOrder1, buyOrderBook.Orders[0])
+	require.Equal(t, processedB.OrderID, buyOrderBook.Orders[1].OrderID)
+	require.Equal(t, replaced, buyOrderBook.Orders[2])
+	require.Equal(t, limitBuyOrder2, buyOrderBook.Orders[3])
+	require.Equal(t, "1490RUNE", bankKeeper.GetCoins(ctx, buyer).String())
+
+	// price change moves the order to its new price level
+	replaced, err = keeper.replaceLimitOrder(
+		ctx, buyer, processedA.OrderID, sdk.NewInt64Coin("ETH", 120), NewInt64Price("RUNE", 5))
+	require.Nil(t, err)
+
+	buyOrderBook = keeper.getOrderBook(ctx, BuyOrder, "ETH", "RUNE")
+	require.Len(t, buyOrderBook.Orders, 4)
+	require.Equal(t, replaced, buyOrderBook.Orders[0])
+	require.Equal(t, limitBuyOrder1, buyOrderBook.Orders[1])
+	require.Equal(t, processedB.OrderID, buyOrderBook.Orders[2].OrderID)
+	require.Equal(t, limitBuyOrder2, buyOrderBook.Orders[3])
+	require.Equal(t, "1250RUNE", bankKeeper.GetCoins(ctx, buyer).String())
+}
+
+func TestKeeperReplaceLimitOrderSad(t *testing.T) {
+	ctx, keeper, bankKeeper, buyer, seller, _, _, _, _ := setupCreateBuyLimitOrderTest()
+
+	processed, _, err := keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 100),
+		NewInt64Price("RUNE", 3), OrderOptions{ExpiresAt: time.Now().Add(time.Minute).UTC()})
+	require.Nil(t, err)
+
+	// order of another sender
+	_, err = keeper.replaceLimitOrder(
+		ctx, seller, processed.OrderID, sdk.NewInt64Coin("ETH", 60), NewInt64Price("RUNE", 3))
+	require.EqualError(t, err, ErrNotOrderOwner(keeper.codespace, processed.OrderID).Error())
+
+	// unknown order
+	_, err = keeper.replaceLimitOrder(
+		ctx, buyer, processed.OrderID+1, sdk.NewInt64Coin("ETH", 60), NewInt64Price("RUNE", 3))
+	require.EqualError(t, err, ErrOrderNotFound(keeper.codespace, processed.OrderID+1).Error())
+
+	// other order book
+	_, err = keeper.replaceLimitOrder(
+		ctx, buyer, processed.OrderID, sdk.NewInt64Coin("BTC", 60), NewInt64Price("RUNE", 3))
+	require.EqualError(t, err, ErrDenomMismatch(keeper.codespace).Error())
+
+	// replaced order would match
+	_, err = keeper.replaceLimitOrder(
+		ctx, buyer, processed.OrderID, sdk.NewInt64Coin("ETH", 60), NewInt64Price("RUNE", 6))
+	require.EqualError(t, err, ErrOrderWouldMatch(keeper.codespace).Error())
+
+	// not enough coins for the increase
+	_, err = keeper.replaceLimitOrder(
+		ctx, buyer, processed.OrderID, sdk.NewInt64Coin("ETH", 1000), NewInt64Price("RUNE", 5))
+	require.NotNil(t, err)
+	require.Equal(t, sdk.CodeInsufficientCoins, err.Code())
+
+	// order and coins untouched
+	orderBook, i, err := keeper.findLimitOrder(ctx, processed.OrderID)
+	require.Nil(t, err)
+	require.Equal(t, sdk.NewInt64Coin("ETH", 100), orderBook.Orders[i].Amount)
+	require.Equal(t, NewInt64Price("RUNE", 3), orderBook.Orders[i].Price)
+	require.Equal(t, "1700RUNE", bankKeeper.GetCoins(ctx, buyer).String())
+}
@@ -0,0 +1,119 @@
+package exchange
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// getSelfTradePrevention returns the self-trade prevention mode of an order, which is the mode of the market if the
+// order does not set one
+func (k Keeper) getSelfTradePrevention(ctx sdk.Context, amountDenom string, priceDenom string,
+	stp SelfTradePrevention) SelfTradePrevention {
+	if stp != MarketDefaultSTP {
+		return stp
+	}
+	return k.getMarket(ctx, amountDenom, priceDenom).SelfTradePrevention
+}
+
+// preventSelfTrade applies the self-trade prevention mode of a new order that would match the stored order of the
+// same sender. The stored order is cancelled or decremented in place and its unlocked coins are refunded. Returns the
+// event and the amount of the new order that may still be matched, which is zero if the new order is cancelled
+func (k Keeper) preventSelfTrade(ctx sdk.Context, storedOrder *LimitOrder, orderID int64, unfilledAmt sdk.Coin,
+	stp SelfTradePrevention) (PreventedSelfTrade, sdk.Coin, sdk.Error) {
+	matchAmt := sdk.Coin{unfilledAmt.Denom, sdk.MinInt(unfilledAmt.Amount, storedOrder.Amount.Amount)}
+	prevented := PreventedSelfTrade{storedOrder.Sender, storedOrder.OrderID, orderID, stp, matchAmt, make([]int64, 0)}
+
+	reduceStored := sdk.NewInt64Coin(unfilledAmt.Denom, 0)
+	reduceNew := sdk.NewInt64Coin(unfilledAmt.Denom, 0)
+
+	switch stp {
+	case CancelNewest:
+		reduceNew = unfilledAmt
+	case CancelOldest:
+		reduceStored = storedOrder.getTotalAmount()
+	case CancelBoth:
+		reduceStored, reduceNew = storedOrder.getTotalAmount(), unfilledAmt
+	case DecrementAndCancel:
+		reduceStored, reduceNew = matchAmt, matchAmt
+	}
+
+	err := k.reduceLockedOrder(ctx, storedOrder, reduceStored)
+	if err != nil {
+		return PreventedSelfTrade{}, unfilledAmt, err
+	}
+	unfilledAmt = unfilledAmt.Minus(reduceNew)
+
+	if storedOrder.getTotalAmount().IsZero() {
+		prevented.CancelledOrderIDs = append(prevented.CancelledOrderIDs, storedOrder.OrderID)
+	}
+	if unfilledAmt.IsZero() {
+		prevented.CancelledOrderIDs = append(prevented.CancelledOrderIDs, orderID)
+	}
+
+	return prevented, unfilledAmt, nil
+}
+
+// preventBatchSelfTrade applies the self-trade prevention mode of the newer of two collected or stored orders of the
+// same sender that would match in a batch auction. Both orders are cancelled or decremented in place and their
+// unlocked coins are refunded
+func (k Keeper) preventBatchSelfTrade(ctx sdk.Context, buy *LimitOrder, sell *LimitOrder, marketSTP SelfTradePrevention,
+) PreventedSelfTrade {
+	maker, taker := buy, sell
+	if sell.OrderID < buy.OrderID {
+		maker, taker = sell, buy
+	}
+
+	stp := taker.SelfTradePrevention
+	if stp == MarketDefaultSTP {
+		stp = marketSTP
+	}
+
+	matchAmt := sdk.Coin{buy.Amount.Denom, sdk.MinInt(buy.Amount.Amount, sell.Amount.Amount)}
+	prevented := PreventedSelfTrade{taker.Sender, maker.OrderID, taker.OrderID, stp, matchAmt, make([]int64, 0)}
+
+	reduceMaker := sdk.NewInt64Coin(matchAmt.Denom, 0)
+	reduceTaker := sdk.NewInt64Coin(matchAmt.Denom, 0)
+
+	switch stp {
+	case CancelNewest:
+		reduceTaker = taker.Amount
+	case CancelOldest:
+		reduceMaker = maker.Amount
+	case CancelBoth:
+		reduceMaker, reduceTaker = maker.Amount, taker.Amount
+	case DecrementAndCancel:
+		reduceMaker, reduceTaker = matchAmt, matchAmt
+	}
+
+	// errors cannot be handled at the end of a block
+	err := k.reduceLockedOrder(ctx, maker, reduceMaker)
+	if err != nil {
+		panic(err)
+	}
+	err = k.reduceLockedOrder(ctx, taker, reduceTaker)
+	if err != nil {
+		panic(err)
+	}
+
+	if maker.Amount.IsZero() {
+		prevented.CancelledOrderIDs = append(prevented.CancelledOrderIDs, maker.OrderID)
+	}
+	if taker.Amount.IsZero() {
+		prevented.CancelledOrderIDs = append(prevented.CancelledOrderIDs, taker.OrderID)
+	}
+
+	return prevented
+}
+
+// reduceLockedOrder decrements the amount of an order whose coins are locked, the shown amount of an iceberg order
+// first, and refunds the coins that are not locked for the remaining amount anymore from the escrow account
+func (k Keeper) reduceLockedOrder(ctx sdk.Context, order *LimitOrder, amount sdk.Coin) sdk.Error {
+	if !amount.IsPositive() {
+		return nil
+	}
+
+	locked := order.getLockedCoins()
+	order.decrement(amount)
+	refund := locked.Minus(order.getLockedCoins())
+
+	return k.releaseCoins(ctx, order.Sender, refund)
+}
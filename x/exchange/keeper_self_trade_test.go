@@ -0,0 +1,133 @@
+package exchange
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+func TestKeeperSelfTradePrevention(t *testing.T) {
+	expiresAt := time.Now().Add(time.Minute).UTC()
+
+	cases := []struct {
+		stp          SelfTradePrevention
+		openAmt      int64 // open amount of the new buy order
+		storedAmt    int64 // remaining amount of the stored sell order
+		cancelledIDs []int64
+	}{
+		{CancelNewest, 0, 100, []int64{2}},
+		{CancelOldest, 50, 0, []int64{1}},
+		{CancelBoth, 0, 0, []int64{1, 2}},
+		{DecrementAndCancel, 0, 50, []int64{2}},
+	}
+
+	for _, c := range cases {
+		ctx := setupContext(exchangeKey)
+		keeper, _, bankKeeper, trader, _ := setupKeepers(exchangeKey, ctx)
+		bankKeeper.SetCoins(ctx, trader, sdk.Coins{sdk.NewInt64Coin("ETH", 100), sdk.NewInt64Coin("RUNE", 1000)})
+
+		_, _, err := keeper.processLimitOrder(ctx, trader, SellOrder, sdk.NewInt64Coin("ETH", 100), NewInt64Price("RUNE", 5),
+			OrderOptions{ExpiresAt: expiresAt})
+		require.Nil(t, err)
+
+		processed, filled, err := keeper.processLimitOrder(ctx, trader, BuyOrder, sdk.NewInt64Coin("ETH", 50),
+			NewInt64Price("RUNE", 5), OrderOptions{ExpiresAt: expiresAt, SelfTradePrevention: c.stp})
+		require.Nil(t, err)
+		require.Len(t, filled, 0)
+		require.Equal(t, c.openAmt, processed.OpenAmount.Amount.Int64())
+		require.Equal(t, []PreventedSelfTrade{{trader, 1, 2, c.stp, sdk.NewInt64Coin("ETH", 50), c.cancelledIDs}},
+			processed.PreventedSelfTrades)
+
+		// no coins change hands, only the open orders stay locked
+		sells := keeper.getOrderBook(ctx, SellOrder, "ETH", "RUNE").Orders
+		storedAmt := int64(0)
+		if len(sells) > 0 {
+			storedAmt = sells[0].Amount.Amount.Int64()
+		}
+		require.Equal(t, c.storedAmt, storedAmt)
+		coins := bankKeeper.GetCoins(ctx, trader)
+		require.Equal(t, 100-c.storedAmt, coins.AmountOf("ETH").Int64())
+		require.Equal(t, 1000-c.openAmt*5, coins.AmountOf("RUNE").Int64())
+		require.Len(t, keeper.getOpenLimitOrdersBySender(ctx, trader), len(sells)+int(c.openAmt/50))
+	}
+}
+
+func TestKeeperSelfTradePreventionContinuesWithOtherOrders(t *testing.T) {
+	ctx := setupContext(exchangeKey)
+	keeper, _, bankKeeper, buyer, seller := setupKeepers(exchangeKey, ctx)
+	bankKeeper.SetCoins(ctx, buyer, sdk.Coins{sdk.NewInt64Coin("ETH", 50), sdk.NewInt64Coin("RUNE", 1000)})
+	bankKeeper.SetCoins(ctx, seller, sdk.Coins{sdk.NewInt64Coin("ETH", 50)})
+	expiresAt := time.Now().Add(time.Minute).UTC()
+
+	_, _, err := keeper.processLimitOrder(ctx, buyer, SellOrder, sdk.NewInt64Coin("ETH", 50), NewInt64Price("RUNE", 4),
+		OrderOptions{ExpiresAt: expiresAt})
+	require.Nil(t, err)
+	_, _, err = keeper.processLimitOrder(ctx, seller, SellOrder, sdk.NewInt64Coin("ETH", 50), NewInt64Price("RUNE", 5),
+		OrderOptions{ExpiresAt: expiresAt})
+	require.Nil(t, err)
+
+	// fill-or-kill orders cannot count on own orders
+	_, _, err = keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 100), NewInt64Price("RUNE", 5),
+		OrderOptions{ExpiresAt: expiresAt, TimeInForce: FillOrKill, SelfTradePrevention: CancelOldest})
+	require.Equal(t, CodeOrderNotFillable, err.Code())
+
+	// the market default cancels the own sell order, then the sell order of the other sender is filled
+	market := keeper.getMarket(ctx, "ETH", "RUNE")
+	market.SelfTradePrevention = CancelOldest
+	keeper.setMarket(ctx, market)
+
+	processed, filled, err := keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 100),
+		NewInt64Price("RUNE", 5), OrderOptions{ExpiresAt: expiresAt})
+	require.Nil(t, err)
+	require.Len(t, processed.PreventedSelfTrades, 1)
+	require.Equal(t, CancelOldest, processed.PreventedSelfTrades[0].Mode)
+	require.Len(t, filled, 1)
+	require.Equal(t, int64(2), filled[0].OrderID)
+	require.Equal(t, int64(50), processed.OpenAmount.Amount.Int64())
+
+	// the stored order keeps following the market default
+	orders := keeper.getOrderBook(ctx, BuyOrder, "ETH", "RUNE").Orders
+	require.Len(t, orders, 1)
+	require.Equal(t, MarketDefaultSTP, orders[0].SelfTradePrevention)
+	require.Equal(t, sdk.Coins{sdk.NewInt64Coin("ETH", 100), sdk.NewInt64Coin("RUNE", 500)},
+		bankKeeper.GetCoins(ctx, buyer))
+	require.Equal(t, sdk.Coins{sdk.NewInt64Coin("RUNE", 250)}, bankKeeper.GetCoins(ctx, seller))
+}
+
+func TestKeeperBatchAuctionSelfTradePrevention(t *testing.T) {
+	ctx := setupContext(exchangeKey)
+	keeper, _, bankKeeper, trader, other := setupKeepers(exchangeKey, ctx)
+	bankKeeper.SetCoins(ctx, trader, sdk.Coins{sdk.NewInt64Coin("ETH", 100), sdk.NewInt64Coin("RUNE", 1000)})
+	bankKeeper.SetCoins(ctx, other, sdk.Coins{sdk.NewInt64Coin("ETH", 30)})
+	market := newListedMarket("ETH", "RUNE")
+	market.Mode = BatchAuctionMode
+	keeper.setMarket(ctx, market)
+	expiresAt := time.Now().Add(time.Minute).UTC()
+
+	_, _, err := keeper.processLimitOrder(ctx, trader, SellOrder, sdk.NewInt64Coin("ETH", 100), NewInt64Price("RUNE", 5),
+		OrderOptions{ExpiresAt: expiresAt})
+	require.Nil(t, err)
+	_, _, err = keeper.processLimitOrder(ctx, other, SellOrder, sdk.NewInt64Coin("ETH", 30), NewInt64Price("RUNE", 5),
+		OrderOptions{ExpiresAt: expiresAt})
+	require.Nil(t, err)
+	_, _, err = keeper.processLimitOrder(ctx, trader, BuyOrder, sdk.NewInt64Coin("ETH", 60), NewInt64Price("RUNE", 5),
+		OrderOptions{ExpiresAt: expiresAt, TimeInForce: ImmediateOrCancel, SelfTradePrevention: DecrementAndCancel})
+	require.Nil(t, err)
+
+	// the own buy order decrements the own sell order, nothing is left to trade with the other sender
+	results := keeper.clearBatchAuctions(ctx)
+	require.Len(t, results, 1)
+	require.Len(t, results[0].Trades, 0)
+	require.True(t, results[0].Volume.IsZero())
+	require.Equal(t, []PreventedSelfTrade{{trader, 1, 3, DecrementAndCancel, sdk.NewInt64Coin("ETH", 60),
+		[]int64{3}}}, results[0].PreventedSelfTrades)
+
+	sells := keeper.getOrderBook(ctx, SellOrder, "ETH", "RUNE").Orders
+	require.Len(t, sells, 2)
+	require.Equal(t, int64(40), sells[0].Amount.Amount.Int64())
+	require.Equal(t, sdk.Coins{sdk.NewInt64Coin("ETH", 60), sdk.NewInt64Coin("RUNE", 1000)},
+		bankKeeper.GetCoins(ctx, trader))
+}
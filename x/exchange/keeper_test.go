@@ -16,6 +16,9 @@ import (
 	"github.com/cosmos/cosmos-sdk/wire"
 	auth "github.com/cosmos/cosmos-sdk/x/auth"
 	bank "github.com/cosmos/cosmos-sdk/x/bank"
+	"github.com/cosmos/cosmos-sdk/x/gov"
+	"github.com/cosmos/cosmos-sdk/x/params"
+	"github.com/cosmos/cosmos-sdk/x/stake"
 )
 
 var (
@@ -27,7 +30,7 @@ func setupContext(exchangeKey *sdk.KVStoreKey) sdk.Context {
 	multiStore := store.NewCommitMultiStore(db)
 	multiStore.MountStoreWithDB(exchangeKey, sdk.StoreTypeIAVL, db)
 	multiStore.LoadLatestVersion()
-	ctx := sdk.NewContext(multiStore, abci.Header{}, false, nil)
+	ctx := sdk.NewContext(multiStore, abci.Header{Time: time.Now().UTC()}, false, nil)
 	return ctx
 }
 
@@ -35,11 +38,17 @@ func setupKeepers(exchangeKey *sdk.KVStoreKey, ctx sdk.Context) (Keeper, *amino.
 	sdk.AccAddress, sdk.AccAddress) {
 	cdc := wire.NewCodec()
 	auth.RegisterBaseAccount(cdc)
+	gov.RegisterWire(cdc)
 	accountMapper := auth.NewAccountMapper(cdc, exchangeKey, auth.ProtoBaseAccount)
 	bankKeeper := bank.NewKeeper(accountMapper)
-	exchangeKeeper := NewKeeper(exchangeKey, bankKeeper, DefaultCodespace)
-
-	InitGenesis(ctx, exchangeKeeper, DefaultGenesisState())
+	paramsKeeper := params.NewKeeper(cdc, exchangeKey)
+	stakeKeeper := stake.NewKeeper(cdc, exchangeKey, bankKeeper, stake.DefaultCodespace)
+	govKeeper := gov.NewKeeper(cdc, exchangeKey, paramsKeeper.Setter(), bankKeeper, stakeKeeper, gov.DefaultCodespace)
+	feeCollectionKeeper := auth.NewFeeCollectionKeeper(cdc, exchangeKey)
+	exchangeKeeper := NewKeeper(exchangeKey, bankKeeper, feeCollectionKeeper, govKeeper, paramsKeeper.Setter(),
+		DefaultCodespace)
+
+	InitGenesis(ctx, exchangeKeeper, testGenesisState())
 	WriteGenesis(ctx, exchangeKeeper)
 
 	buyerAddress := sdk.AccAddress([]byte("buyerAddress"))
@@ -53,6 +62,29 @@ func setupKeepers(exchangeKey *sdk.KVStoreKey, ctx sdk.Context) (Keeper, *amino.
 	return exchangeKeeper, cdc, bankKeeper, buyerAddress, sellerAddress
 }
 
+// newListedMarket returns a listed market with default configuration
+func newListedMarket(amountDenom string, priceDenom string) Market {
+	market := NewMarket(amountDenom, priceDenom, ContinuousMode)
+	market.Status = ListedStatus
+	return market
+}
+
+// testGenesisState returns the default genesis state with the markets used by the tests listed
+func testGenesisState() GenesisState {
+	genesis := DefaultGenesisState()
+	genesis.Markets = []Market{newListedMarket("BTC", "RUNE"), newListedMarket("ETH", "BTC"),
+		newListedMarket("ETH", "RUNE")}
+	return genesis
+}
+
+// expireLimitOrder lets the order at the given index of the order book be expired a minute ago
+func expireLimitOrder(ctx sdk.Context, keeper Keeper, orderBook OrderBook, i int) {
+	keeper.unindexLimitOrder(ctx, orderBook.Orders[i])
+	orderBook.Orders[i].ExpiresAt = time.Now().Add(-time.Minute).UTC()
+	keeper.setOrderBook(ctx, orderBook)
+	keeper.indexLimitOrder(ctx, orderBook.Orders[i], orderBook.Key)
+}
+
 func setupCreateBuyLimitOrderTest() (sdk.Context, Keeper, bank.Keeper, sdk.AccAddress, sdk.AccAddress,
 	LimitOrder, LimitOrder, LimitOrder, LimitOrder) {
 	ctx := setupContext(exchangeKey)
@@ -64,23 +96,26 @@ func setupCreateBuyLimitOrderTest() (sdk.Context, Keeper, bank.Keeper, sdk.AccAd
 	sellOrderBook := keeper.getOrderBook(ctx, SellOrder, "ETH", "RUNE")
 	id, _ := keeper.getNewOrderID(ctx)
 	limitSellOrder1 := NewLimitOrder(id, seller, SellOrder, sdk.NewInt64Coin("ETH", 120),
-		sdk.NewInt64Coin("RUNE", 6), time.Now().Add(time.Minute).UTC())
+		NewInt64Price("RUNE", 6), time.Now().Add(time.Minute).UTC(), GoodTillTime)
 	id, _ = keeper.getNewOrderID(ctx)
 	limitSellOrder2 := NewLimitOrder(id, seller, SellOrder, sdk.NewInt64Coin("ETH", 100),
-		sdk.NewInt64Coin("RUNE", 7), time.Now().Add(time.Minute).UTC())
+		NewInt64Price("RUNE", 7), time.Now().Add(time.Minute).UTC(), GoodTillTime)
 	sellOrderBook.Orders = []LimitOrder{limitSellOrder1, limitSellOrder2}
 	keeper.setOrderBook(ctx, sellOrderBook)
 
 	buyOrderBook := keeper.getOrderBook(ctx, BuyOrder, "ETH", "RUNE")
 	id, _ = keeper.getNewOrderID(ctx)
 	limitBuyOrder1 := NewLimitOrder(id, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 50),
-		sdk.NewInt64Coin("RUNE", 4), time.Now().Add(time.Minute).UTC())
+		NewInt64Price("RUNE", 4), time.Now().Add(time.Minute).UTC(), GoodTillTime)
 	id, _ = keeper.getNewOrderID(ctx)
 	limitBuyOrder2 := NewLimitOrder(id, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 80),
-		sdk.NewInt64Coin("RUNE", 2), time.Now().Add(time.Minute).UTC())
+		NewInt64Price("RUNE", 2), time.Now().Add(time.Minute).UTC(), GoodTillTime)
 	buyOrderBook.Orders = []LimitOrder{limitBuyOrder1, limitBuyOrder2}
 	keeper.setOrderBook(ctx, buyOrderBook)
 
+	// the escrow account holds the coins locked for the stored orders
+	bankKeeper.SetCoins(ctx, EscrowAddress, sdk.Coins{sdk.NewInt64Coin("ETH", 220), sdk.NewInt64Coin("RUNE", 360)})
+
 	return ctx, keeper, bankKeeper, buyer, seller, limitSellOrder1, limitSellOrder2, limitBuyOrder1, limitBuyOrder2
 }
 
@@ -89,33 +124,38 @@ func TestKeeperCreateLimitOrderSad(t *testing.T) {
 
 	// Test invalid limit orders then confirm balances and orderbook is still untouched
 	// Invalid limit order that is expired
-	_, _, err := keeper.processLimitOrder(
-		ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 200), sdk.NewInt64Coin("RUNE", 3), time.Now().Add(-time.Minute))
+	_, _, err := keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 200), NewInt64Price("RUNE", 3),
+		OrderOptions{ExpiresAt: time.Now().Add(-time.Minute)})
 	require.EqualError(t, err, ErrOrderExpired(keeper.codespace).Error())
 
 	// Invalid limit order with wrong kind
-	_, _, err = keeper.processLimitOrder(
-		ctx, buyer, 0x03, sdk.NewInt64Coin("ETH", 200), sdk.NewInt64Coin("RUNE", 3), time.Now().Add(time.Minute))
+	_, _, err = keeper.processLimitOrder(ctx, buyer, 0x03, sdk.NewInt64Coin("ETH", 200), NewInt64Price("RUNE", 3),
+		OrderOptions{ExpiresAt: time.Now().Add(time.Minute)})
 	require.EqualError(t, err, ErrInvalidKind(keeper.codespace).Error())
 
+	// Invalid limit order with wrong time in force
+	_, _, err = keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 200), NewInt64Price("RUNE", 3),
+		OrderOptions{ExpiresAt: time.Now().Add(time.Minute), TimeInForce: 0x05})
+	require.EqualError(t, err, ErrInvalidTimeInForce(keeper.codespace).Error())
+
 	// Invalid limit order token to same token
-	_, _, err = keeper.processLimitOrder(
-		ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 200), sdk.NewInt64Coin("ETH", 3), time.Now().Add(time.Minute))
+	_, _, err = keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 200), NewInt64Price("ETH", 3),
+		OrderOptions{ExpiresAt: time.Now().Add(time.Minute)})
 	require.EqualError(t, err, ErrSameDenom(keeper.codespace).Error())
 
 	// Invalid limit order negative amount
-	_, _, err = keeper.processLimitOrder(
-		ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", -200), sdk.NewInt64Coin("RUNE", 3), time.Now().Add(time.Minute))
+	_, _, err = keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", -200), NewInt64Price("RUNE", 3),
+		OrderOptions{ExpiresAt: time.Now().Add(time.Minute)})
 	require.EqualError(t, err, ErrAmountNotPositive(keeper.codespace).Error())
 
 	// Invalid limit order negative price
-	_, _, err = keeper.processLimitOrder(
-		ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 200), sdk.NewInt64Coin("RUNE", -3), time.Now().Add(time.Minute))
+	_, _, err = keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 200), NewInt64Price("RUNE", -3),
+		OrderOptions{ExpiresAt: time.Now().Add(time.Minute)})
 	require.EqualError(t, err, ErrPriceNotPositive(keeper.codespace).Error())
 
 	// Invalid limit order not enough coins
-	_, _, err = keeper.processLimitOrder(
-		ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 200), sdk.NewInt64Coin("RUNE", 11), time.Now().Add(time.Minute))
+	_, _, err = keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 200), NewInt64Price("RUNE", 11),
+		OrderOptions{ExpiresAt: time.Now().Add(time.Minute)})
 	require.EqualError(t, err, sdk.ErrInsufficientCoins("Must have at least 2200RUNE to place this buy limit order").Error())
 
 	// Check balances still the same after invalid trades
@@ -138,8 +178,8 @@ func TestKeeperCreateBuyLimitOrderNotFilled(t *testing.T) {
 
 	expiresAt := time.Now().Add(time.Minute).UTC()
 
-	processed, filled, err := keeper.processLimitOrder(
-		ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 200), sdk.NewInt64Coin("RUNE", 3), expiresAt)
+	processed, filled, err := keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 200),
+		NewInt64Price("RUNE", 3), OrderOptions{ExpiresAt: expiresAt})
 
 	require.Nil(t, err)
 	require.True(t, processed.OrderID > 0)
@@ -149,7 +189,7 @@ func TestKeeperCreateBuyLimitOrderNotFilled(t *testing.T) {
 	orderBook := keeper.getOrderBook(ctx, BuyOrder, "ETH", "RUNE")
 	require.Equal(t, limitBuyOrder1, orderBook.Orders[0])
 	require.Equal(t, NewLimitOrder(limitBuyOrder2.OrderID+1, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 200),
-		sdk.NewInt64Coin("RUNE", 3), expiresAt), orderBook.Orders[1])
+		NewInt64Price("RUNE", 3), expiresAt, GoodTillTime), orderBook.Orders[1])
 	require.Equal(t, limitBuyOrder2, orderBook.Orders[2])
 
 	// Check balances have been locked
@@ -164,8 +204,8 @@ func TestKeeperCreateBuyLimitOrderFilled(t *testing.T) {
 
 	expiresAt := time.Now().Add(time.Minute).UTC()
 
-	processed, filled, err := keeper.processLimitOrder(
-		ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 200), sdk.NewInt64Coin("RUNE", 8), expiresAt)
+	processed, filled, err := keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 200),
+		NewInt64Price("RUNE", 8), OrderOptions{ExpiresAt: expiresAt})
 
 	require.Nil(t, err)
 	require.True(t, processed.OrderID > 0)
@@ -173,10 +213,10 @@ func TestKeeperCreateBuyLimitOrderFilled(t *testing.T) {
 	require.Len(t, filled, 2)
 	require.Equal(t, limitSellOrder1.OrderID, filled[0].OrderID)
 	require.Equal(t, sdk.NewInt64Coin("ETH", 120), filled[0].FilledAmount)
-	require.Equal(t, sdk.NewInt64Coin("RUNE", 6), filled[0].FilledPrice)
+	require.Equal(t, NewInt64Price("RUNE", 6), filled[0].FilledPrice)
 	require.Equal(t, limitSellOrder2.OrderID, filled[1].OrderID)
 	require.Equal(t, sdk.NewInt64Coin("ETH", 80), filled[1].FilledAmount)
-	require.Equal(t, sdk.NewInt64Coin("RUNE", 7), filled[1].FilledPrice)
+	require.Equal(t, NewInt64Price("RUNE", 7), filled[1].FilledPrice)
 
 	// buy orderbook untouched
 	buyOrderBook := keeper.getOrderBook(ctx, BuyOrder, "ETH", "RUNE")
@@ -198,6 +238,34 @@ func TestKeeperCreateBuyLimitOrderFilled(t *testing.T) {
 	require.Equal(t, "200ETH,720RUNE", coinsBuyer.String())
 }
 
+// Test if maker and taker fees are taken from the proceeds of each fill and sent to the fee collector
+func TestKeeperCreateBuyLimitOrderFilledWithFees(t *testing.T) {
+	ctx, keeper, bankKeeper, buyer, seller, _, _, _, _ := setupCreateBuyLimitOrderTest()
+	keeper.setParams(ctx, Params{MakerFeeRate: 100, TakerFeeRate: 100})
+
+	_, filled, err := keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 200),
+		NewInt64Price("RUNE", 8), OrderOptions{ExpiresAt: time.Now().Add(time.Minute).UTC()})
+	require.Nil(t, err)
+
+	// 1% of 720RUNE and 120ETH, then 1% of 560RUNE and 80ETH, rounded down
+	require.Len(t, filled, 2)
+	require.Equal(t, sdk.NewInt64Coin("RUNE", 7), filled[0].MakerFee)
+	require.Equal(t, sdk.NewInt64Coin("ETH", 1), filled[0].TakerFee)
+	require.Equal(t, sdk.NewInt64Coin("RUNE", 5), filled[1].MakerFee)
+	require.Equal(t, "0ETH", filled[1].TakerFee.String())
+
+	trades := keeper.getTradesByPair(ctx, "ETH", "RUNE", 1, 10)
+	require.Len(t, trades, 2)
+	require.Equal(t, "5RUNE", trades[0].MakerFee.String())
+	require.Equal(t, "0ETH", trades[0].TakerFee.String())
+	require.Equal(t, "7RUNE", trades[1].MakerFee.String())
+	require.Equal(t, "1ETH", trades[1].TakerFee.String())
+
+	require.Equal(t, "250ETH,1268RUNE", bankKeeper.GetCoins(ctx, seller).String())
+	require.Equal(t, "199ETH,720RUNE", bankKeeper.GetCoins(ctx, buyer).String())
+	require.Equal(t, "1ETH,12RUNE", keeper.feeCollectionKeeper.GetCollectedFees(ctx).String())
+}
+
 // Test if buy order can be filled partially by 1 sell order => expect new partial buy order to be placed
 func TestKeeperCreateBuyLimitOrderFilledPartially(t *testing.T) {
 	ctx, keeper, bankKeeper, buyer, seller, limitSellOrder1, limitSellOrder2, limitBuyOrder1, limitBuyOrder2 :=
@@ -205,8 +273,8 @@ func TestKeeperCreateBuyLimitOrderFilledPartially(t *testing.T) {
 
 	expiresAt := time.Now().Add(time.Minute).UTC()
 
-	processed, filled, err := keeper.processLimitOrder(
-		ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 210), sdk.NewInt64Coin("RUNE", 6), expiresAt)
+	processed, filled, err := keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 210),
+		NewInt64Price("RUNE", 6), OrderOptions{ExpiresAt: expiresAt})
 
 	require.Nil(t, err)
 	require.True(t, processed.OrderID > 0)
@@ -214,13 +282,13 @@ func TestKeeperCreateBuyLimitOrderFilledPartially(t *testing.T) {
 	require.Len(t, filled, 1)
 	require.Equal(t, limitSellOrder1.OrderID, filled[0].OrderID)
 	require.Equal(t, sdk.NewInt64Coin("ETH", 120), filled[0].FilledAmount)
-	require.Equal(t, sdk.NewInt64Coin("RUNE", 6), filled[0].FilledPrice)
+	require.Equal(t, NewInt64Price("RUNE", 6), filled[0].FilledPrice)
 
 	// buy orderbook has additional buy order over partial filling
 	buyOrderBook := keeper.getOrderBook(ctx, BuyOrder, "ETH", "RUNE")
 	require.Len(t, buyOrderBook.Orders, 3)
 	require.Equal(t, NewLimitOrder(processed.OrderID, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 90),
-		sdk.NewInt64Coin("RUNE", 6), expiresAt), buyOrderBook.Orders[0])
+		NewInt64Price("RUNE", 6), expiresAt, GoodTillTime), buyOrderBook.Orders[0])
 	require.Equal(t, limitBuyOrder1, buyOrderBook.Orders[1])
 	require.Equal(t, limitBuyOrder2, buyOrderBook.Orders[2])
 
@@ -237,15 +305,180 @@ func TestKeeperCreateBuyLimitOrderFilledPartially(t *testing.T) {
 	require.Equal(t, "120ETH,740RUNE", coinsBuyer.String())
 }
 
+// Test if post-only buy order is rejected if it would match and stored otherwise
+func TestKeeperCreateBuyLimitOrderPostOnly(t *testing.T) {
+	ctx, keeper, bankKeeper, buyer, _, _, _, limitBuyOrder1, limitBuyOrder2 := setupCreateBuyLimitOrderTest()
+
+	expiresAt := time.Now().Add(time.Minute).UTC()
+
+	_, _, err := keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 50), NewInt64Price("RUNE", 6),
+		OrderOptions{ExpiresAt: expiresAt, TimeInForce: PostOnly})
+	require.EqualError(t, err, ErrOrderWouldMatch(keeper.codespace).Error())
+
+	// buyer coins untouched
+	require.Equal(t, "2000RUNE", bankKeeper.GetCoins(ctx, buyer).String())
+
+	processed, filled, err := keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 50),
+		NewInt64Price("RUNE", 5), OrderOptions{ExpiresAt: expiresAt, TimeInForce: PostOnly})
+
+	require.Nil(t, err)
+	require.True(t, processed.OpenAmount.IsEqual(sdk.NewInt64Coin("ETH", 50)))
+	require.Len(t, filled, 0)
+
+	// buy orderbook has additional post-only order
+	buyOrderBook := keeper.getOrderBook(ctx, BuyOrder, "ETH", "RUNE")
+	require.Len(t, buyOrderBook.Orders, 3)
+	require.Equal(t, NewLimitOrder(processed.OrderID, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 50),
+		NewInt64Price("RUNE", 5), expiresAt, PostOnly), buyOrderBook.Orders[0])
+	require.Equal(t, limitBuyOrder1, buyOrderBook.Orders[1])
+	require.Equal(t, limitBuyOrder2, buyOrderBook.Orders[2])
+
+	// buyer coins locked
+	require.Equal(t, "1750RUNE", bankKeeper.GetCoins(ctx, buyer).String())
+}
+
+// Test if immediate-or-cancel buy order is filled partially and the rest is not stored
+func TestKeeperCreateBuyLimitOrderImmediateOrCancel(t *testing.T) {
+	ctx, keeper, bankKeeper, buyer, seller, limitSellOrder1, limitSellOrder2, limitBuyOrder1, limitBuyOrder2 :=
+		setupCreateBuyLimitOrderTest()
+
+	expiresAt := time.Now().Add(time.Minute).UTC()
+
+	processed, filled, err := keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 210),
+		NewInt64Price("RUNE", 6), OrderOptions{ExpiresAt: expiresAt, TimeInForce: ImmediateOrCancel})
+
+	require.Nil(t, err)
+	require.True(t, processed.OrderID > 0)
+	require.True(t, processed.OpenAmount.IsZero())
+	require.Len(t, filled, 1)
+	require.Equal(t, limitSellOrder1.OrderID, filled[0].OrderID)
+	require.Equal(t, sdk.NewInt64Coin("ETH", 120), filled[0].FilledAmount)
+
+	// buy orderbook untouched
+	buyOrderBook := keeper.getOrderBook(ctx, BuyOrder, "ETH", "RUNE")
+	require.Len(t, buyOrderBook.Orders, 2)
+	require.Equal(t, limitBuyOrder1, buyOrderBook.Orders[0])
+	require.Equal(t, limitBuyOrder2, buyOrderBook.Orders[1])
+
+	// sell orderbook changed
+	sellOrderBook := keeper.getOrderBook(ctx, SellOrder, "ETH", "RUNE")
+	require.Len(t, sellOrderBook.Orders, 1)
+	require.Equal(t, limitSellOrder2, sellOrderBook.Orders[0])
+
+	// seller and buyer coins changed, nothing locked for the cancelled rest
+	require.Equal(t, "250ETH,720RUNE", bankKeeper.GetCoins(ctx, seller).String())
+	require.Equal(t, "120ETH,1280RUNE", bankKeeper.GetCoins(ctx, buyer).String())
+}
+
+// Test if fill-or-kill buy order is rejected if it cannot be filled completely and filled otherwise
+func TestKeeperCreateBuyLimitOrderFillOrKill(t *testing.T) {
+	ctx, keeper, bankKeeper, buyer, seller, limitSellOrder1, limitSellOrder2, _, _ := setupCreateBuyLimitOrderTest()
+
+	expiresAt := time.Now().Add(time.Minute).UTC()
+
+	_, _, err := keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 230), NewInt64Price("RUNE", 7),
+		OrderOptions{ExpiresAt: expiresAt, TimeInForce: FillOrKill})
+	require.EqualError(t, err, ErrOrderNotFillable(keeper.codespace).Error())
+
+	// sell orderbook and coins untouched
+	sellOrderBook := keeper.getOrderBook(ctx, SellOrder, "ETH", "RUNE")
+	require.Len(t, sellOrderBook.Orders, 2)
+	require.Equal(t, limitSellOrder1, sellOrderBook.Orders[0])
+	require.Equal(t, limitSellOrder2, sellOrderBook.Orders[1])
+	require.Equal(t, "2000RUNE", bankKeeper.GetCoins(ctx, buyer).String())
+	require.Equal(t, "250ETH", bankKeeper.GetCoins(ctx, seller).String())
+
+	processed, filled, err := keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 200),
+		NewInt64Price("RUNE", 7), OrderOptions{ExpiresAt: expiresAt, TimeInForce: FillOrKill})
+
+	require.Nil(t, err)
+	require.True(t, processed.OpenAmount.IsZero())
+	require.Len(t, filled, 2)
+
+	// sell orderbook changed
+	sellOrderBook = keeper.getOrderBook(ctx, SellOrder, "ETH", "RUNE")
+	require.Len(t, sellOrderBook.Orders, 1)
+	require.Equal(t, sdk.NewInt64Coin("ETH", 20), sellOrderBook.Orders[0].Amount)
+
+	require.Equal(t, "250ETH,1280RUNE", bankKeeper.GetCoins(ctx, seller).String())
+	require.Equal(t, "200ETH,720RUNE", bankKeeper.GetCoins(ctx, buyer).String())
+}
+
+// Test if open orders can be cancelled by their sender only and coins are unlocked
+// Test if an order whose fill fails midway leaves balances, order books, trades and ids untouched
+func TestKeeperCreateBuyLimitOrderAtomic(t *testing.T) {
+	ctx, keeper, bankKeeper, buyer, seller, limitSellOrder1, limitSellOrder2, limitBuyOrder1, limitBuyOrder2 :=
+		setupCreateBuyLimitOrderTest()
+
+	expiresAt := time.Now().Add(time.Minute).UTC()
+
+	// the escrow account only holds the coins for the first fill, so releasing the coins of the second fill fails
+	bankKeeper.SetCoins(ctx, EscrowAddress, sdk.Coins{sdk.NewInt64Coin("ETH", 150), sdk.NewInt64Coin("RUNE", 360)})
+
+	_, _, err := keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 200), NewInt64Price("RUNE", 8),
+		OrderOptions{ExpiresAt: expiresAt, ClientOrderID: "atomic"})
+	require.NotNil(t, err)
+	require.Equal(t, sdk.CodeInsufficientCoins, err.Code())
+
+	// the first fill has been rolled back together with the failed one
+	require.Equal(t, "2000RUNE", bankKeeper.GetCoins(ctx, buyer).String())
+	require.Equal(t, "250ETH", bankKeeper.GetCoins(ctx, seller).String())
+	require.Equal(t, "150ETH,360RUNE", bankKeeper.GetCoins(ctx, EscrowAddress).String())
+	require.Equal(t, "", keeper.feeCollectionKeeper.GetCollectedFees(ctx).String())
+
+	sellOrderBook := keeper.getOrderBook(ctx, SellOrder, "ETH", "RUNE")
+	require.Equal(t, []LimitOrder{limitSellOrder1, limitSellOrder2}, sellOrderBook.Orders)
+	buyOrderBook := keeper.getOrderBook(ctx, BuyOrder, "ETH", "RUNE")
+	require.Equal(t, []LimitOrder{limitBuyOrder1, limitBuyOrder2}, buyOrderBook.Orders)
+	require.Len(t, keeper.getAllTrades(ctx), 0)
+
+	// neither the order id nor the client order id have been used up
+	_, err = keeper.getOrderIDByClientOrderID(ctx, buyer, "atomic")
+	require.Equal(t, CodeClientIDNotFound, err.Code())
+
+	bankKeeper.SetCoins(ctx, EscrowAddress, sdk.Coins{sdk.NewInt64Coin("ETH", 220), sdk.NewInt64Coin("RUNE", 360)})
+	processed, filled, err := keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 200),
+		NewInt64Price("RUNE", 8), OrderOptions{ExpiresAt: expiresAt, ClientOrderID: "atomic"})
+	require.Nil(t, err)
+	require.Equal(t, limitBuyOrder2.OrderID+1, processed.OrderID)
+	require.Len(t, filled, 2)
+	require.Equal(t, "200ETH,720RUNE", bankKeeper.GetCoins(ctx, buyer).String())
+	require.Equal(t, "250ETH,1280RUNE", bankKeeper.GetCoins(ctx, seller).String())
+}
+
+func TestKeeperCancelLimitOrder(t *testing.T) {
+	ctx, keeper, bankKeeper, buyer, seller, _, _, limitBuyOrder1, limitBuyOrder2 := setupCreateBuyLimitOrderTest()
+
+	processed, _, err := keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 200),
+		NewInt64Price("RUNE", 3), OrderOptions{ExpiresAt: time.Now().Add(time.Minute).UTC()})
+	require.Nil(t, err)
+	require.Equal(t, "1400RUNE", bankKeeper.GetCoins(ctx, buyer).String())
+
+	_, _, err = keeper.cancelLimitOrder(ctx, seller, processed.OrderID)
+	require.EqualError(t, err, ErrNotOrderOwner(keeper.codespace, processed.OrderID).Error())
+
+	cancelled, _, err := keeper.cancelLimitOrder(ctx, buyer, processed.OrderID)
+	require.Nil(t, err)
+	require.Equal(t, processed.OrderID, cancelled.OrderID)
+
+	// buy orderbook back to previous state and coins unlocked
+	buyOrderBook := keeper.getOrderBook(ctx, BuyOrder, "ETH", "RUNE")
+	require.Len(t, buyOrderBook.Orders, 2)
+	require.Equal(t, limitBuyOrder1, buyOrderBook.Orders[0])
+	require.Equal(t, limitBuyOrder2, buyOrderBook.Orders[1])
+	require.Equal(t, "2000RUNE", bankKeeper.GetCoins(ctx, buyer).String())
+
+	_, _, err = keeper.cancelLimitOrder(ctx, buyer, processed.OrderID)
+	require.EqualError(t, err, ErrOrderNotFound(keeper.codespace, processed.OrderID).Error())
+}
+
 // Test if refund of expired orders works
 func TestRefundExpiredLimitOrders(t *testing.T) {
 	ctx, keeper, bankKeeper, buyer, seller, _, limitSellOrder2, limitBuyOrder1, limitBuyOrder2 :=
 		setupCreateBuyLimitOrderTest()
 
 	// let cheaper order be expired
-	orderBook := keeper.getOrderBook(ctx, SellOrder, "ETH", "RUNE")
-	orderBook.Orders[0].ExpiresAt = time.Now().Add(-time.Minute).UTC()
-	keeper.setOrderBook(ctx, orderBook)
+	expireLimitOrder(ctx, keeper, keeper.getOrderBook(ctx, SellOrder, "ETH", "RUNE"), 0)
 
 	keeper.refundExpiredLimitOrders(ctx)
 
@@ -268,3 +501,207 @@ func TestRefundExpiredLimitOrders(t *testing.T) {
 	coinsBuyer := bankKeeper.GetCoins(ctx, buyer)
 	require.Equal(t, "2000RUNE", coinsBuyer.String())
 }
+
+// Test if at most the maximum number of expiries per block are refunded, earliest expiry first
+func TestRefundExpiredLimitOrdersCapped(t *testing.T) {
+	ctx := setupContext(exchangeKey)
+	keeper, _, bankKeeper, _, seller := setupKeepers(exchangeKey, ctx)
+	bankKeeper.SetCoins(ctx, seller, sdk.Coins{sdk.NewInt64Coin("ETH", 30)})
+	params := keeper.getParams(ctx)
+	params.MaxExpiriesPerBlock = 2
+	keeper.setParams(ctx, params)
+
+	for i := int64(0); i < 3; i++ {
+		_, _, err := keeper.processLimitOrder(ctx, seller, SellOrder, sdk.NewInt64Coin("ETH", 10), NewInt64Price("RUNE", 5+i),
+			OrderOptions{ExpiresAt: time.Now().Add(time.Minute).UTC()})
+		require.Nil(t, err)
+	}
+
+	// the order with the highest price expired first
+	orderBook := keeper.getOrderBook(ctx, SellOrder, "ETH", "RUNE")
+	for i := 2; i >= 0; i-- {
+		expireLimitOrder(ctx, keeper, orderBook, i)
+		orderBook = keeper.getOrderBook(ctx, SellOrder, "ETH", "RUNE")
+	}
+
+	keeper.refundExpiredLimitOrders(ctx)
+	orderBook = keeper.getOrderBook(ctx, SellOrder, "ETH", "RUNE")
+	require.Len(t, orderBook.Orders, 1)
+	require.Equal(t, int64(1), orderBook.Orders[0].OrderID)
+	require.Equal(t, int64(20), bankKeeper.GetCoins(ctx, seller).AmountOf("ETH").Int64())
+
+	// the rest is carried over to the next block
+	keeper.refundExpiredLimitOrders(ctx)
+	require.Len(t, keeper.getOrderBook(ctx, SellOrder, "ETH", "RUNE").Orders, 0)
+	require.Equal(t, int64(30), bankKeeper.GetCoins(ctx, seller).AmountOf("ETH").Int64())
+}
+
+// Test if orders expire by the block time rather than the wall clock
+func TestRefundExpiredLimitOrdersByBlockTime(t *testing.T) {
+	ctx := setupContext(exchangeKey)
+	keeper, _, bankKeeper, _, seller := setupKeepers(exchangeKey, ctx)
+	bankKeeper.SetCoins(ctx, seller, sdk.Coins{sdk.NewInt64Coin("ETH", 10)})
+	expiresAt := ctx.BlockHeader().Time.Add(time.Minute)
+
+	// an order that expired by the block time is rejected, even though the wall clock has not reached its expiry
+	_, _, err := keeper.processLimitOrder(ctx.WithBlockHeader(abci.Header{Time: expiresAt.Add(time.Second)}), seller,
+		SellOrder, sdk.NewInt64Coin("ETH", 10), NewInt64Price("RUNE", 5), OrderOptions{ExpiresAt: expiresAt})
+	require.EqualError(t, err, ErrOrderExpired(keeper.codespace).Error())
+
+	_, _, err = keeper.processLimitOrder(ctx, seller, SellOrder, sdk.NewInt64Coin("ETH", 10), NewInt64Price("RUNE", 5),
+		OrderOptions{ExpiresAt: expiresAt})
+	require.Nil(t, err)
+
+	keeper.refundExpiredLimitOrders(ctx)
+	require.Len(t, keeper.getOrderBook(ctx, SellOrder, "ETH", "RUNE").Orders, 1)
+
+	keeper.refundExpiredLimitOrders(ctx.WithBlockHeader(abci.Header{Time: expiresAt.Add(time.Second)}))
+	require.Len(t, keeper.getOrderBook(ctx, SellOrder, "ETH", "RUNE").Orders, 0)
+	require.Equal(t, "10ETH", bankKeeper.GetCoins(ctx, seller).String())
+}
+
+// Test if expired orders left over by the cap on expiries per block are refunded instead of being filled
+func TestKeeperFillSkipsExpiredLimitOrders(t *testing.T) {
+	ctx := setupContext(exchangeKey).WithBlockHeight(10)
+	keeper, _, bankKeeper, buyer, seller := setupKeepers(exchangeKey, ctx)
+	bankKeeper.SetCoins(ctx, buyer, sdk.Coins{sdk.NewInt64Coin("RUNE", 100)})
+	bankKeeper.SetCoins(ctx, seller, sdk.Coins{sdk.NewInt64Coin("ETH", 30)})
+	params := keeper.getParams(ctx)
+	params.MaxExpiriesPerBlock = 2
+	keeper.setParams(ctx, params)
+
+	for i := int64(0); i < 3; i++ {
+		_, _, err := keeper.processLimitOrder(ctx, seller, SellOrder, sdk.NewInt64Coin("ETH", 10), NewInt64Price("RUNE", 5),
+			OrderOptions{ExpiresAtHeight: 12})
+		require.Nil(t, err)
+	}
+
+	ctx = ctx.WithBlockHeight(12)
+	keeper.refundExpiredLimitOrders(ctx)
+	orderBook := keeper.getOrderBook(ctx, SellOrder, "ETH", "RUNE")
+	require.Len(t, orderBook.Orders, 1)
+	leftover := orderBook.Orders[0]
+
+	// the leftover does not count as fillable
+	_, _, err := keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 10), NewInt64Price("RUNE", 5),
+		OrderOptions{ExpiresAtHeight: 20, TimeInForce: FillOrKill})
+	require.EqualError(t, err, ErrOrderNotFillable(keeper.codespace).Error())
+
+	// a taker hitting the leftover does not fill it, the leftover is refunded instead
+	processed, filled, err := keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 10),
+		NewInt64Price("RUNE", 5), OrderOptions{ExpiresAtHeight: 20})
+	require.Nil(t, err)
+	require.Len(t, filled, 0)
+	require.Equal(t, sdk.NewInt64Coin("ETH", 10), processed.OpenAmount)
+
+	require.Len(t, keeper.getOrderBook(ctx, SellOrder, "ETH", "RUNE").Orders, 0)
+	require.Equal(t, "30ETH", bankKeeper.GetCoins(ctx, seller).String())
+	require.Equal(t, "50RUNE", bankKeeper.GetCoins(ctx, buyer).String())
+	_, _, err = keeper.findLimitOrder(ctx, leftover.OrderID)
+	require.EqualError(t, err, ErrOrderNotFound(keeper.codespace, leftover.OrderID).Error())
+
+	// its expiry index entry is gone as well, nothing is left for the next block
+	keeper.refundExpiredLimitOrders(ctx.WithBlockHeight(13))
+	require.Equal(t, "30ETH", bankKeeper.GetCoins(ctx, seller).String())
+}
+
+// Test if orders expire at their expiry height and good-till-cancelled orders do not expire
+func TestRefundExpiredLimitOrdersAtHeight(t *testing.T) {
+	ctx := setupContext(exchangeKey).WithBlockHeight(10)
+	keeper, _, bankKeeper, _, seller := setupKeepers(exchangeKey, ctx)
+	bankKeeper.SetCoins(ctx, seller, sdk.Coins{sdk.NewInt64Coin("ETH", 30)})
+
+	_, _, err := keeper.processLimitOrder(ctx, seller, SellOrder, sdk.NewInt64Coin("ETH", 10), NewInt64Price("RUNE", 5),
+		OrderOptions{ExpiresAtHeight: 10})
+	require.EqualError(t, err, ErrOrderExpired(keeper.codespace).Error())
+
+	_, _, err = keeper.processLimitOrder(ctx, seller, SellOrder, sdk.NewInt64Coin("ETH", 10), NewInt64Price("RUNE", 5),
+		OrderOptions{})
+	require.Equal(t, CodeInvalidExpiry, err.Code())
+
+	_, _, err = keeper.processLimitOrder(ctx, seller, SellOrder, sdk.NewInt64Coin("ETH", 10), NewInt64Price("RUNE", 5),
+		OrderOptions{ExpiresAtHeight: 12, TimeInForce: GoodTillCancelled})
+	require.Equal(t, CodeInvalidExpiry, err.Code())
+
+	atHeight, _, err := keeper.processLimitOrder(ctx, seller, SellOrder, sdk.NewInt64Coin("ETH", 10),
+		NewInt64Price("RUNE", 5), OrderOptions{ExpiresAtHeight: 12})
+	require.Nil(t, err)
+	gtc, _, err := keeper.processLimitOrder(ctx, seller, SellOrder, sdk.NewInt64Coin("ETH", 10), NewInt64Price("RUNE", 6),
+		OrderOptions{TimeInForce: GoodTillCancelled})
+	require.Nil(t, err)
+	require.Equal(t, "10ETH", bankKeeper.GetCoins(ctx, seller).String())
+
+	// not expired before its height
+	keeper.refundExpiredLimitOrders(ctx.WithBlockHeight(11))
+	require.Len(t, keeper.getOrderBook(ctx, SellOrder, "ETH", "RUNE").Orders, 2)
+
+	keeper.refundExpiredLimitOrders(ctx.WithBlockHeight(12))
+	orderBook := keeper.getOrderBook(ctx, SellOrder, "ETH", "RUNE")
+	require.Len(t, orderBook.Orders, 1)
+	require.Equal(t, gtc.OrderID, orderBook.Orders[0].OrderID)
+	require.Equal(t, "20ETH", bankKeeper.GetCoins(ctx, seller).String())
+
+	_, _, err = keeper.findLimitOrder(ctx, atHeight.OrderID)
+	require.EqualError(t, err, ErrOrderNotFound(keeper.codespace, atHeight.OrderID).Error())
+
+	// good-till-cancelled orders stay until they are cancelled
+	keeper.refundExpiredLimitOrders(ctx.WithBlockHeight(1000000))
+	require.Len(t, keeper.getOrderBook(ctx, SellOrder, "ETH", "RUNE").Orders, 1)
+	_, _, err = keeper.cancelLimitOrder(ctx, seller, gtc.OrderID)
+	require.Nil(t, err)
+	require.Equal(t, "30ETH", bankKeeper.GetCoins(ctx, seller).String())
+}
+
+// Test if open orders of a sender are indexed across all token pairs
+func TestKeeperGetOpenLimitOrdersBySender(t *testing.T) {
+	ctx := setupContext(exchangeKey)
+	keeper, _, bankKeeper, buyer, seller := setupKeepers(exchangeKey, ctx)
+	bankKeeper.SetCoins(ctx, buyer, sdk.Coins{sdk.NewInt64Coin("RUNE", 2000)})
+	bankKeeper.SetCoins(ctx, seller, sdk.Coins{sdk.NewInt64Coin("ETH", 250)})
+
+	expiresAt := time.Now().Add(time.Minute).UTC()
+
+	processed1, _, err := keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 10),
+		NewInt64Price("RUNE", 5), OrderOptions{ExpiresAt: expiresAt})
+	require.Nil(t, err)
+	processed2, _, err := keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("BTC", 20),
+		NewInt64Price("RUNE", 3), OrderOptions{ExpiresAt: expiresAt})
+	require.Nil(t, err)
+
+	// fill first buy order partially
+	_, _, err = keeper.processLimitOrder(ctx, seller, SellOrder, sdk.NewInt64Coin("ETH", 4), NewInt64Price("RUNE", 5),
+		OrderOptions{ExpiresAt: expiresAt})
+	require.Nil(t, err)
+
+	openOrders := keeper.getOpenLimitOrdersBySender(ctx, buyer)
+	require.Len(t, openOrders, 2)
+	require.Equal(t, processed1.OrderID, openOrders[0].Order.OrderID)
+	require.Equal(t, sdk.NewInt64Coin("ETH", 6), openOrders[0].Order.Amount)
+	require.Equal(t, sdk.NewInt64Coin("RUNE", 30), openOrders[0].LockedCoins)
+	require.Equal(t, processed2.OrderID, openOrders[1].Order.OrderID)
+	require.Equal(t, sdk.NewInt64Coin("BTC", 20), openOrders[1].Order.Amount)
+	require.Equal(t, sdk.NewInt64Coin("RUNE", 60), openOrders[1].LockedCoins)
+	require.Empty(t, keeper.getOpenLimitOrdersBySender(ctx, seller))
+
+	// cancelled orders are removed from the index
+	_, _, err = keeper.cancelLimitOrder(ctx, buyer, processed2.OrderID)
+	require.Nil(t, err)
+	openOrders = keeper.getOpenLimitOrdersBySender(ctx, buyer)
+	require.Len(t, openOrders, 1)
+	require.Equal(t, processed1.OrderID, openOrders[0].Order.OrderID)
+
+	// filled orders are removed from the index, an unfilled part of the incoming order is added
+	processed3, _, err := keeper.processLimitOrder(ctx, seller, SellOrder, sdk.NewInt64Coin("ETH", 8),
+		NewInt64Price("RUNE", 5), OrderOptions{ExpiresAt: expiresAt})
+	require.Nil(t, err)
+	require.Empty(t, keeper.getOpenLimitOrdersBySender(ctx, buyer))
+	openOrders = keeper.getOpenLimitOrdersBySender(ctx, seller)
+	require.Len(t, openOrders, 1)
+	require.Equal(t, processed3.OrderID, openOrders[0].Order.OrderID)
+	require.Equal(t, sdk.NewInt64Coin("ETH", 2), openOrders[0].LockedCoins)
+
+	// expired orders are removed from the index
+	expireLimitOrder(ctx, keeper, keeper.getOrderBook(ctx, SellOrder, "ETH", "RUNE"), 0)
+	keeper.refundExpiredLimitOrders(ctx)
+	require.Empty(t, keeper.getOpenLimitOrdersBySender(ctx, seller))
+}
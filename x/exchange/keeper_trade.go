@@ -0,0 +1,148 @@
+package exchange
+
+import (
+	"bytes"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// getNextTradeID returns the next available trade id without incrementing it. Trade ids start at 1
+func (k Keeper) getNextTradeID(ctx sdk.Context) (tradeID int64) {
+	store := ctx.KVStore(k.storeKey)
+	bz := store.Get(KeyNextTradeID)
+	if bz == nil {
+		return 1
+	}
+	k.cdc.MustUnmarshalBinary(bz, &tradeID)
+	return tradeID
+}
+
+func (k Keeper) setNextTradeID(ctx sdk.Context, tradeID int64) {
+	store := ctx.KVStore(k.storeKey)
+	store.Set(KeyNextTradeID, k.cdc.MustMarshalBinary(tradeID))
+}
+
+// getNewTradeID returns the next available trade id and increments it
+func (k Keeper) getNewTradeID(ctx sdk.Context) int64 {
+	tradeID := k.getNextTradeID(ctx)
+	k.setNextTradeID(ctx, tradeID+1)
+	return tradeID
+}
+
+// storeTrade persists a fill between a maker and a taker order, indexes it for the token pair and both accounts and
+// updates the candles of the token pair
+func (k Keeper) storeTrade(ctx sdk.Context, maker LimitOrder, takerOrderID int64, takerClientOrderID string,
+	taker sdk.AccAddress, takerKind OrderKind, amount sdk.Coin, price Price, makerFee sdk.Coin, takerFee sdk.Coin,
+) Trade {
+	trade := NewTrade(k.getNewTradeID(ctx), maker.OrderID, takerOrderID, maker.Sender, taker, takerKind, amount,
+		price, makerFee, takerFee, ctx.BlockHeight(), ctx.BlockHeader().Time)
+	trade.MakerClientOrderID = maker.ClientOrderID
+	trade.TakerClientOrderID = takerClientOrderID
+
+	k.setTrade(ctx, trade)
+	k.updateCandles(ctx, trade.Timestamp, amount, price)
+
+	return trade
+}
+
+// setTrade saves a new trade and its index entries and marks its market as traded for the trailing stops. Trades must
+// be set in the order of their ids
+func (k Keeper) setTrade(ctx sdk.Context, trade Trade) {
+	store := ctx.KVStore(k.storeKey)
+	tradeKey := MakeKeyTrade(trade.TradeID)
+	store.Set(tradeKey, k.cdc.MustMarshalBinary(trade))
+	store.Set(MakeKeyTradeByPair(trade.Amount.Denom, trade.Price.Denom, trade.TradeID), tradeKey)
+	store.Set(MakeKeyTradeByAccount(trade.Maker, trade.TradeID), tradeKey)
+	store.Set(MakeKeyTradeByAccount(trade.Taker, trade.TradeID), tradeKey)
+	store.Set(MakeKeyTradedMarket(trade.Amount.Denom, trade.Price.Denom),
+		k.cdc.MustMarshalBinary([]string{trade.Amount.Denom, trade.Price.Denom}))
+
+	k.appendTradeSeq(ctx, MakeKeyTradesByPairSubspace(trade.Amount.Denom, trade.Price.Denom), tradeKey)
+	k.appendTradeSeq(ctx, MakeKeyTradesByAccountSubspace(trade.Maker), tradeKey)
+	if !bytes.Equal(trade.Maker, trade.Taker) {
+		k.appendTradeSeq(ctx, MakeKeyTradesByAccountSubspace(trade.Taker), tradeKey)
+	}
+}
+
+// getTradeCount returns the number of trades in the trade index with the given prefix
+func (k Keeper) getTradeCount(ctx sdk.Context, indexSubspace []byte) (count int64) {
+	store := ctx.KVStore(k.storeKey)
+	bz := store.Get(MakeKeyTradeCount(indexSubspace))
+	if bz == nil {
+		return 0
+	}
+	k.cdc.MustUnmarshalBinary(bz, &count)
+	return count
+}
+
+// appendTradeSeq numbers a trade with the next sequence number of the trade index with the given prefix
+func (k Keeper) appendTradeSeq(ctx sdk.Context, indexSubspace []byte, tradeKey []byte) {
+	seq := k.getTradeCount(ctx, indexSubspace) + 1
+	store := ctx.KVStore(k.storeKey)
+	store.Set(MakeKeyTradeBySeq(indexSubspace, seq), tradeKey)
+	store.Set(MakeKeyTradeCount(indexSubspace), k.cdc.MustMarshalBinary(seq))
+}
+
+// getAllTrades returns all trades, sorted by trade id
+func (k Keeper) getAllTrades(ctx sdk.Context) []Trade {
+	trades := make([]Trade, 0)
+
+	store := ctx.KVStore(k.storeKey)
+	iter := sdk.KVStorePrefixIterator(store, tradeSubspace)
+	defer iter.Close()
+
+	for ; iter.Valid(); iter.Next() {
+		var trade Trade
+		k.cdc.MustUnmarshalBinary(iter.Value(), &trade)
+		trades = append(trades, trade)
+	}
+
+	return trades
+}
+
+// getTrade returns the trade with the given id and whether it exists
+func (k Keeper) getTrade(ctx sdk.Context, tradeID int64) (Trade, bool) {
+	store := ctx.KVStore(k.storeKey)
+	bz := store.Get(MakeKeyTrade(tradeID))
+	if bz == nil {
+		return Trade{}, false
+	}
+
+	var trade Trade
+	k.cdc.MustUnmarshalBinary(bz, &trade)
+	return trade, true
+}
+
+// getTradesByPair returns a page (starting at 1) of the trades of a token pair, newest first
+func (k Keeper) getTradesByPair(ctx sdk.Context, amountDenom string, priceDenom string, page int, limit int,
+) []Trade {
+	return k.getTradesPage(ctx, MakeKeyTradesByPairSubspace(amountDenom, priceDenom), page, limit)
+}
+
+// getTradesByAccount returns a page (starting at 1) of the trades an account took part in, newest first
+func (k Keeper) getTradesByAccount(ctx sdk.Context, addr sdk.AccAddress, page int, limit int) []Trade {
+	return k.getTradesPage(ctx, MakeKeyTradesByAccountSubspace(addr), page, limit)
+}
+
+func (k Keeper) getTradesPage(ctx sdk.Context, subspace []byte, page int, limit int) []Trade {
+	trades := make([]Trade, 0)
+	if page < 1 || limit < 1 {
+		return trades
+	}
+
+	store := ctx.KVStore(k.storeKey)
+	iter := sdk.KVStoreReversePrefixIterator(store, subspace)
+	defer iter.Close()
+
+	for skip := (page - 1) * limit; iter.Valid() && skip > 0; iter.Next() {
+		skip--
+	}
+
+	for ; iter.Valid() && len(trades) < limit; iter.Next() {
+		var trade Trade
+		k.cdc.MustUnmarshalBinary(store.Get(iter.Value()), &trade)
+		trades = append(trades, trade)
+	}
+
+	return trades
+}
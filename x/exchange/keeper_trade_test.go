@@ -0,0 +1,80 @@
+package exchange
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/tendermint/abci/types"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+func TestKeeperStoreTrades(t *testing.T) {
+	ctx, keeper, _, buyer, seller, limitSellOrder1, limitSellOrder2, limitBuyOrder1, _ :=
+		setupCreateBuyLimitOrderTest()
+	ctx = ctx.WithBlockHeight(5).WithBlockHeader(abci.Header{Time: time.Unix(1540000000, 0).UTC()})
+
+	expiresAt := time.Now().Add(time.Minute).UTC()
+
+	// buy order filled by both sell orders => 2 trades
+	processedBuy, _, err := keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 200),
+		NewInt64Price("RUNE", 8), OrderOptions{ExpiresAt: expiresAt})
+	require.Nil(t, err)
+
+	// sell order filled by the first buy order => 1 trade
+	processedSell, _, err := keeper.processLimitOrder(ctx, seller, SellOrder, sdk.NewInt64Coin("ETH", 30),
+		NewInt64Price("RUNE", 4), OrderOptions{ExpiresAt: expiresAt})
+	require.Nil(t, err)
+
+	trade1, ok := keeper.getTrade(ctx, 1)
+	require.True(t, ok)
+	require.Equal(t, NewTrade(1, limitSellOrder1.OrderID, processedBuy.OrderID, seller, buyer, BuyOrder,
+		sdk.NewInt64Coin("ETH", 120), NewInt64Price("RUNE", 6), sdk.NewInt64Coin("RUNE", 0),
+		sdk.NewInt64Coin("ETH", 0), 5, ctx.BlockHeader().Time), trade1)
+
+	trade2, ok := keeper.getTrade(ctx, 2)
+	require.True(t, ok)
+	require.Equal(t, NewTrade(2, limitSellOrder2.OrderID, processedBuy.OrderID, seller, buyer, BuyOrder,
+		sdk.NewInt64Coin("ETH", 80), NewInt64Price("RUNE", 7), sdk.NewInt64Coin("RUNE", 0),
+		sdk.NewInt64Coin("ETH", 0), 5, ctx.BlockHeader().Time), trade2)
+
+	trade3, ok := keeper.getTrade(ctx, 3)
+	require.True(t, ok)
+	require.Equal(t, NewTrade(3, limitBuyOrder1.OrderID, processedSell.OrderID, buyer, seller, SellOrder,
+		sdk.NewInt64Coin("ETH", 30), NewInt64Price("RUNE", 4), sdk.NewInt64Coin("ETH", 0),
+		sdk.NewInt64Coin("RUNE", 0), 5, ctx.BlockHeader().Time), trade3)
+
+	_, ok = keeper.getTrade(ctx, 4)
+	require.False(t, ok)
+
+	// trades of the pair are paginated, newest first
+	require.Equal(t, []Trade{trade3, trade2}, keeper.getTradesByPair(ctx, "ETH", "RUNE", 1, 2))
+	require.Equal(t, []Trade{trade1}, keeper.getTradesByPair(ctx, "ETH", "RUNE", 2, 2))
+	require.Empty(t, keeper.getTradesByPair(ctx, "ETH", "RUNE", 3, 2))
+	require.Empty(t, keeper.getTradesByPair(ctx, "ETH", "RUNE", 0, 2))
+	require.Empty(t, keeper.getTradesByPair(ctx, "RUNE", "ETH", 1, 10))
+
+	// both accounts took part in all trades
+	require.Equal(t, []Trade{trade3, trade2, trade1}, keeper.getTradesByAccount(ctx, buyer, 1, 10))
+	require.Equal(t, []Trade{trade2}, keeper.getTradesByAccount(ctx, seller, 2, 1))
+	require.Empty(t, keeper.getTradesByAccount(ctx, sdk.AccAddress([]byte("otherAddress")), 1, 10))
+
+	// the trades of every index are numbered in the order of their ids
+	pair := MakeKeyTradesByPairSubspace("ETH", "RUNE")
+	require.Equal(t, int64(3), keeper.getTradeCount(ctx, pair))
+	require.Equal(t, int64(3), keeper.getTradeCount(ctx, MakeKeyTradesByAccountSubspace(seller)))
+	store := ctx.KVStore(keeper.storeKey)
+	for seq := int64(1); seq <= 3; seq++ {
+		require.Equal(t, MakeKeyTrade(seq), store.Get(MakeKeyTradeBySeq(pair, seq)))
+	}
+}
+
+func TestGetTradePageSeqs(t *testing.T) {
+	require.Equal(t, []int64{3, 2}, GetTradePageSeqs(3, 1, 2))
+	require.Equal(t, []int64{1}, GetTradePageSeqs(3, 2, 2))
+	require.Empty(t, GetTradePageSeqs(3, 3, 2))
+	require.Empty(t, GetTradePageSeqs(3, 0, 2))
+	require.Empty(t, GetTradePageSeqs(3, 1, 0))
+	require.Empty(t, GetTradePageSeqs(0, 1, 2))
+}
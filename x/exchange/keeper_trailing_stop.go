@@ -0,0 +1,418 @@
+package exchange
+
+import (
+	"bytes"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// createTrailingStop stores a trailing stop of the sender, which starts to trail the last trade of its market. Sell
+// stops lock their amount and all stops lock the order deposit, the price of a buy stop is only needed once it is
+// triggered. Stops count towards the open order limits of the sender
+func (k Keeper) createTrailingStop(ctx sdk.Context, sender sdk.AccAddress, kind OrderKind, amount sdk.Coin,
+	priceDenom string, trailingOffset sdk.Rat, trailingRate int64, market bool, limitOffset sdk.Rat) (TrailingStop,
+	sdk.Error) {
+	err := checkTrailingStop(k.codespace, kind, amount, priceDenom, trailingOffset, trailingRate, market, limitOffset)
+	if err != nil {
+		return TrailingStop{}, err
+	}
+
+	// error if the market is not listed, is halted or the amount violates the lot size of the market
+	m := k.getMarket(ctx, amount.Denom, priceDenom)
+	if !m.IsListed() {
+		return TrailingStop{}, ErrMarketNotListed(k.codespace, amount.Denom, priceDenom)
+	}
+	if m.Halted {
+		return TrailingStop{}, ErrMarketHalted(k.codespace, amount.Denom, priceDenom)
+	}
+	if !amount.Amount.Mod(m.LotSize).IsZero() {
+		return TrailingStop{}, ErrInvalidLotSize(k.codespace, m.LotSize)
+	}
+
+	lastTrade, ok := k.getLastTrade(ctx, amount.Denom, priceDenom)
+	if !ok {
+		return TrailingStop{}, ErrInvalidTrailingStop(k.codespace, "market has no last trade price to trail yet")
+	}
+
+	stop := NewTrailingStop(0, sender, kind, amount, priceDenom, trailingOffset, trailingRate, market, limitOffset,
+		lastTrade)
+	if !stop.getTriggerPrice().IsPositive() {
+		return TrailingStop{}, ErrInvalidTrailingStop(k.codespace, "trailing offset must be below the last price")
+	}
+
+	err = k.checkOpenOrderLimits(ctx, sender, amount.Denom, priceDenom)
+	if err != nil {
+		return TrailingStop{}, err
+	}
+
+	stop.StopID, err = k.getNewOrderID(ctx)
+	if err != nil {
+		return TrailingStop{}, err
+	}
+
+	err = k.lockTrailingStop(ctx, &stop)
+	if err != nil {
+		return TrailingStop{}, err
+	}
+
+	k.setTrailingStop(ctx, stop)
+	k.addOpenOrderCount(ctx, sender, amount.Denom, priceDenom, 1)
+
+	return stop, nil
+}
+
+// lockTrailingStop locks the amount of a sell stop and the order deposit in the escrow account
+func (k Keeper) lockTrailingStop(ctx sdk.Context, stop *TrailingStop) sdk.Error {
+	locked := sdk.Coins{}
+	if stop.Kind == SellOrder {
+		locked = sdk.Coins{stop.Amount}
+	}
+
+	for _, coin := range locked {
+		err := k.lockCoins(ctx, stop.Sender, coin)
+		if err != nil {
+			return err
+		}
+	}
+
+	deposit := k.OrderDeposit(ctx)
+	if deposit.IsPositive() {
+		err := k.lockCoins(ctx, stop.Sender, deposit)
+		if err != nil {
+			return ErrInsufficientOrderDeposit(k.codespace, deposit)
+		}
+		locked = locked.Plus(sdk.Coins{deposit})
+	}
+
+	stop.Locked = locked
+	return nil
+}
+
+// cancelTrailingStop removes a trailing stop of the sender that has not been submitted yet and refunds its locked
+// coins
+func (k Keeper) cancelTrailingStop(ctx sdk.Context, sender sdk.AccAddress, stopID int64) (TrailingStop, sdk.Error) {
+	stop, ok := k.getTrailingStop(ctx, stopID)
+	if !ok {
+		return TrailingStop{}, ErrTrailingStopNotFound(k.codespace, stopID)
+	}
+
+	if !bytes.Equal(stop.Sender, sender) {
+		return TrailingStop{}, ErrNotOrderOwner(k.codespace, stopID)
+	}
+
+	err := k.removeTrailingStop(ctx, stop)
+	if err != nil {
+		return TrailingStop{}, err
+	}
+
+	return stop, nil
+}
+
+// processTrailingStops updates the trailing stops of the markets that traded since the last update with their new
+// trades and submits the stops that are triggered. The trades of the submitted orders are only seen by the stops in
+// the next block. At most MaxTriggersPerBlock stops are submitted per block, stops triggered beyond that are submitted
+// in the next blocks before any newly triggered ones, lowest stop id first. The stops of a halted market wait until
+// the market is resumed
+func (k Keeper) processTrailingStops(ctx sdk.Context) []TriggeredTrailingStop {
+	results := make([]TriggeredTrailingStop, 0)
+	maxTriggers := k.MaxTriggersPerBlock(ctx)
+
+	for _, stop := range k.getTriggeredTrailingStops(ctx) {
+		if int64(len(results)) >= maxTriggers {
+			break
+		}
+		if k.getMarket(ctx, stop.Amount.Denom, stop.PriceDenom).Halted {
+			continue
+		}
+		results = append(results, k.submitTrailingStop(ctx, stop))
+	}
+
+	for _, market := range k.getTradedMarkets(ctx) {
+		if market.Halted {
+			continue
+		}
+		amountDenom, priceDenom := market.AmountDenom, market.PriceDenom
+		k.removeTradedMarket(ctx, amountDenom, priceDenom)
+
+		// markets without stops only remember their last trade, so that old trades are not read again
+		stops := k.getTrailingStopsByMarket(ctx, amountDenom, priceDenom)
+		if len(stops) == 0 {
+			if lastTrade, ok := k.getLastTrade(ctx, amountDenom, priceDenom); ok {
+				k.setTrailingStopTradeID(ctx, amountDenom, priceDenom, lastTrade.TradeID)
+			}
+			continue
+		}
+
+		trades := k.getTradesByPairAfter(ctx, amountDenom, priceDenom,
+			k.getTrailingStopTradeID(ctx, amountDenom, priceDenom))
+		if len(trades) == 0 {
+			continue
+		}
+		k.setTrailingStopTradeID(ctx, amountDenom, priceDenom, trades[len(trades)-1].TradeID)
+
+		for _, stop := range stops {
+			if stop.Triggered {
+				continue
+			}
+
+			triggered := false
+			for _, trade := range trades {
+				// trades before the stop has been created do not count
+				if trade.TradeID <= stop.LastTradeID {
+					continue
+				}
+				if stop.update(trade) {
+					triggered = true
+					break
+				}
+			}
+
+			if !triggered {
+				k.setTrailingStop(ctx, stop)
+				continue
+			}
+
+			if int64(len(results)) >= maxTriggers {
+				stop.Triggered = true
+				k.setTrailingStop(ctx, stop)
+				continue
+			}
+
+			results = append(results, k.submitTrailingStop(ctx, stop))
+		}
+	}
+
+	return results
+}
+
+// submitTrailingStop removes a triggered stop, refunds its locked coins and submits its order. A limit stop is
+// submitted as good-till-cancelled order at its limit price, a market stop as immediate-or-cancel order at the price
+// of the order book that fills its amount. An order that fails does not change any state but the removal of the stop
+func (k Keeper) submitTrailingStop(ctx sdk.Context, stop TrailingStop) TriggeredTrailingStop {
+	trigger := stop.getTriggerPrice()
+	result := TriggeredTrailingStop{Stop: stop, TriggerPrice: trigger}
+
+	err := k.removeTrailingStop(ctx, stop)
+	if err != nil {
+		result.Code = err.ABCICode()
+		result.Log = err.ABCILog()
+		return result
+	}
+
+	cacheCtx, writeCache := ctx.CacheContext()
+
+	var processed ProcessedLimitOrder
+	var filled []FilledLimitOrder
+	if stop.Market {
+		var price Price
+		price, err = k.getMarketOrderPrice(cacheCtx, stop.Kind, stop.Amount, stop.PriceDenom)
+		if err == nil {
+			processed, filled, err = k.processLimitOrder(cacheCtx, stop.Sender, stop.Kind, stop.Amount, price,
+				OrderOptions{ExpiresAtHeight: ctx.BlockHeight() + 1, TimeInForce: ImmediateOrCancel})
+		}
+	} else {
+		price := stop.getLimitPrice(trigger, k.getMarket(ctx, stop.Amount.Denom, stop.PriceDenom).TickSize)
+		processed, filled, err = k.processLimitOrder(cacheCtx, stop.Sender, stop.Kind, stop.Amount, price,
+			OrderOptions{TimeInForce: GoodTillCancelled})
+	}
+
+	if err != nil {
+		result.Code = err.ABCICode()
+		result.Log = err.ABCILog()
+		return result
+	}
+
+	writeCache()
+	result.Processed = &processed
+	result.Filled = filled
+	return result
+}
+
+// getMarketOrderPrice returns the price of the stored order at which the opposite order book can fill the amount, or
+// the price of its last order if the order book is too small. Hidden iceberg reserves count, as they are filled at the
+// same price, expired orders do not. An order at that price fills as much as possible
+func (k Keeper) getMarketOrderPrice(ctx sdk.Context, kind OrderKind, amount sdk.Coin, priceDenom string) (Price,
+	sdk.Error) {
+	matchingKind := SellOrder
+	if kind == SellOrder {
+		matchingKind = BuyOrder
+	}
+	orderBook := k.getOrderBook(ctx, matchingKind, amount.Denom, priceDenom)
+
+	var last *LimitOrder
+	remaining := amount.Amount
+	for i := range orderBook.Orders {
+		order := &orderBook.Orders[i]
+		if isExpired(order.ExpiresAt, order.ExpiresAtHeight, ctx.BlockHeader().Time, ctx.BlockHeight()) {
+			continue
+		}
+
+		last = order
+		remaining = remaining.Sub(order.getTotalAmount().Amount)
+		if remaining.Sign() <= 0 {
+			return order.Price, nil
+		}
+	}
+
+	if last == nil {
+		return Price{}, ErrNoLiquidity(k.codespace, amount.Denom, priceDenom)
+	}
+	return last.Price, nil
+}
+
+// getLastTrade returns the newest trade of a market and whether there is one
+func (k Keeper) getLastTrade(ctx sdk.Context, amountDenom string, priceDenom string) (Trade, bool) {
+	trades := k.getTradesByPair(ctx, amountDenom, priceDenom, 1, 1)
+	if len(trades) == 0 {
+		return Trade{}, false
+	}
+	return trades[0], true
+}
+
+// getTradesByPairAfter returns the trades of a token pair after the given trade id, oldest first
+func (k Keeper) getTradesByPairAfter(ctx sdk.Context, amountDenom string, priceDenom string, tradeID int64) []Trade {
+	trades := make([]Trade, 0)
+
+	store := ctx.KVStore(k.storeKey)
+	iter := store.Iterator(MakeKeyTradeByPair(amountDenom, priceDenom, tradeID+1),
+		sdk.PrefixEndBytes(MakeKeyTradesByPairSubspace(amountDenom, priceDenom)))
+	defer iter.Close()
+
+	for ; iter.Valid(); iter.Next() {
+		var trade Trade
+		k.cdc.MustUnmarshalBinary(store.Get(iter.Value()), &trade)
+		trades = append(trades, trade)
+	}
+
+	return trades
+}
+
+// getTrailingStopTradeID returns the id of the last trade of a market the trailing stops have been updated with
+func (k Keeper) getTrailingStopTradeID(ctx sdk.Context, amountDenom string, priceDenom string) (tradeID int64) {
+	store := ctx.KVStore(k.storeKey)
+	bz := store.Get(MakeKeyTrailingStopTradeID(amountDenom, priceDenom))
+	if bz == nil {
+		return 0
+	}
+	k.cdc.MustUnmarshalBinary(bz, &tradeID)
+	return tradeID
+}
+
+func (k Keeper) setTrailingStopTradeID(ctx sdk.Context, amountDenom string, priceDenom string, tradeID int64) {
+	store := ctx.KVStore(k.storeKey)
+	store.Set(MakeKeyTrailingStopTradeID(amountDenom, priceDenom), k.cdc.MustMarshalBinary(tradeID))
+}
+
+// getTradedMarkets returns the markets that traded since their trailing stops have been updated last, sorted by key
+func (k Keeper) getTradedMarkets(ctx sdk.Context) []Market {
+	markets := make([]Market, 0)
+
+	store := ctx.KVStore(k.storeKey)
+	iter := sdk.KVStorePrefixIterator(store, tradedMarketSubspace)
+	defer iter.Close()
+
+	for ; iter.Valid(); iter.Next() {
+		var denoms []string
+		k.cdc.MustUnmarshalBinary(iter.Value(), &denoms)
+		markets = append(markets, k.getMarket(ctx, denoms[0], denoms[1]))
+	}
+
+	return markets
+}
+
+func (k Keeper) removeTradedMarket(ctx sdk.Context, amountDenom string, priceDenom string) {
+	store := ctx.KVStore(k.storeKey)
+	store.Delete(MakeKeyTradedMarket(amountDenom, priceDenom))
+}
+
+// getTrailingStop returns the trailing stop with the given id and whether it exists
+func (k Keeper) getTrailingStop(ctx sdk.Context, stopID int64) (TrailingStop, bool) {
+	store := ctx.KVStore(k.storeKey)
+	bz := store.Get(MakeKeyTrailingStop(stopID))
+	if bz == nil {
+		return TrailingStop{}, false
+	}
+
+	var stop TrailingStop
+	k.cdc.MustUnmarshalBinary(bz, &stop)
+	return stop, true
+}
+
+// setTrailingStop saves a trailing stop and its index entries
+func (k Keeper) setTrailingStop(ctx sdk.Context, stop TrailingStop) {
+	store := ctx.KVStore(k.storeKey)
+	stopKey := MakeKeyTrailingStop(stop.StopID)
+	store.Set(stopKey, k.cdc.MustMarshalBinary(stop))
+	store.Set(MakeKeyTrailingStopByMarket(stop.Amount.Denom, stop.PriceDenom, stop.StopID), stopKey)
+	store.Set(MakeKeyTrailingStopBySender(stop.Sender, stop.StopID), stopKey)
+	if stop.Triggered {
+		store.Set(MakeKeyTriggeredTrailingStop(stop.StopID), stopKey)
+	}
+}
+
+// removeTrailingStop deletes a trailing stop and its index entries, refunds its locked coins and no longer counts it
+// as open order
+func (k Keeper) removeTrailingStop(ctx sdk.Context, stop TrailingStop) sdk.Error {
+	for _, coin := range stop.Locked {
+		err := k.releaseCoins(ctx, stop.Sender, coin)
+		if err != nil {
+			return err
+		}
+	}
+
+	store := ctx.KVStore(k.storeKey)
+	store.Delete(MakeKeyTrailingStop(stop.StopID))
+	store.Delete(MakeKeyTrailingStopByMarket(stop.Amount.Denom, stop.PriceDenom, stop.StopID))
+	store.Delete(MakeKeyTrailingStopBySender(stop.Sender, stop.StopID))
+	store.Delete(MakeKeyTriggeredTrailingStop(stop.StopID))
+	k.addOpenOrderCount(ctx, stop.Sender, stop.Amount.Denom, stop.PriceDenom, -1)
+	return nil
+}
+
+// getTrailingStopsByMarket returns the trailing stops of a market, sorted by stop id
+func (k Keeper) getTrailingStopsByMarket(ctx sdk.Context, amountDenom string, priceDenom string) []TrailingStop {
+	return k.getTrailingStopsByIndex(ctx, MakeKeyTrailingStopsByMarketSubspace(amountDenom, priceDenom))
+}
+
+// getTrailingStopsBySender returns the trailing stops of a sender, sorted by stop id
+func (k Keeper) getTrailingStopsBySender(ctx sdk.Context, sender sdk.AccAddress) []TrailingStop {
+	return k.getTrailingStopsByIndex(ctx, MakeKeyTrailingStopsBySenderSubspace(sender))
+}
+
+// getTriggeredTrailingStops returns the triggered trailing stops that wait for their submission, sorted by stop id
+func (k Keeper) getTriggeredTrailingStops(ctx sdk.Context) []TrailingStop {
+	return k.getTrailingStopsByIndex(ctx, triggeredTrailingStopSubspace)
+}
+
+func (k Keeper) getTrailingStopsByIndex(ctx sdk.Context, subspace []byte) []TrailingStop {
+	stops := make([]TrailingStop, 0)
+
+	store := ctx.KVStore(k.storeKey)
+	iter := sdk.KVStorePrefixIterator(store, subspace)
+	defer iter.Close()
+
+	for ; iter.Valid(); iter.Next() {
+		var stop TrailingStop
+		k.cdc.MustUnmarshalBinary(store.Get(iter.Value()), &stop)
+		stops = append(stops, stop)
+	}
+
+	return stops
+}
+
+// getAllTrailingStops returns all trailing stops, sorted by stop id
+func (k Keeper) getAllTrailingStops(ctx sdk.Context) []TrailingStop {
+	stops := make([]TrailingStop, 0)
+
+	store := ctx.KVStore(k.storeKey)
+	iter := sdk.KVStorePrefixIterator(store, trailingStopSubspace)
+	defer iter.Close()
+
+	for ; iter.Valid(); iter.Next() {
+		var stop TrailingStop
+		k.cdc.MustUnmarshalBinary(iter.Value(), &stop)
+		stops = append(stops, stop)
+	}
+
+	return stops
+}
@@ -0,0 +1,335 @@
+package exchange
+
+import (
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/tendermint/abci/types"
+)
+
+// setupTrailingStopTest returns a keeper with a listed ETH/RUNE market, a buyer and a seller that can trade, and an
+// account with coins for trailing stops
+func setupTrailingStopTest() (sdk.Context, Keeper, func(int64), sdk.AccAddress, sdk.AccAddress, sdk.AccAddress) {
+	ctx := setupContext(exchangeKey)
+	keeper, _, bankKeeper, buyer, seller := setupKeepers(exchangeKey, ctx)
+	stopper := sdk.AccAddress([]byte("stopper"))
+	bankKeeper.SetCoins(ctx, buyer, sdk.Coins{sdk.NewInt64Coin("RUNE", 2000)})
+	bankKeeper.SetCoins(ctx, seller, sdk.Coins{sdk.NewInt64Coin("ETH", 250)})
+	bankKeeper.SetCoins(ctx, stopper, sdk.Coins{sdk.NewInt64Coin("ETH", 10), sdk.NewInt64Coin("RUNE", 200)})
+
+	// trade 1ETH at the given price between buyer and seller
+	trade := func(price int64) {
+		expiresAt := time.Now().Add(time.Minute).UTC()
+		_, _, err := keeper.processLimitOrder(ctx, seller, SellOrder, sdk.NewInt64Coin("ETH", 1),
+			NewInt64Price("RUNE", price), OrderOptions{ExpiresAt: expiresAt})
+		if err != nil {
+			panic(err)
+		}
+		_, filled, err := keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 1),
+			NewInt64Price("RUNE", price), OrderOptions{ExpiresAt: expiresAt, TimeInForce: ImmediateOrCancel})
+		if err != nil || len(filled) != 1 {
+			panic("no trade")
+		}
+	}
+
+	return ctx, keeper, trade, buyer, seller, stopper
+}
+
+func TestTrailingStopTriggerPrice(t *testing.T) {
+	sell := NewTrailingStop(1, nil, SellOrder, sdk.NewInt64Coin("ETH", 10), "RUNE", sdk.NewRat(2), 0, false,
+		sdk.NewRat(1, 2), Trade{TradeID: 1, Price: NewInt64Price("RUNE", 10)})
+	require.Equal(t, NewInt64Price("RUNE", 8), sell.getTriggerPrice())
+
+	// the reference only moves up for sells
+	require.False(t, sell.update(Trade{TradeID: 2, Price: NewInt64Price("RUNE", 12)}))
+	require.False(t, sell.update(Trade{TradeID: 3, Price: NewInt64Price("RUNE", 11)}))
+	require.Equal(t, NewInt64Price("RUNE", 12), sell.ReferencePrice)
+	require.Equal(t, int64(3), sell.LastTradeID)
+	require.True(t, sell.update(Trade{TradeID: 4, Price: NewInt64Price("RUNE", 10)}))
+	require.Equal(t, NewInt64Price("RUNE", 9), sell.getLimitPrice(sell.getTriggerPrice(), sdk.OneRat()))
+
+	// rates are basis points of the reference, which only moves down for buys
+	buy := NewTrailingStop(2, nil, BuyOrder, sdk.NewInt64Coin("ETH", 10), "RUNE", sdk.ZeroRat(), 500, false,
+		sdk.ZeroRat(), Trade{TradeID: 1, Price: NewInt64Price("RUNE", 10)})
+	require.Equal(t, NewPrice("RUNE", sdk.NewRat(21, 2)), buy.getTriggerPrice())
+	require.False(t, buy.update(Trade{TradeID: 2, Price: NewInt64Price("RUNE", 8)}))
+	require.Equal(t, NewPrice("RUNE", sdk.NewRat(42, 5)), buy.getTriggerPrice())
+	require.True(t, buy.update(Trade{TradeID: 3, Price: NewInt64Price("RUNE", 9)}))
+
+	// limit prices are rounded to the tick size away from the trigger price
+	require.Equal(t, NewInt64Price("RUNE", 9), buy.getLimitPrice(buy.getTriggerPrice(), sdk.OneRat()))
+}
+
+func TestMsgCreateTrailingStopValidateBasic(t *testing.T) {
+	sender := sdk.AccAddress([]byte("sender"))
+	amount := sdk.NewInt64Coin("ETH", 10)
+
+	require.Nil(t, NewMsgCreateTrailingStop(sender, SellOrder, amount, "RUNE", sdk.NewRat(2), 0, false,
+		sdk.ZeroRat()).ValidateBasic())
+	require.Nil(t, NewMsgCreateTrailingStop(sender, BuyOrder, amount, "RUNE", sdk.ZeroRat(), 100, true,
+		sdk.ZeroRat()).ValidateBasic())
+
+	// exactly one of offset and rate
+	require.Equal(t, CodeInvalidStop, NewMsgCreateTrailingStop(sender, SellOrder, amount, "RUNE", sdk.NewRat(2), 100,
+		false, sdk.ZeroRat()).ValidateBasic().Code())
+	require.Equal(t, CodeInvalidStop, NewMsgCreateTrailingStop(sender, SellOrder, amount, "RUNE", sdk.ZeroRat(), 0,
+		false, sdk.ZeroRat()).ValidateBasic().Code())
+	require.Equal(t, CodeInvalidStop, NewMsgCreateTrailingStop(sender, SellOrder, amount, "RUNE", sdk.ZeroRat(),
+		trailingRateDenominator, false, sdk.ZeroRat()).ValidateBasic().Code())
+
+	// market stops have no limit offset and stops are not inverted
+	require.Equal(t, CodeInvalidStop, NewMsgCreateTrailingStop(sender, SellOrder, amount, "RUNE", sdk.NewRat(2), 0,
+		true, sdk.OneRat()).ValidateBasic().Code())
+	require.Equal(t, CodeInvalidStop, NewMsgCreateTrailingStop(sender, SellOrder, sdk.NewInt64Coin("RUNE", 10), "ETH",
+		sdk.NewRat(2), 0, false, sdk.ZeroRat()).ValidateBasic().Code())
+}
+
+// Test if a sell stop trails the highest price and is submitted as limit order once the price falls to its trigger
+func TestKeeperTrailingStopLimit(t *testing.T) {
+	ctx, keeper, trade, buyer, _, stopper := setupTrailingStopTest()
+	handler := NewHandler(keeper)
+
+	res := handler(ctx, NewMsgCreateTrailingStop(stopper, SellOrder, sdk.NewInt64Coin("ETH", 10), "RUNE",
+		sdk.NewRat(2), 0, false, sdk.OneRat()))
+	require.Equal(t, ErrInvalidTrailingStop(keeper.codespace, "").Result().Code, res.Code)
+
+	trade(6)
+	stop, err := keeper.createTrailingStop(ctx, stopper, SellOrder, sdk.NewInt64Coin("ETH", 10), "RUNE",
+		sdk.NewRat(2), 0, false, sdk.OneRat())
+	require.Nil(t, err)
+	require.Equal(t, NewInt64Price("RUNE", 4), stop.getTriggerPrice())
+	require.Equal(t, int64(1), keeper.getOpenOrderCount(ctx, MakeKeyOpenOrderCount(stopper)))
+
+	// the price rises, so the trigger price follows it
+	trade(8)
+	require.Len(t, keeper.processTrailingStops(ctx), 0)
+	stop, _ = keeper.getTrailingStop(ctx, stop.StopID)
+	require.Equal(t, NewInt64Price("RUNE", 6), stop.getTriggerPrice())
+
+	_, _, err = keeper.processLimitOrder(ctx, buyer, BuyOrder, sdk.NewInt64Coin("ETH", 10), NewInt64Price("RUNE", 5),
+		OrderOptions{TimeInForce: GoodTillCancelled})
+	require.Nil(t, err)
+
+	// the price falls to the trigger price, the stop sells at one below it
+	trade(7)
+	trade(6)
+	tags := BeginBlocker(ctx, keeper)
+	require.Len(t, tags, 1)
+	require.Equal(t, "trailing_stop_triggered", string(tags[0].Key))
+
+	_, ok := keeper.getTrailingStop(ctx, stop.StopID)
+	require.False(t, ok)
+	require.Len(t, keeper.getTrailingStopsBySender(ctx, stopper), 0)
+	require.Equal(t, "250RUNE", keeper.bankKeeper.GetCoins(ctx, stopper).String())
+	require.Equal(t, int64(0), keeper.getOpenOrderCount(ctx, MakeKeyOpenOrderCount(stopper)))
+	require.True(t, keeper.getEscrowTotals(ctx).IsReconciled())
+}
+
+// Test if a buy stop trails the lowest price by a rate and is submitted as market order, and if a market order without
+// liquidity fails and only removes its stop
+func TestKeeperTrailingStopMarket(t *testing.T) {
+	ctx, keeper, trade, _, seller, stopper := setupTrailingStopTest()
+
+	trade(10)
+	buy, err := keeper.createTrailingStop(ctx, stopper, BuyOrder, sdk.NewInt64Coin("ETH", 10), "RUNE",
+		sdk.ZeroRat(), 1000, true, sdk.ZeroRat())
+	require.Nil(t, err)
+	sell, err := keeper.createTrailingStop(ctx, stopper, SellOrder, sdk.NewInt64Coin("ETH", 5), "RUNE",
+		sdk.NewRat(1), 0, true, sdk.ZeroRat())
+	require.Nil(t, err)
+
+	// the sell stop triggers one below the highest price, but there are no buy orders to sell into
+	trade(9)
+	results := keeper.processTrailingStops(ctx)
+	require.Len(t, results, 1)
+	require.Equal(t, sell.StopID, results[0].Stop.StopID)
+	require.Nil(t, results[0].Processed)
+	require.Equal(t, ErrNoLiquidity(keeper.codespace, "ETH", "RUNE").Result().Code, results[0].Code)
+	require.Equal(t, "10ETH,200RUNE", keeper.bankKeeper.GetCoins(ctx, stopper).String())
+
+	expiresAt := time.Now().Add(time.Minute).UTC()
+	for _, price := range []int64{10, 12} {
+		_, _, err = keeper.processLimitOrder(ctx, seller, SellOrder, sdk.NewInt64Coin("ETH", 5), NewInt64Price("RUNE", price),
+			OrderOptions{ExpiresAt: expiresAt})
+		require.Nil(t, err)
+	}
+
+	// 10 is more than 10% above the lowest price 9, the market order fills the remaining 5ETH at 10 and 5ETH at 12
+	trade(10)
+	results = keeper.processTrailingStops(ctx)
+	require.Len(t, results, 1)
+	require.Equal(t, buy.StopID, results[0].Stop.StopID)
+	require.NotNil(t, results[0].Processed)
+	require.Len(t, results[0].Filled, 3)
+	require.Equal(t, NewInt64Price("RUNE", 12), results[0].Filled[2].FilledPrice)
+	require.Equal(t, "20ETH,90RUNE", keeper.bankKeeper.GetCoins(ctx, stopper).String())
+
+	require.Len(t, keeper.getTrailingStopsBySender(ctx, stopper), 0)
+	require.True(t, keeper.getEscrowTotals(ctx).IsReconciled())
+}
+
+// Test if only the sender can cancel a stop and if stops are exported and imported with the genesis
+func TestKeeperTrailingStopCancelAndGenesis(t *testing.T) {
+	ctx, keeper, trade, buyer, _, stopper := setupTrailingStopTest()
+	handler := NewHandler(keeper)
+	keeper.bankKeeper.SetCoins(ctx, stopper, sdk.Coins{sdk.NewInt64Coin("ETH", 20)})
+
+	trade(6)
+	stop, err := keeper.createTrailingStop(ctx, stopper, SellOrder, sdk.NewInt64Coin("ETH", 10), "RUNE",
+		sdk.NewRat(2), 0, false, sdk.ZeroRat())
+	require.Nil(t, err)
+	other, err := keeper.createTrailingStop(ctx, stopper, SellOrder, sdk.NewInt64Coin("ETH", 10), "RUNE",
+		sdk.ZeroRat(), 100, false, sdk.ZeroRat())
+	require.Nil(t, err)
+
+	genesis := WriteGenesis(ctx, keeper)
+	require.Len(t, genesis.TrailingStops, 2)
+	require.Nil(t, ValidateGenesis(genesis))
+
+	res := handler(ctx, NewMsgCancelTrailingStop(buyer, stop.StopID))
+	require.Equal(t, ErrNotOrderOwner(keeper.codespace, stop.StopID).Result().Code, res.Code)
+	res = handler(ctx, NewMsgCancelTrailingStop(stopper, stop.StopID))
+	require.True(t, res.IsOK(), res.Log)
+	res = handler(ctx, NewMsgCancelTrailingStop(stopper, stop.StopID))
+	require.Equal(t, ErrTrailingStopNotFound(keeper.codespace, stop.StopID).Result().Code, res.Code)
+
+	stops := keeper.getTrailingStopsBySender(ctx, stopper)
+	require.Len(t, stops, 1)
+	require.Equal(t, other.StopID, stops[0].StopID)
+	require.Equal(t, int64(1), keeper.getOpenOrderCount(ctx, MakeKeyOpenOrderCount(stopper)))
+	require.Equal(t, int64(10), keeper.bankKeeper.GetCoins(ctx, stopper).AmountOf("ETH").Int64())
+	require.True(t, keeper.getEscrowTotals(ctx).IsReconciled())
+}
+
+// Test if stops lock their coins and the order deposit, and if stops triggered beyond the maximum per block are
+// submitted in the next block
+func TestKeeperTrailingStopLockAndCap(t *testing.T) {
+	ctx, keeper, trade, _, seller, stopper := setupTrailingStopTest()
+	keeper.bankKeeper.SetCoins(ctx, seller, sdk.Coins{sdk.NewInt64Coin("ETH", 250), sdk.NewInt64Coin("RUNE", 100)})
+	params := keeper.getParams(ctx)
+	params.MaxTriggersPerBlock = 1
+	params.OrderDeposit = sdk.NewInt64Coin("RUNE", 10)
+	keeper.setParams(ctx, params)
+
+	trade(6)
+	first, err := keeper.createTrailingStop(ctx, stopper, SellOrder, sdk.NewInt64Coin("ETH", 5), "RUNE",
+		sdk.NewRat(2), 0, false, sdk.ZeroRat())
+	require.Nil(t, err)
+	second, err := keeper.createTrailingStop(ctx, stopper, SellOrder, sdk.NewInt64Coin("ETH", 5), "RUNE",
+		sdk.NewRat(2), 0, false, sdk.ZeroRat())
+	require.Nil(t, err)
+	buy, err := keeper.createTrailingStop(ctx, stopper, BuyOrder, sdk.NewInt64Coin("ETH", 5), "RUNE",
+		sdk.NewRat(2), 0, false, sdk.ZeroRat())
+	require.Nil(t, err)
+
+	// sell stops lock their amount, all stops lock the order deposit
+	require.Equal(t, sdk.Coins{sdk.NewInt64Coin("ETH", 5), sdk.NewInt64Coin("RUNE", 10)}, first.Locked)
+	require.Equal(t, sdk.Coins{sdk.NewInt64Coin("RUNE", 10)}, buy.Locked)
+	require.Equal(t, int64(0), keeper.bankKeeper.GetCoins(ctx, stopper).AmountOf("ETH").Int64())
+	require.Equal(t, int64(170), keeper.bankKeeper.GetCoins(ctx, stopper).AmountOf("RUNE").Int64())
+	require.True(t, keeper.getEscrowTotals(ctx).IsReconciled())
+
+	_, err = keeper.createTrailingStop(ctx, stopper, SellOrder, sdk.NewInt64Coin("ETH", 5), "RUNE",
+		sdk.NewRat(2), 0, false, sdk.ZeroRat())
+	require.NotNil(t, err)
+
+	_, err = keeper.cancelTrailingStop(ctx, stopper, buy.StopID)
+	require.Nil(t, err)
+	require.Equal(t, int64(180), keeper.bankKeeper.GetCoins(ctx, stopper).AmountOf("RUNE").Int64())
+
+	// both sell stops trigger, but only one is submitted per block
+	trade(4)
+	results := keeper.processTrailingStops(ctx)
+	require.Len(t, results, 1)
+	require.Equal(t, first.StopID, results[0].Stop.StopID)
+	require.NotNil(t, results[0].Processed)
+
+	pending, ok := keeper.getTrailingStop(ctx, second.StopID)
+	require.True(t, ok)
+	require.True(t, pending.Triggered)
+	genesis := WriteGenesis(ctx, keeper)
+	require.Len(t, genesis.TrailingStops, 1)
+	require.Nil(t, ValidateGenesis(genesis))
+
+	// the rest is submitted in the next block without a new trade
+	results = keeper.processTrailingStops(ctx)
+	require.Len(t, results, 1)
+	require.Equal(t, second.StopID, results[0].Stop.StopID)
+	require.NotNil(t, results[0].Processed)
+	require.Len(t, keeper.getTriggeredTrailingStops(ctx), 0)
+	require.Len(t, keeper.getTrailingStopsBySender(ctx, stopper), 0)
+
+	// the submitted orders rest in the order book and lock the coins and deposits again
+	require.Len(t, keeper.getOrderBook(ctx, SellOrder, "ETH", "RUNE").Orders, 2)
+	require.Equal(t, int64(180), keeper.bankKeeper.GetCoins(ctx, stopper).AmountOf("RUNE").Int64())
+	require.True(t, keeper.getEscrowTotals(ctx).IsReconciled())
+}
+
+// Test if market stops count hidden iceberg reserves and skip expired orders when pricing their order
+func TestKeeperTrailingStopMarketOrderPrice(t *testing.T) {
+	ctx, keeper, _, _, seller, _ := setupTrailingStopTest()
+	ctx = ctx.WithBlockHeight(3)
+
+	expiresAt := time.Now().Add(time.Minute).UTC()
+	_, _, err := keeper.processLimitOrder(ctx, seller, SellOrder, sdk.NewInt64Coin("ETH", 10), NewInt64Price("RUNE", 10),
+		OrderOptions{ExpiresAt: expiresAt, DisplayAmount: sdk.NewInt(2)})
+	require.Nil(t, err)
+	_, _, err = keeper.processLimitOrder(ctx, seller, SellOrder, sdk.NewInt64Coin("ETH", 5), NewInt64Price("RUNE", 11),
+		OrderOptions{ExpiresAtHeight: 5})
+	require.Nil(t, err)
+	_, _, err = keeper.processLimitOrder(ctx, seller, SellOrder, sdk.NewInt64Coin("ETH", 5), NewInt64Price("RUNE", 12),
+		OrderOptions{ExpiresAt: expiresAt})
+	require.Nil(t, err)
+
+	// the iceberg order fills 8ETH, although it only shows 2ETH
+	price, err := keeper.getMarketOrderPrice(ctx, BuyOrder, sdk.NewInt64Coin("ETH", 8), "RUNE")
+	require.Nil(t, err)
+	require.Equal(t, NewInt64Price("RUNE", 10), price)
+	price, err = keeper.getMarketOrderPrice(ctx, BuyOrder, sdk.NewInt64Coin("ETH", 12), "RUNE")
+	require.Nil(t, err)
+	require.Equal(t, NewInt64Price("RUNE", 11), price)
+
+	// the order at 11 has expired
+	ctx = ctx.WithBlockHeight(5)
+	price, err = keeper.getMarketOrderPrice(ctx, BuyOrder, sdk.NewInt64Coin("ETH", 12), "RUNE")
+	require.Nil(t, err)
+	require.Equal(t, NewInt64Price("RUNE", 12), price)
+	price, err = keeper.getMarketOrderPrice(ctx, BuyOrder, sdk.NewInt64Coin("ETH", 50), "RUNE")
+	require.Nil(t, err)
+	require.Equal(t, NewInt64Price("RUNE", 12), price)
+
+	// an order book with only expired orders has no liquidity
+	ctx = ctx.WithBlockHeader(abci.Header{Time: expiresAt.Add(time.Second)})
+	_, err = keeper.getMarketOrderPrice(ctx, BuyOrder, sdk.NewInt64Coin("ETH", 1), "RUNE")
+	require.NotNil(t, err)
+	require.Equal(t, ErrNoLiquidity(keeper.codespace, "ETH", "RUNE").Result().Code, err.Result().Code)
+}
+
+// Test if only the markets that traded since the last block are processed
+func TestKeeperTrailingStopTradedMarkets(t *testing.T) {
+	ctx, keeper, trade, _, _, stopper := setupTrailingStopTest()
+	require.Len(t, keeper.getTradedMarkets(ctx), 0)
+
+	trade(10)
+	markets := keeper.getTradedMarkets(ctx)
+	require.Len(t, markets, 1)
+	require.Equal(t, "ETH", markets[0].AmountDenom)
+	require.Equal(t, "RUNE", markets[0].PriceDenom)
+
+	stop, err := keeper.createTrailingStop(ctx, stopper, SellOrder, sdk.NewInt64Coin("ETH", 5), "RUNE",
+		sdk.NewRat(2), 0, false, sdk.ZeroRat())
+	require.Nil(t, err)
+	require.Len(t, keeper.processTrailingStops(ctx), 0)
+	require.Len(t, keeper.getTradedMarkets(ctx), 0)
+
+	// the stop is updated with the new trade and triggers once the price falls by 2
+	trade(12)
+	require.Len(t, keeper.processTrailingStops(ctx), 0)
+	trade(10)
+	results := keeper.processTrailingStops(ctx)
+	require.Len(t, results, 1)
+	require.Equal(t, stop.StopID, results[0].Stop.StopID)
+	require.Len(t, keeper.getTradedMarkets(ctx), 0)
+}
@@ -1,7 +1,9 @@
 package exchange
 
 import (
+	"bytes"
 	"fmt"
+	"sort"
 	"time"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
@@ -17,21 +19,92 @@ const (
 	SellOrder OrderKind = 0x02
 )
 
+// TimeInForce defines how long an order stays active and what happens to the part that cannot be filled right away
+type TimeInForce byte
+
+const (
+	// GoodTillTime orders rest in the orderbook until they are filled or expire at their expiry time or height
+	GoodTillTime TimeInForce = 0x00
+	// ImmediateOrCancel orders are filled as far as possible, the unfilled part is cancelled
+	ImmediateOrCancel TimeInForce = 0x01
+	// FillOrKill orders are either filled completely or rejected
+	FillOrKill TimeInForce = 0x02
+	// PostOnly orders are rejected if they would match a stored order
+	PostOnly TimeInForce = 0x03
+	// GoodTillCancelled orders rest in the orderbook until they are filled or cancelled, they never expire
+	GoodTillCancelled TimeInForce = 0x04
+)
+
+func isValidTimeInForce(timeInForce TimeInForce) bool {
+	return timeInForce == GoodTillTime || timeInForce == ImmediateOrCancel || timeInForce == FillOrKill ||
+		timeInForce == PostOnly || timeInForce == GoodTillCancelled
+}
+
+// OrderOptions are the settings of a limit order besides its sender, kind, amount and price. The zero value is a
+// good-till-time order without expiry that follows the self-trade prevention mode of its market, shows its whole
+// amount and has neither a client order id nor a one-cancels-other group
+type OrderOptions struct {
+	ExpiresAt           time.Time // zero if the order does not expire at a time
+	ExpiresAtHeight     int64     // zero if the order does not expire at a block height
+	TimeInForce         TimeInForce
+	SelfTradePrevention SelfTradePrevention
+	DisplayAmount       sdk.Int // shown slice of an iceberg order, zero or unset to show the whole amount
+	ClientOrderID       string  // id chosen by the sender, unique per sender, empty if not used
+	OCOGroup            string  // one-cancels-other group chosen by the sender, empty if not used
+}
+
 // LimitOrder that is stored in orderbook
 type LimitOrder struct {
-	OrderID   int64          `json:"order_id"`
-	Sender    sdk.AccAddress `json:"sender"`
-	Kind      OrderKind      `json:"kind"`
-	Amount    sdk.Coin       `json:"amount"`
-	Price     sdk.Coin       `json:"price"`
-	ExpiresAt time.Time      `json:"expires_at"`
+	OrderID     int64          `json:"order_id"`
+	Sender      sdk.AccAddress `json:"sender"`
+	Kind        OrderKind      `json:"kind"`
+	Amount      sdk.Coin       `json:"amount"`
+	Price       Price          `json:"price"`
+	ExpiresAt   time.Time      `json:"expires_at"` // zero if the order does not expire at a time
+	TimeInForce TimeInForce    `json:"time_in_force"`
+	// block height at which the order expires, zero if the order does not expire at a height
+	ExpiresAtHeight int64 `json:"expires_at_height"`
+	// self-trade prevention mode, only used by batch auctions, as stored orders are always the older order in
+	// continuous markets
+	SelfTradePrevention SelfTradePrevention `json:"self_trade_prevention"`
+	// hidden reserve of an iceberg order, nil for other orders. The amount is the slice shown in the order book
+	Iceberg *Iceberg `json:"iceberg,omitempty"`
+	// id chosen by the sender, unique per sender, empty if the sender did not choose one
+	ClientOrderID string `json:"client_order_id,omitempty"`
+	// deposit locked while the order is open, empty if the order deposit was disabled when the order was stored
+	Deposit sdk.Coins `json:"deposit,omitempty"`
+	// one-cancels-other group chosen by the sender, empty if the order is not part of a group. Once an order of a
+	// group is filled or cancelled, the other open orders of the sender in the group are cancelled
+	OCOGroup string `json:"oco_group,omitempty"`
+}
+
+// MaxClientOrderIDLength is the maximum length of the client order id of an order
+const MaxClientOrderIDLength = 64
+
+// ClientOrderID is the id of the order a sender placed with a client order id. It is kept after the order is closed
+type ClientOrderID struct {
+	Sender        sdk.AccAddress `json:"sender"`
+	ClientOrderID string         `json:"client_order_id"`
+	OrderID       int64          `json:"order_id"`
 }
 
+// MaxOCOGroupLength is the maximum length of the one-cancels-other group of an order
+const MaxOCOGroupLength = 64
+
 // ProcessedLimitOrder is return after order matching as a log entry to signal whether the order is fully filled or
 // there is an open amount still sitting in the orderbook
 type ProcessedLimitOrder struct {
-	OrderID    int64    `json:"order_id"`
-	OpenAmount sdk.Coin `json:"open_amt"`
+	OrderID             int64                `json:"order_id"`
+	OpenAmount          sdk.Coin             `json:"open_amt"`
+	PreventedSelfTrades []PreventedSelfTrade `json:"prevented_self_trades"`
+	ClientOrderID       string               `json:"client_order_id,omitempty"`
+	// orders of the one-cancels-other groups of the order and of the orders it filled that have been cancelled
+	CancelledOCOOrderIDs []int64 `json:"cancelled_oco_orders,omitempty"`
+}
+
+// NewProcessedLimitOrder creates a new processed limit order without prevented self-trades
+func NewProcessedLimitOrder(orderID int64, openAmount sdk.Coin) ProcessedLimitOrder {
+	return ProcessedLimitOrder{orderID, openAmount, make([]PreventedSelfTrade, 0), "", nil}
 }
 
 // FilledLimitOrder is return after order matching as a log entry to signal what orders have been filled with
@@ -39,32 +112,98 @@ type ProcessedLimitOrder struct {
 type FilledLimitOrder struct {
 	OrderID      int64    `json:"order_id"`
 	FilledAmount sdk.Coin `json:"filled_amt"`
-	FilledPrice  sdk.Coin `json:"filled_price"`
+	FilledPrice  Price    `json:"filled_price"`
+	MakerFee     sdk.Coin `json:"maker_fee"`
+	TakerFee     sdk.Coin `json:"taker_fee"`
+	// client order id of the filled order
+	ClientOrderID string `json:"client_order_id,omitempty"`
+}
+
+// OrderFill is published as an event for every fill of an order, in the terms of the order
+type OrderFill struct {
+	OrderID             int64    `json:"order_id"`
+	CounterpartyOrderID int64    `json:"counterparty_order_id"` // the stored order that has been filled
+	Amount              sdk.Coin `json:"amount"`
+	Price               Price    `json:"price"`
+	MakerFee            sdk.Coin `json:"maker_fee"`
+	TakerFee            sdk.Coin `json:"taker_fee"`
+	// client order ids of the order and of the stored order, empty if not used
+	ClientOrderID             string `json:"client_order_id,omitempty"`
+	CounterpartyClientOrderID string `json:"counterparty_client_order_id,omitempty"`
+}
+
+// OpenLimitOrder is an order that is still sitting in the orderbook, together with the coins locked for its
+// remaining amount
+type OpenLimitOrder struct {
+	Order       LimitOrder `json:"order"`
+	LockedCoins sdk.Coin   `json:"locked_coins"`
 }
 
 // NewLimitOrder creates a new limit order
-func NewLimitOrder(orderID int64, sender sdk.AccAddress, kind OrderKind, amount sdk.Coin, price sdk.Coin, expiresAt time.Time) LimitOrder {
+func NewLimitOrder(orderID int64, sender sdk.AccAddress, kind OrderKind, amount sdk.Coin, price Price,
+	expiresAt time.Time, timeInForce TimeInForce) LimitOrder {
 	newLimitOrder := LimitOrder{
-		OrderID:   orderID,
-		Sender:    sender,
-		Kind:      kind,
-		Amount:    amount,
-		Price:     price,
-		ExpiresAt: expiresAt,
+		OrderID:     orderID,
+		Sender:      sender,
+		Kind:        kind,
+		Amount:      amount,
+		Price:       price,
+		ExpiresAt:   expiresAt,
+		TimeInForce: timeInForce,
 	}
 
 	return newLimitOrder
 }
 
+// isExpired checks if an expiry time or height (each zero if not set) is before the given time or at the given block
+// height
+func isExpired(expiresAt time.Time, expiresAtHeight int64, now time.Time, height int64) bool {
+	return (!expiresAt.IsZero() && expiresAt.Before(now)) || (expiresAtHeight > 0 && expiresAtHeight <= height)
+}
+
+// checkExpiry checks that good-till-cancelled orders have neither an expiry time nor height and that all other orders
+// have at least one of them
+func checkExpiry(codespace sdk.CodespaceType, expiresAt time.Time, expiresAtHeight int64, timeInForce TimeInForce,
+) sdk.Error {
+	if expiresAtHeight < 0 {
+		return ErrInvalidExpiry(codespace, "expiry height must not be negative")
+	}
+
+	hasExpiry := !expiresAt.IsZero() || expiresAtHeight > 0
+	if timeInForce == GoodTillCancelled && hasExpiry {
+		return ErrInvalidExpiry(codespace, "good-till-cancelled orders must not have an expiry time or height")
+	}
+	if timeInForce != GoodTillCancelled && !hasExpiry {
+		return ErrInvalidExpiry(codespace, "order must have an expiry time or height unless it is good-till-cancelled")
+	}
+
+	return nil
+}
+
 // String provides a human-readable representation of an order
 func (lo *LimitOrder) String() string {
 	return fmt.Sprintf("LimitOrder{Sender: %v, Kind: %v, Amount: %v, Price: %v, ExpiresAt: %v}",
 		lo.Sender, lo.Kind, lo.Amount, lo.Price, lo.ExpiresAt)
 }
 
+// getLockedCoins returns the coins that are locked to fill the open amount of the order including the hidden reserve,
+// which is the total price for buy orders and the amount for sell orders
+func (lo *LimitOrder) getLockedCoins() sdk.Coin {
+	if lo.Kind == BuyOrder {
+		return getTotalPrice(lo.getTotalAmount(), lo.Price)
+	}
+	return lo.getTotalAmount()
+}
+
+// getEscrowedCoins returns all coins the escrow account holds for the order, which are its locked coins and its
+// deposit
+func (lo *LimitOrder) getEscrowedCoins() sdk.Coins {
+	return sdk.Coins{lo.getLockedCoins()}.Plus(lo.Deposit)
+}
+
 // DoesFill checks if the stored order does fill the given parameters. If it does, it returns true, the amount that can
 // be filled and the price at which it will be filled.
-func (lo *LimitOrder) DoesFill(kind OrderKind, amount, price sdk.Coin) (bool, sdk.Coin, sdk.Coin) {
+func (lo *LimitOrder) DoesFill(kind OrderKind, amount sdk.Coin, price Price) (bool, sdk.Coin, Price) {
 	// Check if amomunts and prices match
 	if lo.Amount.Denom != amount.Denom {
 		panic(fmt.Sprintf("Amount denom does not match between stored order %v and order to fill %v", lo,
@@ -97,3 +236,33 @@ func (lo *LimitOrder) DoesFill(kind OrderKind, amount, price sdk.Coin) (bool, sd
 	}
 	return false, fillAmount, lo.Price
 }
+
+// GetOpenLimitOrdersOfSender returns all orders of the sender in the given orderbooks, sorted by order id
+func GetOpenLimitOrdersOfSender(sender sdk.AccAddress, orderBooks []OrderBook) []OpenLimitOrder {
+	openOrders := make([]OpenLimitOrder, 0)
+
+	for _, orderBook := range orderBooks {
+		for _, order := range orderBook.Orders {
+			if bytes.Equal(order.Sender, sender) {
+				openOrders = append(openOrders, OpenLimitOrder{order, order.getLockedCoins()})
+			}
+		}
+	}
+
+	sort.Slice(openOrders, func(i, j int) bool {
+		return openOrders[i].Order.OrderID < openOrders[j].Order.OrderID
+	})
+
+	return openOrders
+}
+
+// FilterByClientOrderID returns the open orders that have the given client order id
+func FilterByClientOrderID(openOrders []OpenLimitOrder, clientOrderID string) []OpenLimitOrder {
+	filtered := make([]OpenLimitOrder, 0)
+	for _, openOrder := range openOrders {
+		if openOrder.Order.ClientOrderID == clientOrderID {
+			filtered = append(filtered, openOrder)
+		}
+	}
+	return filtered
+}
@@ -12,17 +12,17 @@ func TestDoesLimitOrderFillSad(t *testing.T) {
 		OrderID: 42,
 		Kind:    BuyOrder,
 		Amount:  sdk.NewInt64Coin("ETH", 60),
-		Price:   sdk.NewInt64Coin("BTC", 150),
+		Price:   NewInt64Price("BTC", 150),
 	}
 
 	require.PanicsWithValue(t, "Amount denom does not match between stored order LimitOrder{Sender: , Kind: 1, Amount: 60ETH, Price: 150BTC, ExpiresAt: 0001-01-01 00:00:00 +0000 UTC} and order to fill RUNE", func() {
-		lo.DoesFill(BuyOrder, sdk.NewInt64Coin("RUNE", 50), sdk.NewInt64Coin("BTC", 140))
+		lo.DoesFill(BuyOrder, sdk.NewInt64Coin("RUNE", 50), NewInt64Price("BTC", 140))
 	})
 	require.PanicsWithValue(t, "Price denom does not match between stored order LimitOrder{Sender: , Kind: 1, Amount: 60ETH, Price: 150BTC, ExpiresAt: 0001-01-01 00:00:00 +0000 UTC} and order to fill RUNE", func() {
-		lo.DoesFill(BuyOrder, sdk.NewInt64Coin("ETH", 50), sdk.NewInt64Coin("RUNE", 140))
+		lo.DoesFill(BuyOrder, sdk.NewInt64Coin("ETH", 50), NewInt64Price("RUNE", 140))
 	})
 	require.PanicsWithValue(t, "Kind does not match between stored order LimitOrder{Sender: , Kind: 1, Amount: 60ETH, Price: 150BTC, ExpiresAt: 0001-01-01 00:00:00 +0000 UTC} and order to fill 1", func() {
-		lo.DoesFill(BuyOrder, sdk.NewInt64Coin("ETH", 50), sdk.NewInt64Coin("BTC", 140))
+		lo.DoesFill(BuyOrder, sdk.NewInt64Coin("ETH", 50), NewInt64Price("BTC", 140))
 	})
 }
 
@@ -31,30 +31,30 @@ func TestDoesLimitOrderFillSellOrder(t *testing.T) {
 		OrderID: 42,
 		Kind:    BuyOrder,
 		Amount:  sdk.NewInt64Coin("ETH", 60),
-		Price:   sdk.NewInt64Coin("BTC", 150),
+		Price:   NewInt64Price("BTC", 150),
 	}
 
-	ok, _, _ := lo.DoesFill(SellOrder, sdk.NewInt64Coin("ETH", 50), sdk.NewInt64Coin("BTC", 151))
+	ok, _, _ := lo.DoesFill(SellOrder, sdk.NewInt64Coin("ETH", 50), NewInt64Price("BTC", 151))
 
 	require.False(t, ok)
 
-	ok, fillAmt, fillPrice := lo.DoesFill(SellOrder, sdk.NewInt64Coin("ETH", 50), sdk.NewInt64Coin("BTC", 140))
+	ok, fillAmt, fillPrice := lo.DoesFill(SellOrder, sdk.NewInt64Coin("ETH", 50), NewInt64Price("BTC", 140))
 
 	require.True(t, ok)
 	require.Equal(t, sdk.NewInt64Coin("ETH", 50), fillAmt)
-	require.Equal(t, sdk.NewInt64Coin("BTC", 150), fillPrice)
+	require.Equal(t, NewInt64Price("BTC", 150), fillPrice)
 
-	ok, fillAmt, fillPrice = lo.DoesFill(SellOrder, sdk.NewInt64Coin("ETH", 50), sdk.NewInt64Coin("BTC", 150))
+	ok, fillAmt, fillPrice = lo.DoesFill(SellOrder, sdk.NewInt64Coin("ETH", 50), NewInt64Price("BTC", 150))
 
 	require.True(t, ok)
 	require.Equal(t, sdk.NewInt64Coin("ETH", 50), fillAmt)
-	require.Equal(t, sdk.NewInt64Coin("BTC", 150), fillPrice)
+	require.Equal(t, NewInt64Price("BTC", 150), fillPrice)
 
-	ok, fillAmt, fillPrice = lo.DoesFill(SellOrder, sdk.NewInt64Coin("ETH", 70), sdk.NewInt64Coin("BTC", 100))
+	ok, fillAmt, fillPrice = lo.DoesFill(SellOrder, sdk.NewInt64Coin("ETH", 70), NewInt64Price("BTC", 100))
 
 	require.True(t, ok)
 	require.Equal(t, sdk.NewInt64Coin("ETH", 60), fillAmt)
-	require.Equal(t, sdk.NewInt64Coin("BTC", 150), fillPrice)
+	require.Equal(t, NewInt64Price("BTC", 150), fillPrice)
 }
 
 func TestDoesLimitOrderFillBuyOrder(t *testing.T) {
@@ -62,28 +62,28 @@ func TestDoesLimitOrderFillBuyOrder(t *testing.T) {
 		OrderID: 42,
 		Kind:    SellOrder,
 		Amount:  sdk.NewInt64Coin("ETH", 200),
-		Price:   sdk.NewInt64Coin("BTC", 11),
+		Price:   NewInt64Price("BTC", 11),
 	}
 
-	ok, _, _ := lo.DoesFill(BuyOrder, sdk.NewInt64Coin("ETH", 180), sdk.NewInt64Coin("BTC", 10))
+	ok, _, _ := lo.DoesFill(BuyOrder, sdk.NewInt64Coin("ETH", 180), NewInt64Price("BTC", 10))
 
 	require.False(t, ok)
 
-	ok, fillAmt, fillPrice := lo.DoesFill(BuyOrder, sdk.NewInt64Coin("ETH", 180), sdk.NewInt64Coin("BTC", 11))
+	ok, fillAmt, fillPrice := lo.DoesFill(BuyOrder, sdk.NewInt64Coin("ETH", 180), NewInt64Price("BTC", 11))
 
 	require.True(t, ok)
 	require.Equal(t, sdk.NewInt64Coin("ETH", 180), fillAmt)
-	require.Equal(t, sdk.NewInt64Coin("BTC", 11), fillPrice)
+	require.Equal(t, NewInt64Price("BTC", 11), fillPrice)
 
-	ok, fillAmt, fillPrice = lo.DoesFill(BuyOrder, sdk.NewInt64Coin("ETH", 200), sdk.NewInt64Coin("BTC", 13))
+	ok, fillAmt, fillPrice = lo.DoesFill(BuyOrder, sdk.NewInt64Coin("ETH", 200), NewInt64Price("BTC", 13))
 
 	require.True(t, ok)
 	require.Equal(t, sdk.NewInt64Coin("ETH", 200), fillAmt)
-	require.Equal(t, sdk.NewInt64Coin("BTC", 11), fillPrice)
+	require.Equal(t, NewInt64Price("BTC", 11), fillPrice)
 
-	ok, fillAmt, fillPrice = lo.DoesFill(BuyOrder, sdk.NewInt64Coin("ETH", 220), sdk.NewInt64Coin("BTC", 13))
+	ok, fillAmt, fillPrice = lo.DoesFill(BuyOrder, sdk.NewInt64Coin("ETH", 220), NewInt64Price("BTC", 13))
 
 	require.True(t, ok)
 	require.Equal(t, sdk.NewInt64Coin("ETH", 200), fillAmt)
-	require.Equal(t, sdk.NewInt64Coin("BTC", 11), fillPrice)
+	require.Equal(t, NewInt64Price("BTC", 11), fillPrice)
 }
@@ -0,0 +1,273 @@
+package exchange
+
+import (
+	"encoding/json"
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// MarketMode defines how orders of a market are matched
+type MarketMode byte
+
+const (
+	// ContinuousMode markets match every order as soon as it is processed
+	ContinuousMode MarketMode = 0x00
+	// BatchAuctionMode markets collect the orders of a block and clear them at the end of the block at a single
+	// uniform price
+	BatchAuctionMode MarketMode = 0x01
+)
+
+func isValidMarketMode(mode MarketMode) bool {
+	return mode == ContinuousMode || mode == BatchAuctionMode
+}
+
+// ParseMarketMode parses a market mode string
+func ParseMarketMode(str string) (MarketMode, error) {
+	switch str {
+	case "continuous":
+		return ContinuousMode, nil
+	case "batch":
+		return BatchAuctionMode, nil
+	default:
+		return 0x02, fmt.Errorf("'%s' is not a valid market mode, use 'continuous' or 'batch'", str)
+	}
+}
+
+// MarketStatus defines whether orders can be placed in a market
+type MarketStatus byte
+
+const (
+	// UnlistedStatus markets reject all orders
+	UnlistedStatus MarketStatus = 0x00
+	// ListedStatus markets are live and accept orders
+	ListedStatus MarketStatus = 0x01
+)
+
+func isValidMarketStatus(status MarketStatus) bool {
+	return status == UnlistedStatus || status == ListedStatus
+}
+
+// price bands are given in basis points, i. e. 1/10000 of the reference price
+const priceBandDenominator = 10000
+
+// noFeeRateOverride is the fee rate of markets that use the fee rates of the exchange parameters
+const noFeeRateOverride = -1
+
+// Market holds the configuration of the token pair with the given amount (base) and price (quote) denoms
+type Market struct {
+	AmountDenom string       `json:"amount_denom"`
+	PriceDenom  string       `json:"price_denom"`
+	Status      MarketStatus `json:"status"`
+	Mode        MarketMode   `json:"mode"`
+	TickSize    sdk.Rat      `json:"tick_size"`    // prices must be multiples of the tick size
+	LotSize     sdk.Int      `json:"lot_size"`     // amounts must be multiples of the lot size
+	MinNotional sdk.Int      `json:"min_notional"` // minimum total price of an order in the price denom
+	// self-trade prevention mode of orders that do not set one
+	SelfTradePrevention SelfTradePrevention `json:"self_trade_prevention"`
+	// fee rates in basis points that override the fee rates of the exchange parameters, not overridden if negative
+	MakerFeeRate int64 `json:"maker_fee_rate"`
+	TakerFeeRate int64 `json:"taker_fee_rate"`
+	// account that listed the market by deposit and the deposit that is kept while the market is listed
+	Lister  sdk.AccAddress `json:"lister"`
+	Deposit sdk.Coins      `json:"deposit"`
+	// a halted market rejects new orders and does not match, but still accepts cancels
+	Halted bool `json:"halted"`
+	// maximum distance of order prices from the last trade price in basis points, no price band if zero
+	PriceBand int64 `json:"price_band"`
+}
+
+// NewMarket creates a new unlisted market with whole prices and amounts, no minimum notional, cancel-newest self-trade
+// prevention and the fee rates of the exchange parameters
+func NewMarket(amountDenom string, priceDenom string, mode MarketMode) Market {
+	return Market{
+		AmountDenom:         amountDenom,
+		PriceDenom:          priceDenom,
+		Status:              UnlistedStatus,
+		Mode:                mode,
+		TickSize:            sdk.OneRat(),
+		LotSize:             sdk.OneInt(),
+		MinNotional:         sdk.ZeroInt(),
+		SelfTradePrevention: CancelNewest,
+		MakerFeeRate:        noFeeRateOverride,
+		TakerFeeRate:        noFeeRateOverride,
+	}
+}
+
+// IsListed checks if the market accepts orders
+func (m Market) IsListed() bool {
+	return m.Status == ListedStatus
+}
+
+var marketSubspace = []byte("market:")
+
+// Key for getting all markets from the store
+func MakeKeyMarketsSubspace() []byte {
+	return marketSubspace
+}
+
+// Key for getting the market of a token pair from the store
+func MakeKeyMarket(amountDenom string, priceDenom string) []byte {
+	return []byte(fmt.Sprintf("market:%v:%v", amountDenom, priceDenom))
+}
+
+// String provides a human-readable representation of a market
+func (m Market) String() string {
+	return fmt.Sprintf("Market{AmountDenom: %v, PriceDenom: %v, Status: %v, Mode: %v, TickSize: %v, LotSize: %v, "+
+		"MinNotional: %v, SelfTradePrevention: %v, MakerFeeRate: %v, TakerFeeRate: %v, Lister: %v, Deposit: %v, "+
+		"Halted: %v, PriceBand: %v}", m.AmountDenom, m.PriceDenom, m.Status, m.Mode, m.TickSize, m.LotSize,
+		m.MinNotional, m.SelfTradePrevention, m.MakerFeeRate, m.TakerFeeRate, m.Lister, m.Deposit, m.Halted,
+		m.PriceBand)
+}
+
+// validateMarket checks that the market has a valid token pair, status, mode, fee rates, tick size, lot size,
+// minimum notional, self-trade prevention mode and price band. The lot size times the tick size must be a whole
+// number, so that the total price of every valid order is exact
+func validateMarket(m Market) error {
+	if m.AmountDenom == "" || m.PriceDenom == "" || m.AmountDenom == m.PriceDenom {
+		return fmt.Errorf("market %v must have two different denoms", m.String())
+	}
+	if !isValidMarketMode(m.Mode) {
+		return fmt.Errorf("market %v has an invalid mode", m.String())
+	}
+	if !isValidMarketStatus(m.Status) {
+		return fmt.Errorf("market %v has an invalid status", m.String())
+	}
+	if m.MakerFeeRate < noFeeRateOverride || m.MakerFeeRate > feeRateDenominator ||
+		m.TakerFeeRate < noFeeRateOverride || m.TakerFeeRate > feeRateDenominator {
+		return fmt.Errorf("fee rates of market %v must be between 0 and %v or %v", m.String(), feeRateDenominator,
+			noFeeRateOverride)
+	}
+	if m.TickSize.Rat == nil || m.TickSize.Rat.Sign() <= 0 {
+		return fmt.Errorf("market %v must have a positive tick size", m.String())
+	}
+	if m.LotSize == (sdk.Int{}) || m.LotSize.Sign() <= 0 {
+		return fmt.Errorf("market %v must have a positive lot size", m.String())
+	}
+	if m.MinNotional == (sdk.Int{}) || m.MinNotional.Sign() < 0 {
+		return fmt.Errorf("market %v must not have a negative minimum notional", m.String())
+	}
+	if !isValidMarketSelfTradePrevention(m.SelfTradePrevention) {
+		return fmt.Errorf("market %v has an invalid self-trade prevention mode", m.String())
+	}
+	if m.PriceBand < 0 || m.PriceBand >= priceBandDenominator {
+		return fmt.Errorf("price band of market %v must be between 0 and %v", m.String(), priceBandDenominator-1)
+	}
+	if !m.TickSize.Mul(sdk.NewRatFromInt(m.LotSize)).Rat.IsInt() {
+		return fmt.Errorf("lot size times tick size of market %v must be a whole number", m.String())
+	}
+	return nil
+}
+
+// MarketListing is the configuration of a market that is requested to be listed. A governance proposal lists a
+// market if it is a passed text proposal with the listing proposal description of the market listing as description
+type MarketListing struct {
+	AmountDenom  string  `json:"amount_denom"`
+	PriceDenom   string  `json:"price_denom"`
+	TickSize     sdk.Rat `json:"tick_size"`
+	LotSize      sdk.Int `json:"lot_size"`
+	MinNotional  sdk.Int `json:"min_notional"`
+	MakerFeeRate int64   `json:"maker_fee_rate"` // not overridden if negative
+	TakerFeeRate int64   `json:"taker_fee_rate"` // not overridden if negative
+}
+
+// NewMarketListing creates a new market listing
+func NewMarketListing(amountDenom string, priceDenom string, tickSize sdk.Rat, lotSize sdk.Int, minNotional sdk.Int,
+	makerFeeRate int64, takerFeeRate int64) MarketListing {
+	return MarketListing{
+		AmountDenom:  amountDenom,
+		PriceDenom:   priceDenom,
+		TickSize:     tickSize,
+		LotSize:      lotSize,
+		MinNotional:  minNotional,
+		MakerFeeRate: makerFeeRate,
+		TakerFeeRate: takerFeeRate,
+	}
+}
+
+// HasFeeOverride checks if the listing overrides any fee rate of the exchange parameters
+func (ml MarketListing) HasFeeOverride() bool {
+	return ml.MakerFeeRate != noFeeRateOverride || ml.TakerFeeRate != noFeeRateOverride
+}
+
+// toMarket returns the listed market in continuous mode
+func (ml MarketListing) toMarket() Market {
+	market := NewMarket(ml.AmountDenom, ml.PriceDenom, ContinuousMode)
+	market.Status = ListedStatus
+	market.TickSize = ml.TickSize
+	market.LotSize = ml.LotSize
+	market.MinNotional = ml.MinNotional
+	market.MakerFeeRate = ml.MakerFeeRate
+	market.TakerFeeRate = ml.TakerFeeRate
+	return market
+}
+
+var usedListingProposalSubspace = []byte("usedListingProposal:")
+
+// Key for marking a listing proposal as used, so that it cannot list its market again after a delisting
+func MakeKeyUsedListingProposal(proposalID int64) []byte {
+	return []byte(fmt.Sprintf("usedListingProposal:%020d", proposalID))
+}
+
+// GetListingProposalDescription returns the description a governance proposal must have to list the market
+func GetListingProposalDescription(listing MarketListing) string {
+	b, err := json.Marshal(listing)
+	if err != nil {
+		panic(err)
+	}
+	return string(sdk.MustSortJSON(b))
+}
+
+// isInPriceBand checks if the price is within the price band of the market around the reference price
+func (m Market) isInPriceBand(price Price, reference Price) bool {
+	if m.PriceBand == 0 {
+		return true
+	}
+
+	distance := reference.Amount.Mul(sdk.NewRat(m.PriceBand, priceBandDenominator))
+	return !price.Amount.LT(reference.Amount.Sub(distance)) && !price.Amount.GT(reference.Amount.Add(distance))
+}
+
+// MarketHalt is the halt or resumption of a market. A governance proposal halts or resumes a market if it is a passed
+// text proposal with the halt proposal description of the market halt as description
+type MarketHalt struct {
+	AmountDenom string `json:"amount_denom"`
+	PriceDenom  string `json:"price_denom"`
+	Halted      bool   `json:"halted"`
+}
+
+// NewMarketHalt creates a new market halt, or resumption if halted is false
+func NewMarketHalt(amountDenom string, priceDenom string, halted bool) MarketHalt {
+	return MarketHalt{
+		AmountDenom: amountDenom,
+		PriceDenom:  priceDenom,
+		Halted:      halted,
+	}
+}
+
+var usedHaltProposalSubspace = []byte("usedHaltProposal:")
+
+// Key for marking a halt proposal as used, so that it cannot halt or resume its market again
+func MakeKeyUsedHaltProposal(proposalID int64) []byte {
+	return []byte(fmt.Sprintf("usedHaltProposal:%020d", proposalID))
+}
+
+// GetHaltProposalDescription returns the description a governance proposal must have to halt or resume the market
+func GetHaltProposalDescription(halt MarketHalt) string {
+	b, err := json.Marshal(halt)
+	if err != nil {
+		panic(err)
+	}
+	return string(sdk.MustSortJSON(b))
+}
+
+// GetListedMarkets returns the markets that are listed
+func GetListedMarkets(markets []Market) []Market {
+	listed := make([]Market, 0, len(markets))
+	for _, market := range markets {
+		if market.IsListed() {
+			listed = append(listed, market)
+		}
+	}
+	return listed
+}
@@ -0,0 +1,118 @@
+package exchange
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// MaxBatchOrderItems is the maximum number of creates and cancels in a single batch
+const MaxBatchOrderItems = 100
+
+// BatchOrderItem is a create or a cancel of a batch, exactly one of both must be set
+type BatchOrderItem struct {
+	Create *MsgCreateLimitOrder `json:"create,omitempty"`
+	Cancel *MsgCancelLimitOrder `json:"cancel,omitempty"`
+}
+
+// Batch type, the creates and cancels are applied in order. In atomic mode, either all items are applied or none
+type MsgBatchOrders struct {
+	Sender sdk.AccAddress
+	Items  []BatchOrderItem
+	Atomic bool
+}
+
+// new batch message
+func NewMsgBatchOrders(sender sdk.AccAddress, items []BatchOrderItem, atomic bool) MsgBatchOrders {
+	return MsgBatchOrders{
+		Sender: sender,
+		Items:  items,
+		Atomic: atomic,
+	}
+}
+
+// BatchOrderResult is the result of an item of a batch. A failed item has the code and log of its error instead
+type BatchOrderResult struct {
+	Processed *ProcessedLimitOrder `json:"processed,omitempty"`
+	Filled    []FilledLimitOrder   `json:"filled,omitempty"`
+	Inverted  bool                 `json:"inverted,omitempty"`
+	Cancelled *LimitOrder          `json:"cancelled,omitempty"`
+	// orders cancelled with the cancelled order because they are in the same one-cancels-other group
+	CancelledOCOOrderIDs []int64          `json:"cancelled_oco_orders,omitempty"`
+	Code                 sdk.ABCICodeType `json:"code,omitempty"`
+	Log                  string           `json:"log,omitempty"`
+}
+
+// enforce the msg type at compile time
+var _ sdk.Msg = MsgBatchOrders{}
+
+// Get MsgBatchOrders Type
+func (msg MsgBatchOrders) Type() string { return "exchange" }
+
+// Get Batch Signers
+func (msg MsgBatchOrders) GetSigners() []sdk.AccAddress {
+	return []sdk.AccAddress{msg.Sender}
+}
+
+func (msg MsgBatchOrders) String() string {
+	return fmt.Sprintf("MsgBatchOrders{Sender: %v, Items: %v, Atomic: %v}", msg.Sender, len(msg.Items), msg.Atomic)
+}
+
+// Validate Basic is used to quickly disqualify obviously invalid messages quickly
+func (msg MsgBatchOrders) ValidateBasic() sdk.Error {
+	if len(msg.Sender) == 0 {
+		return sdk.ErrUnknownAddress(msg.Sender.String()).TraceSDK("")
+	}
+
+	if len(msg.Items) == 0 || len(msg.Items) > MaxBatchOrderItems {
+		return ErrInvalidBatch(DefaultCodespace, fmt.Sprintf("batch must have between 1 and %v items",
+			MaxBatchOrderItems))
+	}
+
+	for i, item := range msg.Items {
+		var sender sdk.AccAddress
+		var err sdk.Error
+		switch {
+		case item.Create != nil && item.Cancel == nil:
+			sender, err = item.Create.Sender, item.Create.ValidateBasic()
+		case item.Cancel != nil && item.Create == nil:
+			sender, err = item.Cancel.Sender, item.Cancel.ValidateBasic()
+		default:
+			return ErrInvalidBatch(DefaultCodespace, fmt.Sprintf("item %v must be either a create or a cancel", i))
+		}
+
+		if err != nil {
+			return err
+		}
+
+		// the batch is only signed by its sender
+		if !bytes.Equal(sender, msg.Sender) {
+			return ErrInvalidBatch(DefaultCodespace, fmt.Sprintf("item %v must have the sender of the batch", i))
+		}
+	}
+
+	return nil
+}
+
+// Get the bytes for the message signer to sign on
+func (msg MsgBatchOrders) GetSignBytes() []byte {
+	// ensure expires at is in UTC to have deterministic sign bytes
+	msgUtc := msg
+	msgUtc.Items = make([]BatchOrderItem, len(msg.Items))
+	for i, item := range msg.Items {
+		if item.Create != nil {
+			create := *item.Create
+			create.ExpiresAt = create.ExpiresAt.UTC()
+			item.Create = &create
+		}
+		msgUtc.Items[i] = item
+	}
+
+	b, err := json.Marshal(msgUtc)
+	if err != nil {
+		panic(err)
+	}
+	return sdk.MustSortJSON(b)
+}
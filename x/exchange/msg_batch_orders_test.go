@@ -0,0 +1,126 @@
+package exchange
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+)
+
+// batchOrderTestResult holds the fields of a BatchOrderResult that are checked by the tests, prices cannot be parsed
+// from plain JSON
+type batchOrderTestResult struct {
+	Processed *struct {
+		OrderID       int64  `json:"order_id"`
+		ClientOrderID string `json:"client_order_id"`
+	} `json:"processed"`
+	Filled    []json.RawMessage `json:"filled"`
+	Cancelled *struct {
+		OrderID int64 `json:"order_id"`
+	} `json:"cancelled"`
+	Code sdk.ABCICodeType `json:"code"`
+	Log  string           `json:"log"`
+}
+
+// getBatchOrderResults parses the results of the log of a handled MsgBatchOrders
+func getBatchOrderResults(t *testing.T, res sdk.Result) []batchOrderTestResult {
+	var parsed struct {
+		Results []batchOrderTestResult `json:"results"`
+	}
+	err := json.Unmarshal([]byte(strings.TrimSuffix(strings.TrimPrefix(res.Log, "json"), "json")), &parsed)
+	require.Nil(t, err)
+	return parsed.Results
+}
+
+func TestMsgBatchOrdersValidateBasic(t *testing.T) {
+	sender := sdk.AccAddress([]byte("sender"))
+	create := NewMsgCreateLimitOrder(sender, SellOrder, sdk.NewInt64Coin("ETH", 10), NewInt64Price("RUNE", 5),
+		time.Time{}, 0, GoodTillCancelled, MarketDefaultSTP, sdk.ZeroInt(), "", "")
+	cancel := NewMsgCancelLimitOrder(sender, 1)
+	otherCancel := NewMsgCancelLimitOrder(sdk.AccAddress([]byte("other")), 1)
+	invalidCancel := NewMsgCancelLimitOrder(sender, 0)
+
+	require.Nil(t, NewMsgBatchOrders(sender, []BatchOrderItem{{Create: &create}, {Cancel: &cancel}}, true).
+		ValidateBasic())
+	require.Equal(t, CodeInvalidBatch, NewMsgBatchOrders(sender, []BatchOrderItem{}, false).ValidateBasic().Code())
+	require.Equal(t, CodeInvalidBatch, NewMsgBatchOrders(sender, []BatchOrderItem{{&create, &cancel}}, false).
+		ValidateBasic().Code())
+	require.Equal(t, CodeInvalidBatch, NewMsgBatchOrders(sender, []BatchOrderItem{{}}, false).ValidateBasic().Code())
+	require.Equal(t, CodeInvalidBatch, NewMsgBatchOrders(sender, []BatchOrderItem{{Cancel: &otherCancel}}, false).
+		ValidateBasic().Code())
+	require.Equal(t, CodeOrderNotFound, NewMsgBatchOrders(sender, []BatchOrderItem{{Cancel: &invalidCancel}}, false).
+		ValidateBasic().Code())
+
+	items := make([]BatchOrderItem, MaxBatchOrderItems+1)
+	for i := range items {
+		items[i].Cancel = &cancel
+	}
+	require.Equal(t, CodeInvalidBatch, NewMsgBatchOrders(sender, items, false).ValidateBasic().Code())
+}
+
+// Test if the items of a batch are applied in order and failed items are skipped, or fail the whole batch in atomic
+// mode
+func TestHandleMsgBatchOrders(t *testing.T) {
+	ctx := setupContext(exchangeKey)
+	keeper, _, bankKeeper, _, seller := setupKeepers(exchangeKey, ctx)
+	bankKeeper.SetCoins(ctx, seller, sdk.Coins{sdk.NewInt64Coin("ETH", 100)})
+	handler := NewHandler(keeper)
+
+	create := NewMsgCreateLimitOrder(seller, SellOrder, sdk.NewInt64Coin("ETH", 50), NewInt64Price("RUNE", 5),
+		time.Time{}, 0, GoodTillCancelled, MarketDefaultSTP, sdk.ZeroInt(), "quote-1", "")
+	tooLarge := NewMsgCreateLimitOrder(seller, SellOrder, sdk.NewInt64Coin("ETH", 100), NewInt64Price("RUNE", 6),
+		time.Time{}, 0, GoodTillCancelled, MarketDefaultSTP, sdk.ZeroInt(), "", "")
+	cancel := NewMsgCancelLimitOrderByClientOrderID(seller, "quote-1")
+	items := []BatchOrderItem{{Create: &create}, {Create: &tooLarge}, {Cancel: &cancel}}
+
+	// in atomic mode, the failing item fails the whole batch
+	res := handler(ctx, NewMsgBatchOrders(seller, items, true))
+	require.Equal(t, sdk.ErrInsufficientCoins("").Result().Code, res.Code)
+	require.True(t, strings.HasPrefix(res.Log, "item 1 of the batch failed"), res.Log)
+	require.Equal(t, "100ETH", bankKeeper.GetCoins(ctx, seller).String())
+	require.Len(t, keeper.getOpenLimitOrdersBySender(ctx, seller), 0)
+	_, err := keeper.getOrderIDByClientOrderID(ctx, seller, "quote-1")
+	require.Equal(t, CodeClientIDNotFound, err.Code())
+
+	// otherwise, the failing item is reported and the other items are applied
+	res = handler(ctx, NewMsgBatchOrders(seller, items, false))
+	require.True(t, res.IsOK(), res.Log)
+	results := getBatchOrderResults(t, res)
+	require.Len(t, results, 3)
+	require.NotNil(t, results[0].Processed)
+	require.Equal(t, "quote-1", results[0].Processed.ClientOrderID)
+	require.Nil(t, results[1].Processed)
+	require.Equal(t, sdk.ErrInsufficientCoins("").Result().Code, results[1].Code)
+	require.NotEmpty(t, results[1].Log)
+	require.NotNil(t, results[2].Cancelled)
+	require.Equal(t, results[0].Processed.OrderID, results[2].Cancelled.OrderID)
+
+	require.Equal(t, "100ETH", bankKeeper.GetCoins(ctx, seller).String())
+	require.Len(t, keeper.getOpenLimitOrdersBySender(ctx, seller), 0)
+}
+
+// Test if a batch is charged gas for each of its items and fills
+func TestHandleMsgBatchOrdersGas(t *testing.T) {
+	ctx, keeper, _, buyer, _, _, _, _, _ := setupCreateBuyLimitOrderTest()
+	handler := NewHandler(keeper)
+
+	create := NewMsgCreateLimitOrder(buyer, BuyOrder, sdk.NewInt64Coin("ETH", 10), NewInt64Price("RUNE", 3),
+		time.Time{}, 0, GoodTillCancelled, MarketDefaultSTP, sdk.ZeroInt(), "", "")
+	taker := NewMsgCreateLimitOrder(buyer, BuyOrder, sdk.NewInt64Coin("ETH", 200), NewInt64Price("RUNE", 7),
+		time.Now().Add(time.Minute).UTC(), 0, ImmediateOrCancel, MarketDefaultSTP, sdk.ZeroInt(), "", "")
+
+	ctx = ctx.WithGasMeter(sdk.NewGasMeter(1000000))
+	res := handler(ctx, NewMsgBatchOrders(buyer, []BatchOrderItem{{Create: &create}}, false))
+	require.True(t, res.IsOK(), res.Log)
+	single := ctx.GasMeter().GasConsumed()
+	require.True(t, single >= batchItemGas)
+
+	ctx = ctx.WithGasMeter(sdk.NewGasMeter(1000000))
+	res = handler(ctx, NewMsgBatchOrders(buyer, []BatchOrderItem{{Create: &create}, {Create: &taker}}, false))
+	require.True(t, res.IsOK(), res.Log)
+	require.Len(t, getBatchOrderResults(t, res)[1].Filled, 2)
+	require.True(t, ctx.GasMeter().GasConsumed() >= single+batchItemGas+2*batchFillGas)
+}
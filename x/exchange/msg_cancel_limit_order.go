@@ -0,0 +1,76 @@
+package exchange
+
+import (
+	"encoding/json"
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// Cancel type, the order is given either by order id or by client order id
+type MsgCancelLimitOrder struct {
+	Sender        sdk.AccAddress
+	OrderID       int64
+	ClientOrderID string
+}
+
+// new cancel message
+func NewMsgCancelLimitOrder(sender sdk.AccAddress, orderID int64) MsgCancelLimitOrder {
+	return MsgCancelLimitOrder{
+		Sender:  sender,
+		OrderID: orderID,
+	}
+}
+
+// new cancel message for the order of the sender with the client order id
+func NewMsgCancelLimitOrderByClientOrderID(sender sdk.AccAddress, clientOrderID string) MsgCancelLimitOrder {
+	return MsgCancelLimitOrder{
+		Sender:        sender,
+		ClientOrderID: clientOrderID,
+	}
+}
+
+// enforce the msg type at compile time
+var _ sdk.Msg = MsgCancelLimitOrder{}
+
+//Get MsgCancel Type
+func (msg MsgCancelLimitOrder) Type() string { return "exchange" }
+
+//Get Cancel Signers
+func (msg MsgCancelLimitOrder) GetSigners() []sdk.AccAddress {
+	return []sdk.AccAddress{msg.Sender}
+}
+
+func (msg MsgCancelLimitOrder) String() string {
+	return fmt.Sprintf("MsgCancelLimitOrder{Sender: %v, OrderID: %v, ClientOrderID: %v}", msg.Sender, msg.OrderID,
+		msg.ClientOrderID)
+}
+
+// Validate Basic is used to quickly disqualify obviously invalid messages quickly
+func (msg MsgCancelLimitOrder) ValidateBasic() sdk.Error {
+	if msg.ClientOrderID != "" {
+		if msg.OrderID != 0 {
+			return sdk.ErrUnknownRequest("order must be given either by order id or by client order id")
+		}
+		if len(msg.ClientOrderID) > MaxClientOrderIDLength {
+			return ErrInvalidClientOrderID(DefaultCodespace)
+		}
+	} else if msg.OrderID <= 0 {
+		return ErrOrderNotFound(DefaultCodespace, msg.OrderID)
+	}
+
+	if len(msg.Sender) == 0 {
+		return sdk.ErrUnknownAddress(msg.Sender.String()).TraceSDK("")
+	}
+
+	return nil
+}
+
+// Get the bytes for the message signer to sign on
+func (msg MsgCancelLimitOrder) GetSignBytes() []byte {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		panic(err)
+	}
+	return sdk.MustSortJSON(b)
+}
@@ -0,0 +1,59 @@
+package exchange
+
+import (
+	"encoding/json"
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// Cancel trailing stop type
+type MsgCancelTrailingStop struct {
+	Sender sdk.AccAddress
+	StopID int64
+}
+
+// new cancel trailing stop message
+func NewMsgCancelTrailingStop(sender sdk.AccAddress, stopID int64) MsgCancelTrailingStop {
+	return MsgCancelTrailingStop{
+		Sender: sender,
+		StopID: stopID,
+	}
+}
+
+// enforce the msg type at compile time
+var _ sdk.Msg = MsgCancelTrailingStop{}
+
+// Get MsgCancelTrailingStop Type
+func (msg MsgCancelTrailingStop) Type() string { return "exchange" }
+
+// Get Cancel Trailing Stop Signers
+func (msg MsgCancelTrailingStop) GetSigners() []sdk.AccAddress {
+	return []sdk.AccAddress{msg.Sender}
+}
+
+func (msg MsgCancelTrailingStop) String() string {
+	return fmt.Sprintf("MsgCancelTrailingStop{Sender: %v, StopID: %v}", msg.Sender, msg.StopID)
+}
+
+// Validate Basic is used to quickly disqualify obviously invalid messages quickly
+func (msg MsgCancelTrailingStop) ValidateBasic() sdk.Error {
+	if msg.StopID <= 0 {
+		return ErrTrailingStopNotFound(DefaultCodespace, msg.StopID)
+	}
+
+	if len(msg.Sender) == 0 {
+		return sdk.ErrUnknownAddress(msg.Sender.String()).TraceSDK("")
+	}
+
+	return nil
+}
+
+// Get the bytes for the message signer to sign on
+func (msg MsgCancelTrailingStop) GetSignBytes() []byte {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		panic(err)
+	}
+	return sdk.MustSortJSON(b)
+}
@@ -10,22 +10,35 @@ import (
 
 // Create type
 type MsgCreateLimitOrder struct {
-	Sender    sdk.AccAddress
-	Kind      OrderKind
-	Amount    sdk.Coin
-	Price     sdk.Coin
-	ExpiresAt time.Time
+	Sender              sdk.AccAddress
+	Kind                OrderKind
+	Amount              sdk.Coin
+	Price               Price
+	ExpiresAt           time.Time // zero if the order does not expire at a time
+	ExpiresAtHeight     int64     // zero if the order does not expire at a block height
+	TimeInForce         TimeInForce
+	SelfTradePrevention SelfTradePrevention
+	DisplayAmount       sdk.Int // shown slice of an iceberg order, zero to show the whole amount
+	ClientOrderID       string  // id chosen by the sender, unique per sender, empty if not used
+	OCOGroup            string  // one-cancels-other group chosen by the sender, empty if not used
 }
 
 // new create message
-func NewMsgCreateLimitOrder(
-	sender sdk.AccAddress, kind OrderKind, amount sdk.Coin, price sdk.Coin, expiresAt time.Time) MsgCreateLimitOrder {
+func NewMsgCreateLimitOrder(sender sdk.AccAddress, kind OrderKind, amount sdk.Coin, price Price,
+	expiresAt time.Time, expiresAtHeight int64, timeInForce TimeInForce, stp SelfTradePrevention,
+	displayAmount sdk.Int, clientOrderID string, ocoGroup string) MsgCreateLimitOrder {
 	return MsgCreateLimitOrder{
-		Sender:    sender,
-		Kind:      kind,
-		Amount:    amount,
-		Price:     price,
-		ExpiresAt: expiresAt,
+		Sender:              sender,
+		Kind:                kind,
+		Amount:              amount,
+		Price:               price,
+		ExpiresAt:           expiresAt,
+		ExpiresAtHeight:     expiresAtHeight,
+		TimeInForce:         timeInForce,
+		SelfTradePrevention: stp,
+		DisplayAmount:       displayAmount,
+		ClientOrderID:       clientOrderID,
+		OCOGroup:            ocoGroup,
 	}
 }
 
@@ -43,6 +56,24 @@ func ParseKind(str string) (OrderKind, error) {
 	return 0x03, ErrInvalidKind(DefaultCodespace)
 }
 
+// Parser for TimeInForce. Returns an error if str is none of "gtt", "ioc", "fok", "post-only" or "gtc". An empty string
+// defaults to good-till-time.
+func ParseTimeInForce(str string) (TimeInForce, error) {
+	switch str {
+	case "", "gtt":
+		return GoodTillTime, nil
+	case "ioc":
+		return ImmediateOrCancel, nil
+	case "fok":
+		return FillOrKill, nil
+	case "post-only":
+		return PostOnly, nil
+	case "gtc":
+		return GoodTillCancelled, nil
+	}
+	return 0x05, ErrInvalidTimeInForce(DefaultCodespace)
+}
+
 //Get MsgCreate Type
 func (msg MsgCreateLimitOrder) Type() string { return "exchange" }
 
@@ -52,8 +83,11 @@ func (msg MsgCreateLimitOrder) GetSigners() []sdk.AccAddress {
 }
 
 func (msg MsgCreateLimitOrder) String() string {
-	return fmt.Sprintf("MsgCreateLimitOrder{Sender: %v, Kind: %v, Amount: %v, Price: %v, ExpiresAt: %v}",
-		msg.Sender, msg.Kind, msg.Amount, msg.Price, msg.ExpiresAt)
+	return fmt.Sprintf(
+		"MsgCreateLimitOrder{Sender: %v, Kind: %v, Amount: %v, Price: %v, ExpiresAt: %v, ExpiresAtHeight: %v, "+
+			"TimeInForce: %v, SelfTradePrevention: %v, DisplayAmount: %v, ClientOrderID: %v, OCOGroup: %v}",
+		msg.Sender, msg.Kind, msg.Amount, msg.Price, msg.ExpiresAt, msg.ExpiresAtHeight, msg.TimeInForce,
+		msg.SelfTradePrevention, msg.DisplayAmount, msg.ClientOrderID, msg.OCOGroup)
 }
 
 // Validate Basic is used to quickly disqualify obviously invalid messages quickly
@@ -62,19 +96,63 @@ func (msg MsgCreateLimitOrder) ValidateBasic() sdk.Error {
 		return ErrInvalidKind(DefaultCodespace)
 	}
 
+	if !isValidTimeInForce(msg.TimeInForce) {
+		return ErrInvalidTimeInForce(DefaultCodespace)
+	}
+
+	if !isValidSelfTradePrevention(msg.SelfTradePrevention) {
+		return ErrInvalidSelfTradePrevention(DefaultCodespace)
+	}
+
 	if msg.Amount.Denom == msg.Price.Denom {
 		return ErrSameDenom(DefaultCodespace)
 	}
 
-	// if rune is involved, it always must be the price denom, otherwise denoms must be sorted
-	if msg.Amount.Denom == "RUNE" || (msg.Price.Denom != "RUNE" && msg.Amount.Denom < msg.Price.Denom) {
-		return ErrOrderBookDirection(DefaultCodespace)
+	if !msg.Amount.IsPositive() {
+		return ErrAmountNotPositive(DefaultCodespace)
+	}
+
+	if !msg.Price.IsPositive() {
+		return ErrPriceNotPositive(DefaultCodespace)
+	}
+
+	// orders in the other direction than the order book must be convertible
+	_, _, _, _, err := NormalizeOrder(msg.Kind, msg.Amount, msg.Price)
+	if err != nil {
+		return err
+	}
+
+	// an unset display amount shows the whole amount
+	if msg.DisplayAmount != (sdk.Int{}) {
+		if msg.DisplayAmount.Sign() < 0 {
+			return ErrInvalidDisplayAmount(DefaultCodespace)
+		}
+
+		_, err = NormalizeDisplayAmount(msg.DisplayAmount, msg.Amount, msg.Price)
+		if err != nil {
+			return err
+		}
 	}
 
-	if msg.ExpiresAt.Before(time.Now()) {
+	err = checkExpiry(DefaultCodespace, msg.ExpiresAt, msg.ExpiresAtHeight, msg.TimeInForce)
+	if err != nil {
+		return err
+	}
+
+	// the expiry height can only be checked against the current height when the order is processed
+	if !msg.ExpiresAt.IsZero() && msg.ExpiresAt.Before(time.Now()) {
 		return ErrOrderExpired(DefaultCodespace)
 	}
 
+	if len(msg.ClientOrderID) > MaxClientOrderIDLength {
+		return ErrInvalidClientOrderID(DefaultCodespace)
+	}
+
+	if len(msg.OCOGroup) > MaxOCOGroupLength {
+		return ErrInvalidOCOGroup(DefaultCodespace,
+			fmt.Sprintf("one-cancels-other group must not be longer than %v characters", MaxOCOGroupLength))
+	}
+
 	if len(msg.Sender) == 0 {
 		return sdk.ErrUnknownAddress(msg.Sender.String()).TraceSDK("")
 	}
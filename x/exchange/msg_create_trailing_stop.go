@@ -0,0 +1,76 @@
+package exchange
+
+import (
+	"encoding/json"
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// Create trailing stop type, exactly one of trailing offset and trailing rate must be set
+type MsgCreateTrailingStop struct {
+	Sender         sdk.AccAddress
+	Kind           OrderKind
+	Amount         sdk.Coin
+	PriceDenom     string
+	TrailingOffset sdk.Rat // distance of the trigger price from the best price in the price denom
+	TrailingRate   int64   // distance of the trigger price from the best price in basis points
+	Market         bool    // submit a market order when triggered instead of a limit order
+	LimitOffset    sdk.Rat // distance of the limit price from the trigger price, zero for market stops
+}
+
+// new create trailing stop message
+func NewMsgCreateTrailingStop(sender sdk.AccAddress, kind OrderKind, amount sdk.Coin, priceDenom string,
+	trailingOffset sdk.Rat, trailingRate int64, market bool, limitOffset sdk.Rat) MsgCreateTrailingStop {
+	return MsgCreateTrailingStop{
+		Sender:         sender,
+		Kind:           kind,
+		Amount:         amount,
+		PriceDenom:     priceDenom,
+		TrailingOffset: trailingOffset,
+		TrailingRate:   trailingRate,
+		Market:         market,
+		LimitOffset:    limitOffset,
+	}
+}
+
+// enforce the msg type at compile time
+var _ sdk.Msg = MsgCreateTrailingStop{}
+
+// Get MsgCreateTrailingStop Type
+func (msg MsgCreateTrailingStop) Type() string { return "exchange" }
+
+// Get Create Trailing Stop Signers
+func (msg MsgCreateTrailingStop) GetSigners() []sdk.AccAddress {
+	return []sdk.AccAddress{msg.Sender}
+}
+
+func (msg MsgCreateTrailingStop) String() string {
+	return fmt.Sprintf("MsgCreateTrailingStop{Sender: %v, Kind: %v, Amount: %v, PriceDenom: %v, TrailingOffset: %v, "+
+		"TrailingRate: %v, Market: %v, LimitOffset: %v}", msg.Sender, msg.Kind, msg.Amount, msg.PriceDenom,
+		msg.TrailingOffset, msg.TrailingRate, msg.Market, msg.LimitOffset)
+}
+
+// Validate Basic is used to quickly disqualify obviously invalid messages quickly
+func (msg MsgCreateTrailingStop) ValidateBasic() sdk.Error {
+	err := checkTrailingStop(DefaultCodespace, msg.Kind, msg.Amount, msg.PriceDenom, msg.TrailingOffset,
+		msg.TrailingRate, msg.Market, msg.LimitOffset)
+	if err != nil {
+		return err
+	}
+
+	if len(msg.Sender) == 0 {
+		return sdk.ErrUnknownAddress(msg.Sender.String()).TraceSDK("")
+	}
+
+	return nil
+}
+
+// Get the bytes for the message signer to sign on
+func (msg MsgCreateTrailingStop) GetSignBytes() []byte {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		panic(err)
+	}
+	return sdk.MustSortJSON(b)
+}
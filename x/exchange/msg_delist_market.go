@@ -0,0 +1,62 @@
+package exchange
+
+import (
+	"encoding/json"
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// Delist market type, unlists a market by its lister or the authority and refunds the listing deposit
+type MsgDelistMarket struct {
+	Sender      sdk.AccAddress
+	AmountDenom string
+	PriceDenom  string
+}
+
+// new delist market message
+func NewMsgDelistMarket(sender sdk.AccAddress, amountDenom string, priceDenom string) MsgDelistMarket {
+	return MsgDelistMarket{
+		Sender:      sender,
+		AmountDenom: amountDenom,
+		PriceDenom:  priceDenom,
+	}
+}
+
+// enforce the msg type at compile time
+var _ sdk.Msg = MsgDelistMarket{}
+
+//Get MsgDelistMarket Type
+func (msg MsgDelistMarket) Type() string { return "exchange" }
+
+//Get DelistMarket Signers
+func (msg MsgDelistMarket) GetSigners() []sdk.AccAddress {
+	return []sdk.AccAddress{msg.Sender}
+}
+
+func (msg MsgDelistMarket) String() string {
+	return fmt.Sprintf("MsgDelistMarket{Sender: %v, AmountDenom: %v, PriceDenom: %v}", msg.Sender, msg.AmountDenom,
+		msg.PriceDenom)
+}
+
+// Validate Basic is used to quickly disqualify obviously invalid messages quickly
+func (msg MsgDelistMarket) ValidateBasic() sdk.Error {
+	if msg.AmountDenom == msg.PriceDenom {
+		return ErrSameDenom(DefaultCodespace)
+	}
+
+	if len(msg.Sender) == 0 {
+		return sdk.ErrUnknownAddress(msg.Sender.String()).TraceSDK("")
+	}
+
+	return nil
+}
+
+// Get the bytes for the message signer to sign on
+func (msg MsgDelistMarket) GetSignBytes() []byte {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		panic(err)
+	}
+	return sdk.MustSortJSON(b)
+}
@@ -0,0 +1,81 @@
+package exchange
+
+import (
+	"encoding/json"
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// List market type, lists a market either by a passed governance proposal or by a listing deposit
+type MsgListMarket struct {
+	Sender     sdk.AccAddress
+	Listing    MarketListing
+	ProposalID int64 // id of the passed governance proposal, the listing deposit is paid if zero
+}
+
+// new list market message
+func NewMsgListMarket(sender sdk.AccAddress, listing MarketListing, proposalID int64) MsgListMarket {
+	return MsgListMarket{
+		Sender:     sender,
+		Listing:    listing,
+		ProposalID: proposalID,
+	}
+}
+
+// enforce the msg type at compile time
+var _ sdk.Msg = MsgListMarket{}
+
+//Get MsgListMarket Type
+func (msg MsgListMarket) Type() string { return "exchange" }
+
+//Get ListMarket Signers
+func (msg MsgListMarket) GetSigners() []sdk.AccAddress {
+	return []sdk.AccAddress{msg.Sender}
+}
+
+func (msg MsgListMarket) String() string {
+	return fmt.Sprintf("MsgListMarket{Sender: %v, Listing: %v, ProposalID: %v}", msg.Sender,
+		GetListingProposalDescription(msg.Listing), msg.ProposalID)
+}
+
+// Validate Basic is used to quickly disqualify obviously invalid messages quickly
+func (msg MsgListMarket) ValidateBasic() sdk.Error {
+	if msg.Listing.AmountDenom == msg.Listing.PriceDenom {
+		return ErrSameDenom(DefaultCodespace)
+	}
+
+	// if rune is involved, it always must be the price denom, otherwise denoms must be sorted
+	if msg.Listing.AmountDenom == "RUNE" ||
+		(msg.Listing.PriceDenom != "RUNE" && msg.Listing.AmountDenom < msg.Listing.PriceDenom) {
+		return ErrOrderBookDirection(DefaultCodespace)
+	}
+
+	err := validateMarket(msg.Listing.toMarket())
+	if err != nil {
+		return ErrInvalidMarket(DefaultCodespace, err.Error())
+	}
+
+	if msg.ProposalID < 0 {
+		return ErrInvalidListing(DefaultCodespace, "proposal id must not be negative")
+	}
+
+	if msg.ProposalID == 0 && msg.Listing.HasFeeOverride() {
+		return ErrInvalidListing(DefaultCodespace, "fee rates can only be overridden by a governance proposal")
+	}
+
+	if len(msg.Sender) == 0 {
+		return sdk.ErrUnknownAddress(msg.Sender.String()).TraceSDK("")
+	}
+
+	return nil
+}
+
+// Get the bytes for the message signer to sign on
+func (msg MsgListMarket) GetSignBytes() []byte {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		panic(err)
+	}
+	return sdk.MustSortJSON(b)
+}
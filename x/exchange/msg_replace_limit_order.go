@@ -0,0 +1,77 @@
+package exchange
+
+import (
+	"encoding/json"
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// Replace type, changes price and/or amount of a stored limit order
+type MsgReplaceLimitOrder struct {
+	Sender  sdk.AccAddress
+	OrderID int64
+	Amount  sdk.Coin
+	Price   Price
+}
+
+// new replace message
+func NewMsgReplaceLimitOrder(sender sdk.AccAddress, orderID int64, amount sdk.Coin, price Price,
+) MsgReplaceLimitOrder {
+	return MsgReplaceLimitOrder{
+		Sender:  sender,
+		OrderID: orderID,
+		Amount:  amount,
+		Price:   price,
+	}
+}
+
+// enforce the msg type at compile time
+var _ sdk.Msg = MsgReplaceLimitOrder{}
+
+//Get MsgReplace Type
+func (msg MsgReplaceLimitOrder) Type() string { return "exchange" }
+
+//Get Replace Signers
+func (msg MsgReplaceLimitOrder) GetSigners() []sdk.AccAddress {
+	return []sdk.AccAddress{msg.Sender}
+}
+
+func (msg MsgReplaceLimitOrder) String() string {
+	return fmt.Sprintf("MsgReplaceLimitOrder{Sender: %v, OrderID: %v, Amount: %v, Price: %v}",
+		msg.Sender, msg.OrderID, msg.Amount, msg.Price)
+}
+
+// Validate Basic is used to quickly disqualify obviously invalid messages quickly
+func (msg MsgReplaceLimitOrder) ValidateBasic() sdk.Error {
+	if msg.OrderID <= 0 {
+		return ErrOrderNotFound(DefaultCodespace, msg.OrderID)
+	}
+
+	if msg.Amount.Denom == msg.Price.Denom {
+		return ErrSameDenom(DefaultCodespace)
+	}
+
+	if !msg.Amount.IsPositive() {
+		return ErrAmountNotPositive(DefaultCodespace)
+	}
+
+	if !msg.Price.IsPositive() {
+		return ErrPriceNotPositive(DefaultCodespace)
+	}
+
+	if len(msg.Sender) == 0 {
+		return sdk.ErrUnknownAddress(msg.Sender.String()).TraceSDK("")
+	}
+
+	return nil
+}
+
+// Get the bytes for the message signer to sign on
+func (msg MsgReplaceLimitOrder) GetSignBytes() []byte {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		panic(err)
+	}
+	return sdk.MustSortJSON(b)
+}
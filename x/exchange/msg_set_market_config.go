@@ -0,0 +1,84 @@
+package exchange
+
+import (
+	"encoding/json"
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// Set market config type, changes tick size, lot size, minimum notional, default self-trade prevention mode and price
+// band of a market
+type MsgSetMarketConfig struct {
+	Sender              sdk.AccAddress
+	AmountDenom         string
+	PriceDenom          string
+	TickSize            sdk.Rat
+	LotSize             sdk.Int
+	MinNotional         sdk.Int
+	SelfTradePrevention SelfTradePrevention
+	PriceBand           int64
+}
+
+// new set market config message
+func NewMsgSetMarketConfig(sender sdk.AccAddress, amountDenom string, priceDenom string, tickSize sdk.Rat,
+	lotSize sdk.Int, minNotional sdk.Int, stp SelfTradePrevention, priceBand int64) MsgSetMarketConfig {
+	return MsgSetMarketConfig{
+		Sender:              sender,
+		AmountDenom:         amountDenom,
+		PriceDenom:          priceDenom,
+		TickSize:            tickSize,
+		LotSize:             lotSize,
+		MinNotional:         minNotional,
+		SelfTradePrevention: stp,
+		PriceBand:           priceBand,
+	}
+}
+
+// enforce the msg type at compile time
+var _ sdk.Msg = MsgSetMarketConfig{}
+
+//Get MsgSetMarketConfig Type
+func (msg MsgSetMarketConfig) Type() string { return "exchange" }
+
+//Get SetMarketConfig Signers
+func (msg MsgSetMarketConfig) GetSigners() []sdk.AccAddress {
+	return []sdk.AccAddress{msg.Sender}
+}
+
+func (msg MsgSetMarketConfig) String() string {
+	return fmt.Sprintf(
+		"MsgSetMarketConfig{Sender: %v, AmountDenom: %v, PriceDenom: %v, TickSize: %v, LotSize: %v, MinNotional: %v, "+
+			"SelfTradePrevention: %v, PriceBand: %v}", msg.Sender, msg.AmountDenom, msg.PriceDenom, msg.TickSize,
+		msg.LotSize, msg.MinNotional, msg.SelfTradePrevention, msg.PriceBand)
+}
+
+// Validate Basic is used to quickly disqualify obviously invalid messages quickly
+func (msg MsgSetMarketConfig) ValidateBasic() sdk.Error {
+	market := NewMarket(msg.AmountDenom, msg.PriceDenom, ContinuousMode)
+	market.TickSize = msg.TickSize
+	market.LotSize = msg.LotSize
+	market.MinNotional = msg.MinNotional
+	market.SelfTradePrevention = msg.SelfTradePrevention
+	market.PriceBand = msg.PriceBand
+
+	err := validateMarket(market)
+	if err != nil {
+		return ErrInvalidMarket(DefaultCodespace, err.Error())
+	}
+
+	if len(msg.Sender) == 0 {
+		return sdk.ErrUnknownAddress(msg.Sender.String()).TraceSDK("")
+	}
+
+	return nil
+}
+
+// Get the bytes for the message signer to sign on
+func (msg MsgSetMarketConfig) GetSignBytes() []byte {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		panic(err)
+	}
+	return sdk.MustSortJSON(b)
+}
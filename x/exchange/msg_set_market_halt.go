@@ -0,0 +1,71 @@
+package exchange
+
+import (
+	"encoding/json"
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// Set market halt type, halts or resumes a market either by the authority or by a passed governance proposal
+type MsgSetMarketHalt struct {
+	Sender      sdk.AccAddress
+	AmountDenom string
+	PriceDenom  string
+	Halted      bool
+	ProposalID  int64 // id of the passed governance proposal, only the authority may halt or resume if zero
+}
+
+// new set market halt message
+func NewMsgSetMarketHalt(sender sdk.AccAddress, amountDenom string, priceDenom string, halted bool,
+	proposalID int64) MsgSetMarketHalt {
+	return MsgSetMarketHalt{
+		Sender:      sender,
+		AmountDenom: amountDenom,
+		PriceDenom:  priceDenom,
+		Halted:      halted,
+		ProposalID:  proposalID,
+	}
+}
+
+// enforce the msg type at compile time
+var _ sdk.Msg = MsgSetMarketHalt{}
+
+//Get MsgSetMarketHalt Type
+func (msg MsgSetMarketHalt) Type() string { return "exchange" }
+
+//Get SetMarketHalt Signers
+func (msg MsgSetMarketHalt) GetSigners() []sdk.AccAddress {
+	return []sdk.AccAddress{msg.Sender}
+}
+
+func (msg MsgSetMarketHalt) String() string {
+	return fmt.Sprintf("MsgSetMarketHalt{Sender: %v, AmountDenom: %v, PriceDenom: %v, Halted: %v, ProposalID: %v}",
+		msg.Sender, msg.AmountDenom, msg.PriceDenom, msg.Halted, msg.ProposalID)
+}
+
+// Validate Basic is used to quickly disqualify obviously invalid messages quickly
+func (msg MsgSetMarketHalt) ValidateBasic() sdk.Error {
+	if msg.AmountDenom == msg.PriceDenom {
+		return ErrSameDenom(DefaultCodespace)
+	}
+
+	if msg.ProposalID < 0 {
+		return ErrInvalidHalt(DefaultCodespace, "proposal id must not be negative")
+	}
+
+	if len(msg.Sender) == 0 {
+		return sdk.ErrUnknownAddress(msg.Sender.String()).TraceSDK("")
+	}
+
+	return nil
+}
+
+// Get the bytes for the message signer to sign on
+func (msg MsgSetMarketHalt) GetSignBytes() []byte {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		panic(err)
+	}
+	return sdk.MustSortJSON(b)
+}
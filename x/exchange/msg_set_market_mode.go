@@ -0,0 +1,69 @@
+package exchange
+
+import (
+	"encoding/json"
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// Set market mode type, switches a market between continuous matching and batch auctions
+type MsgSetMarketMode struct {
+	Sender      sdk.AccAddress
+	AmountDenom string
+	PriceDenom  string
+	Mode        MarketMode
+}
+
+// new set market mode message
+func NewMsgSetMarketMode(sender sdk.AccAddress, amountDenom string, priceDenom string, mode MarketMode,
+) MsgSetMarketMode {
+	return MsgSetMarketMode{
+		Sender:      sender,
+		AmountDenom: amountDenom,
+		PriceDenom:  priceDenom,
+		Mode:        mode,
+	}
+}
+
+// enforce the msg type at compile time
+var _ sdk.Msg = MsgSetMarketMode{}
+
+//Get MsgSetMarketMode Type
+func (msg MsgSetMarketMode) Type() string { return "exchange" }
+
+//Get SetMarketMode Signers
+func (msg MsgSetMarketMode) GetSigners() []sdk.AccAddress {
+	return []sdk.AccAddress{msg.Sender}
+}
+
+func (msg MsgSetMarketMode) String() string {
+	return fmt.Sprintf("MsgSetMarketMode{Sender: %v, AmountDenom: %v, PriceDenom: %v, Mode: %v}",
+		msg.Sender, msg.AmountDenom, msg.PriceDenom, msg.Mode)
+}
+
+// Validate Basic is used to quickly disqualify obviously invalid messages quickly
+func (msg MsgSetMarketMode) ValidateBasic() sdk.Error {
+	if msg.AmountDenom == msg.PriceDenom {
+		return ErrSameDenom(DefaultCodespace)
+	}
+
+	if !isValidMarketMode(msg.Mode) {
+		return ErrInvalidMarketMode(DefaultCodespace)
+	}
+
+	if len(msg.Sender) == 0 {
+		return sdk.ErrUnknownAddress(msg.Sender.String()).TraceSDK("")
+	}
+
+	return nil
+}
+
+// Get the bytes for the message signer to sign on
+func (msg MsgSetMarketMode) GetSignBytes() []byte {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		panic(err)
+	}
+	return sdk.MustSortJSON(b)
+}
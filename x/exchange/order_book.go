@@ -30,6 +30,11 @@ func NewOrderBook(kind OrderKind, amountDenom string, priceDenom string) OrderBo
 	return newOrderBook
 }
 
+// Key for getting all order books from the store
+func MakeKeyOrderBooksSubspace() []byte {
+	return orderBookSubspace
+}
+
 // Key for getting a specific order book from the store
 func MakeKeyOrderBook(kind OrderKind, amountDenom string, priceDenom string) []byte {
 	return []byte(fmt.Sprintf("orderBook:%v:%v:%v", kind, amountDenom, priceDenom))
@@ -94,6 +99,13 @@ func (ob *OrderBook) AddLimitOrder(limitOrder LimitOrder) sdk.Error {
 	return nil
 }
 
+// RemoveLimitOrder removes the limit order at index i, keeping the order of all other limit orders
+func (ob *OrderBook) RemoveLimitOrder(i int) {
+	newOrders := make([]LimitOrder, 0, len(ob.Orders)-1)
+	newOrders = append(newOrders, ob.Orders[:i]...)
+	ob.Orders = append(newOrders, ob.Orders[i+1:]...)
+}
+
 // Removes limit orders that are filled (amount is zero), useful for efficient cleanup after order matching
 func (ob *OrderBook) RemoveFilledLimitOrders() {
 	// New orders list
@@ -113,7 +125,7 @@ func (ob *OrderBook) RemoveFilledLimitOrders() {
 }
 
 // In a buy orderbook, highest prices come first, in a sell orderbook, lowest come first.
-func shouldInsertBefore(kind OrderKind, order1Price sdk.Coin, order2Price sdk.Coin) bool {
+func shouldInsertBefore(kind OrderKind, order1Price Price, order2Price Price) bool {
 	if kind == BuyOrder {
 		if !order2Price.IsGTE(order1Price) {
 			return true
@@ -15,7 +15,7 @@ func TestAddLimitOrderToEmptyBuyOrderBook(t *testing.T) {
 		OrderID: 4,
 		Kind:    BuyOrder,
 		Amount:  sdk.NewInt64Coin("ETH", 60),
-		Price:   sdk.NewInt64Coin("BTC", 150),
+		Price:   NewInt64Price("BTC", 150),
 	}
 
 	err := orderBook1.AddLimitOrder(lo)
@@ -33,19 +33,19 @@ func TestAddLimitOrderToBuyOrderBook(t *testing.T) {
 		OrderID: 1,
 		Kind:    BuyOrder,
 		Amount:  sdk.NewInt64Coin("ETH", 80),
-		Price:   sdk.NewInt64Coin("BTC", 180),
+		Price:   NewInt64Price("BTC", 180),
 	}
 	lo2 := LimitOrder{
 		OrderID: 2,
 		Kind:    BuyOrder,
 		Amount:  sdk.NewInt64Coin("ETH", 20),
-		Price:   sdk.NewInt64Coin("BTC", 150),
+		Price:   NewInt64Price("BTC", 150),
 	}
 	lo3 := LimitOrder{
 		OrderID: 3,
 		Kind:    BuyOrder,
 		Amount:  sdk.NewInt64Coin("ETH", 200),
-		Price:   sdk.NewInt64Coin("BTC", 100),
+		Price:   NewInt64Price("BTC", 100),
 	}
 
 	orderBook1.Orders = []LimitOrder{lo1, lo2, lo3}
@@ -54,7 +54,7 @@ func TestAddLimitOrderToBuyOrderBook(t *testing.T) {
 		OrderID: 4,
 		Kind:    BuyOrder,
 		Amount:  sdk.NewInt64Coin("ETH", 60),
-		Price:   sdk.NewInt64Coin("BTC", 150),
+		Price:   NewInt64Price("BTC", 150),
 	}
 
 	err := orderBook1.AddLimitOrder(lo4)
@@ -74,7 +74,7 @@ func TestAddLimitOrderToEmptySellOrderBook(t *testing.T) {
 		OrderID: 4,
 		Kind:    SellOrder,
 		Amount:  sdk.NewInt64Coin("ETH", 60),
-		Price:   sdk.NewInt64Coin("BTC", 150),
+		Price:   NewInt64Price("BTC", 150),
 	}
 
 	err := orderBook1.AddLimitOrder(lo)
@@ -91,19 +91,19 @@ func TestAddLimitOrderToSellOrderBook(t *testing.T) {
 		OrderID: 1,
 		Kind:    SellOrder,
 		Amount:  sdk.NewInt64Coin("ETH", 80),
-		Price:   sdk.NewInt64Coin("BTC", 90),
+		Price:   NewInt64Price("BTC", 90),
 	}
 	lo2 := LimitOrder{
 		OrderID: 2,
 		Kind:    SellOrder,
 		Amount:  sdk.NewInt64Coin("ETH", 20),
-		Price:   sdk.NewInt64Coin("BTC", 150),
+		Price:   NewInt64Price("BTC", 150),
 	}
 	lo3 := LimitOrder{
 		OrderID: 3,
 		Kind:    SellOrder,
 		Amount:  sdk.NewInt64Coin("ETH", 200),
-		Price:   sdk.NewInt64Coin("BTC", 151),
+		Price:   NewInt64Price("BTC", 151),
 	}
 
 	orderBook1.Orders = []LimitOrder{lo1, lo2, lo3}
@@ -112,7 +112,7 @@ func TestAddLimitOrderToSellOrderBook(t *testing.T) {
 		OrderID: 4,
 		Kind:    SellOrder,
 		Amount:  sdk.NewInt64Coin("ETH", 60),
-		Price:   sdk.NewInt64Coin("BTC", 150),
+		Price:   NewInt64Price("BTC", 150),
 	}
 
 	err := orderBook1.AddLimitOrder(lo4)
@@ -132,14 +132,14 @@ func TestRemoveFilledLimitOrders(t *testing.T) {
 		OrderID:   4,
 		Kind:      BuyOrder,
 		Amount:    sdk.NewInt64Coin("ETH", 0),
-		Price:     sdk.NewInt64Coin("BTC", 150),
+		Price:     NewInt64Price("BTC", 150),
 		ExpiresAt: time.Now().Add(time.Minute),
 	}
 	lo2 := LimitOrder{
 		OrderID:   5,
 		Kind:      BuyOrder,
 		Amount:    sdk.NewInt64Coin("ETH", 30),
-		Price:     sdk.NewInt64Coin("BTC", 130),
+		Price:     NewInt64Price("BTC", 130),
 		ExpiresAt: time.Now().Add(time.Minute),
 	}
 
@@ -156,3 +156,37 @@ func TestRemoveFilledLimitOrders(t *testing.T) {
 	require.Len(t, orderBook1.Orders, 1)
 	require.Equal(t, lo2, orderBook1.Orders[0])
 }
+
+func TestRemoveLimitOrder(t *testing.T) {
+	orderBook1 := NewOrderBook(SellOrder, "ETH", "BTC")
+
+	lo1 := LimitOrder{
+		OrderID: 1,
+		Kind:    SellOrder,
+		Amount:  sdk.NewInt64Coin("ETH", 80),
+		Price:   NewInt64Price("BTC", 90),
+	}
+	lo2 := LimitOrder{
+		OrderID: 2,
+		Kind:    SellOrder,
+		Amount:  sdk.NewInt64Coin("ETH", 20),
+		Price:   NewInt64Price("BTC", 150),
+	}
+	lo3 := LimitOrder{
+		OrderID: 3,
+		Kind:    SellOrder,
+		Amount:  sdk.NewInt64Coin("ETH", 200),
+		Price:   NewInt64Price("BTC", 151),
+	}
+
+	orderBook1.Orders = []LimitOrder{lo1, lo2, lo3}
+
+	orderBook1.RemoveLimitOrder(1)
+	require.Len(t, orderBook1.Orders, 2)
+	require.Equal(t, lo1, orderBook1.Orders[0])
+	require.Equal(t, lo3, orderBook1.Orders[1])
+
+	orderBook1.RemoveLimitOrder(1)
+	require.Len(t, orderBook1.Orders, 1)
+	require.Equal(t, lo1, orderBook1.Orders[0])
+}
@@ -0,0 +1,87 @@
+package exchange
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// IsCanonicalPair checks if the denoms are in the direction of the order books: if rune is involved, it always is the
+// price denom, otherwise the amount denom sorts after the price denom
+func IsCanonicalPair(amountDenom string, priceDenom string) bool {
+	return amountDenom != "RUNE" && (priceDenom == "RUNE" || amountDenom >= priceDenom)
+}
+
+// NormalizeOrder converts an order whose denoms are the other way round than the order book into the equivalent
+// order of the order book. Buying an amount at a price is selling the total price at the inverse price and vice
+// versa, so the converted order spends and receives the same coins at its limit price. Orders whose total price is
+// not a whole number cannot be converted exactly and are rejected. Returns whether the order was inverted
+func NormalizeOrder(kind OrderKind, amount sdk.Coin, price Price) (OrderKind, sdk.Coin, Price, bool, sdk.Error) {
+	if IsCanonicalPair(amount.Denom, price.Denom) {
+		return kind, amount, price, false, nil
+	}
+
+	total := sdk.NewRatFromInt(amount.Amount).Mul(price.Amount)
+	if !total.Rat.IsInt() {
+		return kind, amount, price, false, ErrInexactInversion(DefaultCodespace, amount, price)
+	}
+
+	invertedKind := SellOrder
+	if kind == SellOrder {
+		invertedKind = BuyOrder
+	}
+
+	return invertedKind, sdk.Coin{price.Denom, total.Num()}, invertPrice(amount.Denom, price), true, nil
+}
+
+// NormalizeDisplayAmount converts the display amount of an iceberg order like NormalizeOrder converts its amount. The
+// slices of an inverted order must be whole numbers in the amount denom of the order book as well
+func NormalizeDisplayAmount(displayAmount sdk.Int, amount sdk.Coin, price Price) (sdk.Int, sdk.Error) {
+	if IsCanonicalPair(amount.Denom, price.Denom) {
+		return displayAmount, nil
+	}
+
+	total := sdk.NewRatFromInt(displayAmount).Mul(price.Amount)
+	if !total.Rat.IsInt() {
+		return displayAmount, ErrInexactInversion(DefaultCodespace, sdk.Coin{amount.Denom, displayAmount}, price)
+	}
+
+	return total.Num(), nil
+}
+
+// SnapInvertedOrder fits a converted order and its display amount to the tick and lot size of the market. The inverse
+// of a price rarely is a multiple of the tick size, so the price is rounded to the tick size in favour of the sender,
+// i. e. up for sell orders and down for buy orders, and the amounts are rounded down to the lot size, so that the
+// order never spends more than the order of the sender. Orders that are too small to fit the market are rejected, they
+// have to be placed in the direction of the order book
+func SnapInvertedOrder(kind OrderKind, amount sdk.Coin, price Price, displayAmount sdk.Int, market Market) (sdk.Coin,
+	Price, sdk.Int, sdk.Error) {
+	ticks := price.Amount.Quo(market.TickSize)
+	snappedTicks := ticks.Num().Div(ticks.Denom())
+	if kind == SellOrder {
+		snappedTicks = roundUp(ticks)
+	}
+	snappedPrice := NewPrice(price.Denom, sdk.NewRatFromInt(snappedTicks).Mul(market.TickSize))
+	snappedAmount := sdk.Coin{amount.Denom, amount.Amount.Div(market.LotSize).Mul(market.LotSize)}
+	snappedDisplayAmount := displayAmount.Div(market.LotSize).Mul(market.LotSize)
+
+	if !snappedPrice.IsPositive() || snappedAmount.IsZero() ||
+		(displayAmount.Sign() > 0 && snappedDisplayAmount.IsZero()) {
+		return amount, price, displayAmount, ErrInvalidInversion(DefaultCodespace, market.AmountDenom,
+			market.PriceDenom)
+	}
+
+	return snappedAmount, snappedPrice, snappedDisplayAmount, nil
+}
+
+// invertPrice returns the price of one unit of the price denom, given in the amount denom
+func invertPrice(amountDenom string, price Price) Price {
+	return NewPrice(amountDenom, sdk.OneRat().Quo(price.Amount))
+}
+
+// invertFilledLimitOrder converts a fill of the order book into the terms of an inverted order, i. e. the filled
+// amount is given in the price denom of the order book, the price in its amount denom
+func invertFilledLimitOrder(filled FilledLimitOrder) FilledLimitOrder {
+	inverted := filled
+	inverted.FilledAmount = getTotalPrice(filled.FilledAmount, filled.FilledPrice)
+	inverted.FilledPrice = invertPrice(filled.FilledAmount.Denom, filled.FilledPrice)
+	return inverted
+}
@@ -0,0 +1,118 @@
+package exchange
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+func TestNormalizeOrder(t *testing.T) {
+	// orders in the direction of the order book stay as they are
+	kind, amount, price, inverted, err := NormalizeOrder(BuyOrder, sdk.NewInt64Coin("ETH", 10),
+		NewInt64Price("RUNE", 4))
+	require.Nil(t, err)
+	require.False(t, inverted)
+	require.Equal(t, BuyOrder, kind)
+	require.Equal(t, sdk.NewInt64Coin("ETH", 10), amount)
+	require.Equal(t, NewInt64Price("RUNE", 4), price)
+
+	// buying rune for eth is selling eth for rune
+	kind, amount, price, inverted, err = NormalizeOrder(BuyOrder, sdk.NewInt64Coin("RUNE", 100),
+		NewPrice("ETH", sdk.NewRat(1, 4)))
+	require.Nil(t, err)
+	require.True(t, inverted)
+	require.Equal(t, SellOrder, kind)
+	require.Equal(t, sdk.NewInt64Coin("ETH", 25), amount)
+	require.Equal(t, NewInt64Price("RUNE", 4), price)
+
+	// denoms without rune are sorted
+	kind, amount, price, inverted, err = NormalizeOrder(SellOrder, sdk.NewInt64Coin("BTC", 3),
+		NewInt64Price("ETH", 20))
+	require.Nil(t, err)
+	require.True(t, inverted)
+	require.Equal(t, BuyOrder, kind)
+	require.Equal(t, sdk.NewInt64Coin("ETH", 60), amount)
+	require.Equal(t, NewPrice("BTC", sdk.NewRat(1, 20)), price)
+
+	_, _, _, _, err = NormalizeOrder(BuyOrder, sdk.NewInt64Coin("RUNE", 10), NewPrice("ETH", sdk.NewRat(1, 3)))
+	require.Equal(t, CodeInexactInversion, err.Code())
+}
+
+func TestHandleInvertedLimitOrder(t *testing.T) {
+	ctx := setupContext(exchangeKey)
+	keeper, _, bankKeeper, buyer, seller := setupKeepers(exchangeKey, ctx)
+	bankKeeper.SetCoins(ctx, buyer, sdk.Coins{sdk.NewInt64Coin("RUNE", 1000)})
+	bankKeeper.SetCoins(ctx, seller, sdk.Coins{sdk.NewInt64Coin("ETH", 100)})
+	expiresAt := time.Now().Add(time.Minute).UTC()
+	handler := NewHandler(keeper)
+
+	res := handler(ctx, NewMsgCreateLimitOrder(buyer, BuyOrder, sdk.NewInt64Coin("ETH", 50), NewInt64Price("RUNE", 5),
+		expiresAt, 0, GoodTillTime, MarketDefaultSTP, sdk.ZeroInt(), "", ""))
+	require.True(t, res.IsOK(), res.Log)
+
+	// selling rune for eth at 1/4ETH is buying eth at 4RUNE, which fills the buy order at 5RUNE
+	msg := NewMsgCreateLimitOrder(seller, BuyOrder, sdk.NewInt64Coin("RUNE", 120), NewPrice("ETH", sdk.NewRat(1, 4)),
+		expiresAt, 0, GoodTillTime, MarketDefaultSTP, sdk.ZeroInt(), "", "")
+	require.Nil(t, msg.ValidateBasic())
+	res = handler(ctx, msg)
+	require.True(t, res.IsOK(), res.Log)
+	require.Contains(t, res.Log, `"inverted":true`)
+	require.Contains(t, res.Log, `"filled_amt":{"denom":"RUNE","amount":"150"}`)
+
+	require.Equal(t, sdk.Coins{sdk.NewInt64Coin("ETH", 70), sdk.NewInt64Coin("RUNE", 150)},
+		bankKeeper.GetCoins(ctx, seller))
+	// the buyer's open 20ETH keep 100RUNE locked
+	require.Equal(t, sdk.Coins{sdk.NewInt64Coin("ETH", 30), sdk.NewInt64Coin("RUNE", 750)},
+		bankKeeper.GetCoins(ctx, buyer))
+
+	// an order that cannot be converted exactly is rejected
+	msg = NewMsgCreateLimitOrder(seller, BuyOrder, sdk.NewInt64Coin("RUNE", 10), NewPrice("ETH", sdk.NewRat(1, 3)),
+		expiresAt, 0, GoodTillTime, MarketDefaultSTP, sdk.ZeroInt(), "", "")
+	require.Equal(t, CodeInexactInversion, msg.ValidateBasic().Code())
+}
+
+// Test if converted orders are fitted to the tick and lot size of the market in favour of the sender, and rejected if
+// they are too small
+func TestHandleInvertedLimitOrderTickSize(t *testing.T) {
+	ctx := setupContext(exchangeKey)
+	keeper, _, bankKeeper, buyer, seller := setupKeepers(exchangeKey, ctx)
+	bankKeeper.SetCoins(ctx, buyer, sdk.Coins{sdk.NewInt64Coin("RUNE", 1000)})
+	bankKeeper.SetCoins(ctx, seller, sdk.Coins{sdk.NewInt64Coin("ETH", 100)})
+	market := newListedMarket("ETH", "RUNE")
+	market.TickSize = sdk.NewRat(5)
+	market.LotSize = sdk.NewInt(3)
+	keeper.setMarket(ctx, market)
+	handler := NewHandler(keeper)
+
+	// buying rune at 1/7ETH is selling 20ETH at 7RUNE, the price is rounded up to 10RUNE and the amount down to 18ETH
+	res := handler(ctx, NewMsgCreateLimitOrder(seller, BuyOrder, sdk.NewInt64Coin("RUNE", 140),
+		NewPrice("ETH", sdk.NewRat(1, 7)), time.Time{}, 0, GoodTillCancelled, MarketDefaultSTP, sdk.ZeroInt(), "", ""))
+	require.True(t, res.IsOK(), res.Log)
+	sellOrderBook := keeper.getOrderBook(ctx, SellOrder, "ETH", "RUNE")
+	require.Len(t, sellOrderBook.Orders, 1)
+	require.Equal(t, sdk.NewInt64Coin("ETH", 18), sellOrderBook.Orders[0].Amount)
+	require.Equal(t, NewInt64Price("RUNE", 10), sellOrderBook.Orders[0].Price)
+	require.Equal(t, "82ETH", bankKeeper.GetCoins(ctx, seller).String())
+
+	// selling rune at 1/7ETH is buying 20ETH at 7RUNE, the price is rounded down to 5RUNE and the amount to 18ETH
+	res = handler(ctx, NewMsgCreateLimitOrder(buyer, SellOrder, sdk.NewInt64Coin("RUNE", 140),
+		NewPrice("ETH", sdk.NewRat(1, 7)), time.Time{}, 0, GoodTillCancelled, MarketDefaultSTP, sdk.ZeroInt(), "", ""))
+	require.True(t, res.IsOK(), res.Log)
+	buyOrderBook := keeper.getOrderBook(ctx, BuyOrder, "ETH", "RUNE")
+	require.Len(t, buyOrderBook.Orders, 1)
+	require.Equal(t, sdk.NewInt64Coin("ETH", 18), buyOrderBook.Orders[0].Amount)
+	require.Equal(t, NewInt64Price("RUNE", 5), buyOrderBook.Orders[0].Price)
+	require.Equal(t, "910RUNE", bankKeeper.GetCoins(ctx, buyer).String())
+
+	// orders whose price is below the tick size or whose amount is below the lot size once converted are rejected
+	res = handler(ctx, NewMsgCreateLimitOrder(buyer, SellOrder, sdk.NewInt64Coin("RUNE", 30),
+		NewPrice("ETH", sdk.NewRat(1, 3)), time.Time{}, 0, GoodTillCancelled, MarketDefaultSTP, sdk.ZeroInt(), "", ""))
+	require.Equal(t, ErrInvalidInversion(DefaultCodespace, "ETH", "RUNE").Result().Code, res.Code)
+	res = handler(ctx, NewMsgCreateLimitOrder(buyer, SellOrder, sdk.NewInt64Coin("RUNE", 20),
+		NewPrice("ETH", sdk.NewRat(1, 10)), time.Time{}, 0, GoodTillCancelled, MarketDefaultSTP, sdk.ZeroInt(), "", ""))
+	require.Equal(t, ErrInvalidInversion(DefaultCodespace, "ETH", "RUNE").Result().Code, res.Code)
+	require.Equal(t, "910RUNE", bankKeeper.GetCoins(ctx, buyer).String())
+}
@@ -0,0 +1,255 @@
+package exchange
+
+import (
+	"bytes"
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// nolint
+const (
+	MakerFeeRateKey    = "exchange/MakerFeeRate"
+	TakerFeeRateKey    = "exchange/TakerFeeRate"
+	AuthorityKey       = "exchange/Authority"
+	ListingDepositKey  = "exchange/ListingDeposit"
+	MaxExpiriesKey     = "exchange/MaxExpiriesPerBlock"
+	MaxOpenOrdersKey   = "exchange/MaxOpenOrders"
+	MaxMarketOrdersKey = "exchange/MaxOpenOrdersPerMarket"
+	OrderDepositKey    = "exchange/OrderDeposit"
+	MaxTriggersKey     = "exchange/MaxTriggersPerBlock"
+)
+
+// fee rates are given in basis points, i. e. 1/10000 of the proceeds of a fill
+const (
+	feeRateDenominator  = 10000
+	defaultMakerFeeRate = 0
+	defaultTakerFeeRate = 0
+)
+
+// EscrowAddress is the account that holds the coins locked for open orders until they are filled, cancelled or expire
+var EscrowAddress = sdk.AccAddress([]byte("t0exchangeescrow"))
+
+// defaultMaxExpiriesPerBlock is the default maximum number of expired orders that are removed at the start of a block
+const defaultMaxExpiriesPerBlock = 100
+
+// defaultMaxTriggersPerBlock is the default maximum number of triggered trailing stops that are submitted per block
+const defaultMaxTriggersPerBlock = 100
+
+// default maximum numbers of open orders of an account in total and in a single market
+const (
+	defaultMaxOpenOrders          = 200
+	defaultMaxOpenOrdersPerMarket = 50
+)
+
+// defaultOrderDeposit is the default deposit per open order, which is disabled
+var defaultOrderDeposit = sdk.NewInt64Coin("RUNE", 0)
+
+// defaultListingDeposit is the default deposit to list a market without a governance proposal
+var defaultListingDeposit = sdk.NewInt64Coin("RUNE", 1000)
+
+// Params are the exchange parameters that can be set at genesis
+type Params struct {
+	MakerFeeRate int64 `json:"maker_fee_rate"` // fee rate in basis points charged on the proceeds of the stored order
+	TakerFeeRate int64 `json:"taker_fee_rate"` // fee rate in basis points charged on the proceeds of the incoming order
+	// bech32 address of the account that may change the configuration of markets, nobody can if empty
+	Authority string `json:"authority"`
+	// deposit to list a market without a governance proposal, listing by deposit is disabled if zero
+	ListingDeposit sdk.Coin `json:"listing_deposit"`
+	// maximum number of expired orders that are removed at the start of a block, the rest is left for later blocks
+	MaxExpiriesPerBlock int64 `json:"max_expiries_per_block"`
+	// maximum numbers of open orders of an account in total and in a single market, including collected batch orders
+	MaxOpenOrders          int64 `json:"max_open_orders"`
+	MaxOpenOrdersPerMarket int64 `json:"max_open_orders_per_market"`
+	// deposit locked for every order that rests in an order book and refunded when the order is closed, disabled if
+	// zero
+	OrderDeposit sdk.Coin `json:"order_deposit"`
+	// maximum number of triggered trailing stops that are submitted per block, the rest is left for later blocks
+	MaxTriggersPerBlock int64 `json:"max_triggers_per_block"`
+}
+
+// DefaultParams returns the default exchange parameters
+func DefaultParams() Params {
+	return Params{
+		MakerFeeRate:           defaultMakerFeeRate,
+		TakerFeeRate:           defaultTakerFeeRate,
+		ListingDeposit:         defaultListingDeposit,
+		MaxExpiriesPerBlock:    defaultMaxExpiriesPerBlock,
+		MaxOpenOrders:          defaultMaxOpenOrders,
+		MaxOpenOrdersPerMarket: defaultMaxOpenOrdersPerMarket,
+		OrderDeposit:           defaultOrderDeposit,
+		MaxTriggersPerBlock:    defaultMaxTriggersPerBlock,
+	}
+}
+
+// ValidateParams checks that the fee rates are between 0 and 100%, that the authority is a valid address and that
+// the deposits and the maximum numbers of expiries and triggers per block and of open orders are not negative
+func ValidateParams(params Params) error {
+	if params.MakerFeeRate < 0 || params.MakerFeeRate > feeRateDenominator {
+		return fmt.Errorf("maker fee rate must be between 0 and %v, is %v", feeRateDenominator, params.MakerFeeRate)
+	}
+	if params.TakerFeeRate < 0 || params.TakerFeeRate > feeRateDenominator {
+		return fmt.Errorf("taker fee rate must be between 0 and %v, is %v", feeRateDenominator, params.TakerFeeRate)
+	}
+	if params.Authority != "" {
+		_, err := sdk.AccAddressFromBech32(params.Authority)
+		if err != nil {
+			return fmt.Errorf("invalid authority: %v", err)
+		}
+	}
+	if params.ListingDeposit.Amount != (sdk.Int{}) && params.ListingDeposit.Amount.Sign() < 0 {
+		return fmt.Errorf("listing deposit must not be negative, is %v", params.ListingDeposit)
+	}
+	if params.MaxExpiriesPerBlock < 0 {
+		return fmt.Errorf("max expiries per block must not be negative, is %v", params.MaxExpiriesPerBlock)
+	}
+	if params.MaxOpenOrders < 0 || params.MaxOpenOrdersPerMarket < 0 {
+		return fmt.Errorf("max open orders must not be negative, is %v in total and %v per market",
+			params.MaxOpenOrders, params.MaxOpenOrdersPerMarket)
+	}
+	if params.OrderDeposit.Amount != (sdk.Int{}) && params.OrderDeposit.Amount.Sign() < 0 {
+		return fmt.Errorf("order deposit must not be negative, is %v", params.OrderDeposit)
+	}
+	if params.MaxTriggersPerBlock < 0 {
+		return fmt.Errorf("max triggers per block must not be negative, is %v", params.MaxTriggersPerBlock)
+	}
+	return nil
+}
+
+// MakerFeeRate - fee rate in basis points for the stored order of a fill
+func (k Keeper) MakerFeeRate(ctx sdk.Context) int64 {
+	return k.params.GetInt64WithDefault(ctx, MakerFeeRateKey, defaultMakerFeeRate)
+}
+
+// TakerFeeRate - fee rate in basis points for the incoming order of a fill
+func (k Keeper) TakerFeeRate(ctx sdk.Context) int64 {
+	return k.params.GetInt64WithDefault(ctx, TakerFeeRateKey, defaultTakerFeeRate)
+}
+
+// Authority - account that may change the configuration of markets
+func (k Keeper) Authority(ctx sdk.Context) sdk.AccAddress {
+	return sdk.AccAddress(k.params.GetRaw(ctx, AuthorityKey))
+}
+
+// ListingDeposit - deposit to list a market without a governance proposal
+func (k Keeper) ListingDeposit(ctx sdk.Context) sdk.Coin {
+	var deposit sdk.Coin
+	err := k.params.Get(ctx, ListingDepositKey, &deposit)
+	if err != nil {
+		return defaultListingDeposit
+	}
+	return deposit
+}
+
+// MaxExpiriesPerBlock - maximum number of expired orders that are removed at the start of a block
+func (k Keeper) MaxExpiriesPerBlock(ctx sdk.Context) int64 {
+	return k.params.GetInt64WithDefault(ctx, MaxExpiriesKey, defaultMaxExpiriesPerBlock)
+}
+
+// MaxOpenOrders - maximum number of open orders of an account
+func (k Keeper) MaxOpenOrders(ctx sdk.Context) int64 {
+	return k.params.GetInt64WithDefault(ctx, MaxOpenOrdersKey, defaultMaxOpenOrders)
+}
+
+// MaxOpenOrdersPerMarket - maximum number of open orders of an account in a single market
+func (k Keeper) MaxOpenOrdersPerMarket(ctx sdk.Context) int64 {
+	return k.params.GetInt64WithDefault(ctx, MaxMarketOrdersKey, defaultMaxOpenOrdersPerMarket)
+}
+
+// OrderDeposit - deposit locked for every order that rests in an order book
+func (k Keeper) OrderDeposit(ctx sdk.Context) sdk.Coin {
+	var deposit sdk.Coin
+	err := k.params.Get(ctx, OrderDepositKey, &deposit)
+	if err != nil {
+		return defaultOrderDeposit
+	}
+	return deposit
+}
+
+// MaxTriggersPerBlock - maximum number of triggered trailing stops that are submitted per block
+func (k Keeper) MaxTriggersPerBlock(ctx sdk.Context) int64 {
+	return k.params.GetInt64WithDefault(ctx, MaxTriggersKey, defaultMaxTriggersPerBlock)
+}
+
+// isAuthority checks if the given address is the authority, which must be set
+func (k Keeper) isAuthority(ctx sdk.Context, addr sdk.AccAddress) bool {
+	authority := k.Authority(ctx)
+	return len(authority) > 0 && bytes.Equal(authority, addr)
+}
+
+func (k Keeper) getParams(ctx sdk.Context) Params {
+	params := Params{
+		MakerFeeRate:           k.MakerFeeRate(ctx),
+		TakerFeeRate:           k.TakerFeeRate(ctx),
+		ListingDeposit:         k.ListingDeposit(ctx),
+		MaxExpiriesPerBlock:    k.MaxExpiriesPerBlock(ctx),
+		MaxOpenOrders:          k.MaxOpenOrders(ctx),
+		MaxOpenOrdersPerMarket: k.MaxOpenOrdersPerMarket(ctx),
+		OrderDeposit:           k.OrderDeposit(ctx),
+		MaxTriggersPerBlock:    k.MaxTriggersPerBlock(ctx),
+	}
+	if authority := k.Authority(ctx); len(authority) > 0 {
+		params.Authority = authority.String()
+	}
+	return params
+}
+
+// setParams stores the parameters, which must have been validated
+func (k Keeper) setParams(ctx sdk.Context, params Params) {
+	k.params.SetInt64(ctx, MakerFeeRateKey, params.MakerFeeRate)
+	k.params.SetInt64(ctx, TakerFeeRateKey, params.TakerFeeRate)
+	// a missing listing deposit keeps the default
+	if params.ListingDeposit.Amount != (sdk.Int{}) {
+		err := k.params.Set(ctx, ListingDepositKey, params.ListingDeposit)
+		if err != nil {
+			panic(err)
+		}
+	}
+	// a missing maximum number of expiries per block keeps the default
+	if params.MaxExpiriesPerBlock > 0 {
+		k.params.SetInt64(ctx, MaxExpiriesKey, params.MaxExpiriesPerBlock)
+	}
+	// missing maximum numbers of open orders keep the defaults
+	if params.MaxOpenOrders > 0 {
+		k.params.SetInt64(ctx, MaxOpenOrdersKey, params.MaxOpenOrders)
+	}
+	if params.MaxOpenOrdersPerMarket > 0 {
+		k.params.SetInt64(ctx, MaxMarketOrdersKey, params.MaxOpenOrdersPerMarket)
+	}
+	// a missing order deposit keeps the default
+	if params.OrderDeposit.Amount != (sdk.Int{}) {
+		err := k.params.Set(ctx, OrderDepositKey, params.OrderDeposit)
+		if err != nil {
+			panic(err)
+		}
+	}
+	// a missing maximum number of triggers per block keeps the default
+	if params.MaxTriggersPerBlock > 0 {
+		k.params.SetInt64(ctx, MaxTriggersKey, params.MaxTriggersPerBlock)
+	}
+	if params.Authority != "" {
+		authority, err := sdk.AccAddressFromBech32(params.Authority)
+		if err != nil {
+			panic(err)
+		}
+		k.params.SetRaw(ctx, AuthorityKey, authority)
+	}
+}
+
+// getFee returns the fee for the given proceeds and fee rate, rounded down
+func getFee(proceeds sdk.Coin, feeRate int64) sdk.Coin {
+	return sdk.Coin{proceeds.Denom, proceeds.Amount.MulRaw(feeRate).DivRaw(feeRateDenominator)}
+}
+
+// getFeeRates returns the maker and taker fee rates of the market, which are the rates of the exchange parameters
+// unless the market overrides them
+func (k Keeper) getFeeRates(ctx sdk.Context, market Market) (int64, int64) {
+	makerFeeRate, takerFeeRate := market.MakerFeeRate, market.TakerFeeRate
+	if makerFeeRate == noFeeRateOverride {
+		makerFeeRate = k.MakerFeeRate(ctx)
+	}
+	if takerFeeRate == noFeeRateOverride {
+		takerFeeRate = k.TakerFeeRate(ctx)
+	}
+	return makerFeeRate, takerFeeRate
+}
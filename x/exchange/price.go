@@ -0,0 +1,106 @@
+package exchange
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// Price is the price of one unit of the amount denom, given in the price denom. Prices are rational so that a unit
+// can cost less than one unit of the price denom, e. g. 0.25RUNE or 1/3RUNE
+type Price struct {
+	Denom  string  `json:"denom"`
+	Amount sdk.Rat `json:"amount"`
+}
+
+// NewPrice creates a new price
+func NewPrice(denom string, amount sdk.Rat) Price {
+	return Price{
+		Denom:  denom,
+		Amount: amount,
+	}
+}
+
+// NewInt64Price creates a new price with a whole amount
+func NewInt64Price(denom string, amount int64) Price {
+	return NewPrice(denom, sdk.NewRat(amount))
+}
+
+var reDecPrice = regexp.MustCompile(`^([0-9]+(?:[./][0-9]+)?)\s*([[:alpha:]][[:alnum:]]{2,15})$`)
+
+// ParsePrice parses a price with a whole, decimal or fractional amount, e. g. '25RUNE', '0.25RUNE' or '1/3RUNE'
+func ParsePrice(str string) (Price, error) {
+	matches := reDecPrice.FindStringSubmatch(strings.TrimSpace(str))
+	if matches == nil {
+		return Price{}, fmt.Errorf("invalid price expression: %s", str)
+	}
+
+	amount, err := ParseRat(matches[1])
+	if err != nil {
+		return Price{}, err
+	}
+
+	return NewPrice(matches[2], amount), nil
+}
+
+// ParseRat parses a whole, decimal or fractional number, e. g. '25', '0.25' or '1/3'
+func ParseRat(str string) (sdk.Rat, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(str))
+	if !ok {
+		return sdk.Rat{}, fmt.Errorf("invalid number: %s", str)
+	}
+	return sdk.Rat{r}, nil
+}
+
+// String provides a human-readable representation of a price. Amounts that have no exact decimal representation
+// are shown as fraction
+func (p Price) String() string {
+	if p.Amount.Rat == nil {
+		return fmt.Sprintf("0%v", p.Denom)
+	}
+	if p.Amount.Rat.IsInt() {
+		return fmt.Sprintf("%v%v", p.Amount.Num(), p.Denom)
+	}
+
+	decimal := strings.TrimRight(p.Amount.Rat.FloatString(18), "0")
+	if exact, ok := new(big.Rat).SetString(decimal); ok && exact.Cmp(p.Amount.Rat) == 0 {
+		return fmt.Sprintf("%v%v", decimal, p.Denom)
+	}
+	return fmt.Sprintf("%v%v", p.Amount.String(), p.Denom)
+}
+
+// IsPositive checks if the price amount is set and greater than zero
+func (p Price) IsPositive() bool {
+	return p.Amount.Rat != nil && p.Amount.Rat.Sign() > 0
+}
+
+// IsEqual checks if both prices have the same denom and amount
+func (p Price) IsEqual(other Price) bool {
+	return p.Denom == other.Denom && p.Amount.Equal(other.Amount)
+}
+
+// IsGTE checks if the price is greater than or equal to the other price, which must have the same denom
+func (p Price) IsGTE(other Price) bool {
+	return p.Denom == other.Denom && p.Amount.GTE(other.Amount)
+}
+
+// IsMultipleOf checks if the price amount is a whole multiple of the given step
+func (p Price) IsMultipleOf(step sdk.Rat) bool {
+	return p.Amount.Quo(step).Rat.IsInt()
+}
+
+// getTotalPrice returns the amount times the price in the price denom. A total that is not a whole number is rounded
+// up, so a buyer never locks or pays less than its price. The tick and lot sizes of a market ensure that the totals
+// of its orders are whole numbers
+func getTotalPrice(amt sdk.Coin, price Price) sdk.Coin {
+	total := sdk.NewRatFromInt(amt.Amount).Mul(price.Amount)
+	return sdk.Coin{price.Denom, roundUp(total)}
+}
+
+// roundUp returns the smallest whole number greater than or equal to the non-negative rational
+func roundUp(r sdk.Rat) sdk.Int {
+	return r.Num().Add(r.Denom()).Sub(sdk.OneInt()).Div(r.Denom())
+}
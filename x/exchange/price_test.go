@@ -0,0 +1,43 @@
+package exchange
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+func TestParsePrice(t *testing.T) {
+	price, err := ParsePrice("25RUNE")
+	require.Nil(t, err)
+	require.True(t, price.IsEqual(NewInt64Price("RUNE", 25)))
+
+	price, err = ParsePrice("0.25RUNE")
+	require.Nil(t, err)
+	require.True(t, price.IsEqual(NewPrice("RUNE", sdk.NewRat(1, 4))))
+
+	price, err = ParsePrice("1/3RUNE")
+	require.Nil(t, err)
+	require.True(t, price.IsEqual(NewPrice("RUNE", sdk.NewRat(1, 3))))
+
+	_, err = ParsePrice("RUNE")
+	require.NotNil(t, err)
+	_, err = ParsePrice("-1RUNE")
+	require.NotNil(t, err)
+	_, err = ParsePrice("1/0RUNE")
+	require.NotNil(t, err)
+}
+
+func TestPriceString(t *testing.T) {
+	require.Equal(t, "25RUNE", NewInt64Price("RUNE", 25).String())
+	require.Equal(t, "0.25RUNE", NewPrice("RUNE", sdk.NewRat(1, 4)).String())
+	require.Equal(t, "1/3RUNE", NewPrice("RUNE", sdk.NewRat(1, 3)).String())
+}
+
+func TestGetTotalPrice(t *testing.T) {
+	require.Equal(t, "30RUNE", getTotalPrice(sdk.NewInt64Coin("ETH", 120), NewPrice("RUNE", sdk.NewRat(1, 4))).String())
+
+	// rounded up
+	require.Equal(t, "41RUNE", getTotalPrice(sdk.NewInt64Coin("ETH", 121), NewPrice("RUNE", sdk.NewRat(1, 3))).String())
+}
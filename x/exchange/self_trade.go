@@ -0,0 +1,64 @@
+package exchange
+
+import (
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// SelfTradePrevention defines what happens if an order would match a stored order of the same sender
+type SelfTradePrevention byte
+
+const (
+	// MarketDefaultSTP orders use the self-trade prevention mode of their market
+	MarketDefaultSTP SelfTradePrevention = 0x00
+	// CancelNewest cancels the remaining amount of the newer order, the older order stays untouched
+	CancelNewest SelfTradePrevention = 0x01
+	// CancelOldest cancels the older order, the newer order continues matching
+	CancelOldest SelfTradePrevention = 0x02
+	// CancelBoth cancels the older order and the remaining amount of the newer order
+	CancelBoth SelfTradePrevention = 0x03
+	// DecrementAndCancel decrements both orders by the amount that would have matched, an order that has no amount
+	// left is cancelled
+	DecrementAndCancel SelfTradePrevention = 0x04
+)
+
+func isValidSelfTradePrevention(stp SelfTradePrevention) bool {
+	return stp == MarketDefaultSTP || isValidMarketSelfTradePrevention(stp)
+}
+
+// isValidMarketSelfTradePrevention checks if the mode can be the default of a market, which excludes the market
+// default itself
+func isValidMarketSelfTradePrevention(stp SelfTradePrevention) bool {
+	return stp == CancelNewest || stp == CancelOldest || stp == CancelBoth || stp == DecrementAndCancel
+}
+
+// ParseSelfTradePrevention parses a self-trade prevention mode. An empty string defaults to the mode of the market
+func ParseSelfTradePrevention(str string) (SelfTradePrevention, error) {
+	switch str {
+	case "", "market":
+		return MarketDefaultSTP, nil
+	case "cancel-newest":
+		return CancelNewest, nil
+	case "cancel-oldest":
+		return CancelOldest, nil
+	case "cancel-both":
+		return CancelBoth, nil
+	case "decrement-and-cancel":
+		return DecrementAndCancel, nil
+	default:
+		return 0x05, fmt.Errorf("'%s' is not a valid self-trade prevention mode, use 'market', 'cancel-newest', "+
+			"'cancel-oldest', 'cancel-both' or 'decrement-and-cancel'", str)
+	}
+}
+
+// PreventedSelfTrade is published as an event when two orders of the same sender would have matched. The maker order
+// is the older, the taker order the newer order
+type PreventedSelfTrade struct {
+	Sender            sdk.AccAddress      `json:"sender"`
+	MakerOrderID      int64               `json:"maker_order_id"`
+	TakerOrderID      int64               `json:"taker_order_id"`
+	Mode              SelfTradePrevention `json:"mode"`
+	Amount            sdk.Coin            `json:"amount"` // amount that would have matched
+	CancelledOrderIDs []int64             `json:"cancelled_order_ids"`
+}
@@ -0,0 +1,72 @@
+package exchange
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// Types of the messages of an order book stream. A stream starts with a snapshot, which is followed by updates for
+// every block that changed the order books, the trades or the open orders of the subscribed account. A resync tells
+// the client that the stream lost its connection to the node and may have missed blocks, a new snapshot follows it
+const (
+	StreamSnapshot  = "snapshot"
+	StreamBookDelta = "book"
+	StreamTrades    = "trades"
+	StreamOrders    = "orders"
+	StreamResync    = "resync"
+)
+
+// StreamMessage is sent to the clients of an order book stream. The sequence number increases by one with every
+// message of a stream, a client that misses a sequence number has to request a new snapshot
+type StreamMessage struct {
+	Type        string           `json:"type"`
+	Sequence    int64            `json:"sequence"`
+	BlockHeight int64            `json:"block_height"`
+	Depth       *OrderBookDepth  `json:"depth,omitempty"`  // snapshot only
+	Bids        []PriceLevel     `json:"bids,omitempty"`   // changed levels of a book delta
+	Asks        []PriceLevel     `json:"asks,omitempty"`   // changed levels of a book delta
+	Trades      []Trade          `json:"trades,omitempty"` // new trades of the token pair, oldest first
+	Orders      []OpenLimitOrder `json:"orders,omitempty"` // all open orders of the subscribed account
+}
+
+// StreamRequest is sent by the clients of an order book stream, e. g. to request a new snapshot
+type StreamRequest struct {
+	Type string `json:"type"`
+}
+
+// GetDepthDelta returns the price levels that differ between two depths of the same token pair. A level that is no
+// longer part of the newer depth is returned with a zero amount and order count
+func GetDepthDelta(prev OrderBookDepth, next OrderBookDepth) (bids []PriceLevel, asks []PriceLevel) {
+	return getPriceLevelsDelta(prev.Bids, next.Bids), getPriceLevelsDelta(prev.Asks, next.Asks)
+}
+
+func getPriceLevelsDelta(prev []PriceLevel, next []PriceLevel) []PriceLevel {
+	delta := make([]PriceLevel, 0)
+
+	for _, level := range next {
+		if !containsPriceLevel(prev, level) {
+			delta = append(delta, level)
+		}
+	}
+
+	for _, level := range prev {
+		if findPriceLevel(next, level.Price) < 0 {
+			delta = append(delta, PriceLevel{level.Price, sdk.NewInt64Coin(level.Amount.Denom, 0), 0})
+		}
+	}
+
+	return delta
+}
+
+func findPriceLevel(levels []PriceLevel, price Price) int {
+	for i, level := range levels {
+		if level.Price.IsEqual(price) {
+			return i
+		}
+	}
+	return -1
+}
+
+func containsPriceLevel(levels []PriceLevel, level PriceLevel) bool {
+	i := findPriceLevel(levels, level.Price)
+	return i >= 0 && levels[i].Amount.IsEqual(level.Amount) && levels[i].OrderCount == level.OrderCount
+}
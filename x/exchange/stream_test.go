@@ -0,0 +1,52 @@
+package exchange
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/wire"
+)
+
+func TestGetDepthDelta(t *testing.T) {
+	prev := OrderBookDepth{
+		Bids: []PriceLevel{
+			{NewInt64Price("RUNE", 8), sdk.NewInt64Coin("ETH", 30), 2},
+			{NewInt64Price("RUNE", 7), sdk.NewInt64Coin("ETH", 5), 1},
+		},
+		Asks: []PriceLevel{{NewInt64Price("RUNE", 9), sdk.NewInt64Coin("ETH", 3), 1}},
+	}
+	next := OrderBookDepth{
+		Bids: []PriceLevel{
+			{NewInt64Price("RUNE", 8), sdk.NewInt64Coin("ETH", 20), 1},
+			{NewInt64Price("RUNE", 6), sdk.NewInt64Coin("ETH", 5), 1},
+		},
+		Asks: []PriceLevel{{NewInt64Price("RUNE", 9), sdk.NewInt64Coin("ETH", 3), 1}},
+	}
+
+	bids, asks := GetDepthDelta(prev, next)
+	require.Equal(t, []PriceLevel{
+		{NewInt64Price("RUNE", 8), sdk.NewInt64Coin("ETH", 20), 1},
+		{NewInt64Price("RUNE", 6), sdk.NewInt64Coin("ETH", 5), 1},
+		{NewInt64Price("RUNE", 7), sdk.NewInt64Coin("ETH", 0), 0},
+	}, bids)
+	require.Len(t, asks, 0)
+}
+
+func TestStreamMessageJSON(t *testing.T) {
+	cdc := wire.NewCodec()
+	depth := GetOrderBookDepth(NewOrderBook(BuyOrder, "ETH", "RUNE"), NewOrderBook(SellOrder, "ETH", "RUNE"), 20)
+
+	bz, err := cdc.MarshalJSON(StreamMessage{Type: StreamSnapshot, Sequence: 1, BlockHeight: 5, Depth: &depth})
+	require.Nil(t, err)
+	require.Contains(t, string(bz), `"type":"snapshot"`)
+	require.NotContains(t, string(bz), `"trades"`)
+
+	trade := NewTrade(1, 1, 2, nil, nil, BuyOrder, sdk.NewInt64Coin("ETH", 1), NewInt64Price("RUNE", 9),
+		sdk.NewInt64Coin("RUNE", 0), sdk.NewInt64Coin("ETH", 0), 6, time.Now().UTC())
+	bz, err = cdc.MarshalJSON(StreamMessage{Type: StreamTrades, Sequence: 2, BlockHeight: 6, Trades: []Trade{trade}})
+	require.Nil(t, err)
+	require.NotContains(t, string(bz), `"depth"`)
+}
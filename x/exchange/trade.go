@@ -0,0 +1,105 @@
+package exchange
+
+import (
+	"fmt"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// Trade is a single fill between a stored (maker) order and an incoming (taker) order. Trades are persisted and
+// can be queried per token pair and per account
+type Trade struct {
+	TradeID      int64          `json:"trade_id"`
+	MakerOrderID int64          `json:"maker_order_id"`
+	TakerOrderID int64          `json:"taker_order_id"`
+	Maker        sdk.AccAddress `json:"maker"`
+	Taker        sdk.AccAddress `json:"taker"`
+	TakerKind    OrderKind      `json:"taker_kind"`
+	Amount       sdk.Coin       `json:"amount"`
+	Price        Price          `json:"price"`
+	MakerFee     sdk.Coin       `json:"maker_fee"` // taken from the proceeds of the maker
+	TakerFee     sdk.Coin       `json:"taker_fee"` // taken from the proceeds of the taker
+	BlockHeight  int64          `json:"block_height"`
+	Timestamp    time.Time      `json:"timestamp"`
+	// client order ids of the maker and taker order, empty if not set
+	MakerClientOrderID string `json:"maker_client_order_id,omitempty"`
+	TakerClientOrderID string `json:"taker_client_order_id,omitempty"`
+}
+
+// NewTrade creates a new trade
+func NewTrade(tradeID, makerOrderID, takerOrderID int64, maker, taker sdk.AccAddress, takerKind OrderKind,
+	amount sdk.Coin, price Price, makerFee sdk.Coin, takerFee sdk.Coin, blockHeight int64, timestamp time.Time,
+) Trade {
+	return Trade{
+		TradeID:      tradeID,
+		MakerOrderID: makerOrderID,
+		TakerOrderID: takerOrderID,
+		Maker:        maker,
+		Taker:        taker,
+		TakerKind:    takerKind,
+		Amount:       amount,
+		Price:        price,
+		MakerFee:     makerFee,
+		TakerFee:     takerFee,
+		BlockHeight:  blockHeight,
+		Timestamp:    timestamp,
+	}
+}
+
+// Key for getting the next available tradeID from the store
+var KeyNextTradeID = []byte("nextTradeID")
+
+var tradeSubspace = []byte("trade:")
+
+// Key for getting a specific trade from the store
+func MakeKeyTrade(tradeID int64) []byte {
+	return []byte(fmt.Sprintf("trade:%020d", tradeID))
+}
+
+// Prefix of all trade index entries of a token pair, sorted by trade id
+func MakeKeyTradesByPairSubspace(amountDenom string, priceDenom string) []byte {
+	return []byte(fmt.Sprintf("tradeByPair:%v:%v:", amountDenom, priceDenom))
+}
+
+// Key for the index entry of a trade of a token pair, the value is the key of the trade
+func MakeKeyTradeByPair(amountDenom string, priceDenom string, tradeID int64) []byte {
+	return []byte(fmt.Sprintf("tradeByPair:%v:%v:%020d", amountDenom, priceDenom, tradeID))
+}
+
+// Prefix of all trade index entries of an account, sorted by trade id
+func MakeKeyTradesByAccountSubspace(addr sdk.AccAddress) []byte {
+	return []byte(fmt.Sprintf("tradeByAccount:%v:", addr.String()))
+}
+
+// Key for the index entry of a trade of an account, the value is the key of the trade
+func MakeKeyTradeByAccount(addr sdk.AccAddress, tradeID int64) []byte {
+	return []byte(fmt.Sprintf("tradeByAccount:%v:%020d", addr.String(), tradeID))
+}
+
+// Key for the number of trades in the trade index with the given prefix, e. g. of a token pair or an account
+func MakeKeyTradeCount(indexSubspace []byte) []byte {
+	return []byte(fmt.Sprintf("tradeCount:%s", indexSubspace))
+}
+
+// Key for the trade with the given sequence number (starting at 1) in the trade index with the given prefix. Trades
+// are numbered in the order of their ids, so that clients can read a page or the new trades of an index without
+// reading the whole index. The value is the key of the trade
+func MakeKeyTradeBySeq(indexSubspace []byte, seq int64) []byte {
+	return []byte(fmt.Sprintf("tradeSeq:%s%020d", indexSubspace, seq))
+}
+
+// GetTradePageSeqs returns the sequence numbers of the trades of the requested page (starting at 1) of a trade index
+// with the given number of trades. Pages are counted from the newest trade on
+func GetTradePageSeqs(count int64, page int, limit int) []int64 {
+	seqs := make([]int64, 0)
+	if page < 1 || limit < 1 {
+		return seqs
+	}
+
+	for seq := count - int64(page-1)*int64(limit); seq > 0 && len(seqs) < limit; seq-- {
+		seqs = append(seqs, seq)
+	}
+
+	return seqs
+}
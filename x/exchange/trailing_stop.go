@@ -0,0 +1,209 @@
+package exchange
+
+import (
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// trailing rates are given in basis points, i. e. 1/10000 of the reference price
+const trailingRateDenominator = 10000
+
+// TrailingStop is a stop order whose trigger price follows the best last-trade price of its market by a fixed offset
+// or a rate. A sell stop follows the highest price since it has been created and triggers once the price falls to its
+// trigger price, a buy stop follows the lowest price and triggers once the price rises to its trigger price. A
+// triggered stop is submitted as a limit order at its trigger price moved by the limit offset, or as market order.
+// Sell stops lock their amount, all stops lock the order deposit until they are cancelled or submitted
+type TrailingStop struct {
+	StopID     int64          `json:"stop_id"` // stops share the id sequence of the orders
+	Sender     sdk.AccAddress `json:"sender"`
+	Kind       OrderKind      `json:"kind"`
+	Amount     sdk.Coin       `json:"amount"`
+	PriceDenom string         `json:"price_denom"`
+	// distance of the trigger price from the reference price in the price denom, zero if the rate is used
+	TrailingOffset sdk.Rat `json:"trailing_offset"`
+	// distance of the trigger price from the reference price in basis points, zero if the offset is used
+	TrailingRate int64 `json:"trailing_rate"`
+	// a market stop is submitted as immediate-or-cancel order that fills at the prices of the order book
+	Market bool `json:"market"`
+	// distance of the limit price from the trigger price in the price denom, below it for sells and above it for buys
+	LimitOffset sdk.Rat `json:"limit_offset"`
+	// best last-trade price since the stop has been created and id of the last trade the stop has seen
+	ReferencePrice Price `json:"reference_price"`
+	LastTradeID    int64 `json:"last_trade_id"`
+	// coins locked in the escrow account for the stop
+	Locked sdk.Coins `json:"locked,omitempty"`
+	// a triggered stop waits for its submission in a later block once the maximum triggers per block are reached
+	Triggered bool `json:"triggered,omitempty"`
+}
+
+// NewTrailingStop creates a new trailing stop that references the given last trade
+func NewTrailingStop(stopID int64, sender sdk.AccAddress, kind OrderKind, amount sdk.Coin, priceDenom string,
+	trailingOffset sdk.Rat, trailingRate int64, market bool, limitOffset sdk.Rat, lastTrade Trade) TrailingStop {
+	return TrailingStop{
+		StopID:         stopID,
+		Sender:         sender,
+		Kind:           kind,
+		Amount:         amount,
+		PriceDenom:     priceDenom,
+		TrailingOffset: trailingOffset,
+		TrailingRate:   trailingRate,
+		Market:         market,
+		LimitOffset:    limitOffset,
+		ReferencePrice: lastTrade.Price,
+		LastTradeID:    lastTrade.TradeID,
+	}
+}
+
+// String provides a human-readable representation of a trailing stop
+func (s TrailingStop) String() string {
+	return fmt.Sprintf("TrailingStop{StopID: %v, Sender: %v, Kind: %v, Amount: %v, TriggerPrice: %v, Market: %v}",
+		s.StopID, s.Sender, s.Kind, s.Amount, s.getTriggerPrice(), s.Market)
+}
+
+// checkTrailingStop checks the parameters of a trailing stop that do not depend on the state of the exchange
+func checkTrailingStop(codespace sdk.CodespaceType, kind OrderKind, amount sdk.Coin, priceDenom string,
+	trailingOffset sdk.Rat, trailingRate int64, market bool, limitOffset sdk.Rat) sdk.Error {
+	if kind != BuyOrder && kind != SellOrder {
+		return ErrInvalidKind(codespace)
+	}
+
+	if amount.Denom == priceDenom {
+		return ErrSameDenom(codespace)
+	}
+
+	if !amount.IsPositive() {
+		return ErrAmountNotPositive(codespace)
+	}
+
+	// the offsets are given in the price denom of the order book, so stops cannot be inverted
+	if !IsCanonicalPair(amount.Denom, priceDenom) {
+		return ErrInvalidTrailingStop(codespace, "trailing stops must be in the direction of the order book")
+	}
+
+	if trailingOffset.Rat == nil || limitOffset.Rat == nil {
+		return ErrInvalidTrailingStop(codespace, "trailing and limit offset must be set")
+	}
+
+	hasOffset := trailingOffset.GT(sdk.ZeroRat())
+	hasRate := trailingRate > 0 && trailingRate < trailingRateDenominator
+	if hasOffset == hasRate || trailingOffset.LT(sdk.ZeroRat()) || trailingRate < 0 {
+		return ErrInvalidTrailingStop(codespace, fmt.Sprintf("either a positive trailing offset or a trailing rate "+
+			"between 1 and %v basis points must be set", trailingRateDenominator-1))
+	}
+
+	if limitOffset.LT(sdk.ZeroRat()) || (market && !limitOffset.IsZero()) {
+		return ErrInvalidTrailingStop(codespace, "limit offset must not be negative and must be zero for market stops")
+	}
+
+	return nil
+}
+
+// getTriggerPrice returns the price at which the stop triggers, which trails the reference price by the offset or rate
+func (s TrailingStop) getTriggerPrice() Price {
+	distance := s.TrailingOffset
+	if s.TrailingRate > 0 {
+		distance = s.ReferencePrice.Amount.Mul(sdk.NewRat(s.TrailingRate, trailingRateDenominator))
+	}
+
+	if s.Kind == SellOrder {
+		return NewPrice(s.PriceDenom, s.ReferencePrice.Amount.Sub(distance))
+	}
+	return NewPrice(s.PriceDenom, s.ReferencePrice.Amount.Add(distance))
+}
+
+// update moves the reference price with the price of a new trade if it is better for the stop and returns whether the
+// trade triggers the stop
+func (s *TrailingStop) update(trade Trade) bool {
+	s.LastTradeID = trade.TradeID
+
+	if (s.Kind == SellOrder && trade.Price.Amount.GT(s.ReferencePrice.Amount)) ||
+		(s.Kind == BuyOrder && trade.Price.Amount.LT(s.ReferencePrice.Amount)) {
+		s.ReferencePrice = trade.Price
+	}
+
+	trigger := s.getTriggerPrice()
+	if s.Kind == SellOrder {
+		return trigger.IsGTE(trade.Price)
+	}
+	return trade.Price.IsGTE(trigger)
+}
+
+// getLimitPrice returns the price of the limit order a stop is submitted as, which is the trigger price moved by the
+// limit offset and rounded to the tick size, downwards for sells and upwards for buys
+func (s TrailingStop) getLimitPrice(trigger Price, tickSize sdk.Rat) Price {
+	if s.Kind == SellOrder {
+		ticks := trigger.Amount.Sub(s.LimitOffset).Quo(tickSize)
+		if ticks.LT(sdk.ZeroRat()) {
+			return NewPrice(s.PriceDenom, sdk.ZeroRat())
+		}
+		return NewPrice(s.PriceDenom, sdk.NewRatFromInt(ticks.Num().Div(ticks.Denom())).Mul(tickSize))
+	}
+
+	ticks := trigger.Amount.Add(s.LimitOffset).Quo(tickSize)
+	return NewPrice(s.PriceDenom, sdk.NewRatFromInt(roundUp(ticks)).Mul(tickSize))
+}
+
+// TriggeredTrailingStop is the result of a trailing stop that has been triggered and submitted. A stop whose order
+// fails has the code and log of its error instead
+type TriggeredTrailingStop struct {
+	Stop         TrailingStop         `json:"stop"`
+	TriggerPrice Price                `json:"trigger_price"`
+	Processed    *ProcessedLimitOrder `json:"processed,omitempty"`
+	Filled       []FilledLimitOrder   `json:"filled,omitempty"`
+	Code         sdk.ABCICodeType     `json:"code,omitempty"`
+	Log          string               `json:"log,omitempty"`
+}
+
+var trailingStopSubspace = []byte("trailingStop:")
+
+// Key for getting a trailing stop from the store
+func MakeKeyTrailingStop(stopID int64) []byte {
+	return []byte(fmt.Sprintf("trailingStop:%020d", stopID))
+}
+
+// Prefix of the keys of the trailing stops of a market, sorted by stop id
+func MakeKeyTrailingStopsByMarketSubspace(amountDenom string, priceDenom string) []byte {
+	return []byte(fmt.Sprintf("trailingStopByMarket:%v:%v:", amountDenom, priceDenom))
+}
+
+// Key for the index entry of a trailing stop of a market. The value is the key of the stop
+func MakeKeyTrailingStopByMarket(amountDenom string, priceDenom string, stopID int64) []byte {
+	return []byte(fmt.Sprintf("trailingStopByMarket:%v:%v:%020d", amountDenom, priceDenom, stopID))
+}
+
+// Prefix of the keys of the trailing stops of a sender, sorted by stop id
+func MakeKeyTrailingStopsBySenderSubspace(sender sdk.AccAddress) []byte {
+	return []byte(fmt.Sprintf("trailingStopBySender:%v:", sender.String()))
+}
+
+// Key for the index entry of a trailing stop of a sender. The value is the key of the stop
+func MakeKeyTrailingStopBySender(sender sdk.AccAddress, stopID int64) []byte {
+	return []byte(fmt.Sprintf("trailingStopBySender:%v:%020d", sender.String(), stopID))
+}
+
+// Key for getting all trailing stops from the store
+func MakeKeyTrailingStopsSubspace() []byte {
+	return trailingStopSubspace
+}
+
+var triggeredTrailingStopSubspace = []byte("triggeredTrailingStop:")
+
+// Key for the index entry of a triggered trailing stop that waits for its submission, sorted by stop id. The value is
+// the key of the stop
+func MakeKeyTriggeredTrailingStop(stopID int64) []byte {
+	return []byte(fmt.Sprintf("triggeredTrailingStop:%020d", stopID))
+}
+
+// Key for getting the id of the last trade of a market the trailing stops have been updated with
+func MakeKeyTrailingStopTradeID(amountDenom string, priceDenom string) []byte {
+	return []byte(fmt.Sprintf("trailingStopTradeID:%v:%v", amountDenom, priceDenom))
+}
+
+var tradedMarketSubspace = []byte("tradedMarket:")
+
+// Key for the entry of a market that traded since its trailing stops have been updated last. The value is the amount
+// and the price denom of the market
+func MakeKeyTradedMarket(amountDenom string, priceDenom string) []byte {
+	return []byte(fmt.Sprintf("tradedMarket:%v:%v", amountDenom, priceDenom))
+}
@@ -7,4 +7,14 @@ import (
 //Function to register a codec with this packages concretes/interfaces
 func RegisterWire(cdc *wire.Codec) {
 	cdc.RegisterConcrete(MsgCreateLimitOrder{}, "exchange/MsgCreateLimitOrder", nil)
+	cdc.RegisterConcrete(MsgCancelLimitOrder{}, "exchange/MsgCancelLimitOrder", nil)
+	cdc.RegisterConcrete(MsgReplaceLimitOrder{}, "exchange/MsgReplaceLimitOrder", nil)
+	cdc.RegisterConcrete(MsgSetMarketMode{}, "exchange/MsgSetMarketMode", nil)
+	cdc.RegisterConcrete(MsgSetMarketConfig{}, "exchange/MsgSetMarketConfig", nil)
+	cdc.RegisterConcrete(MsgSetMarketHalt{}, "exchange/MsgSetMarketHalt", nil)
+	cdc.RegisterConcrete(MsgListMarket{}, "exchange/MsgListMarket", nil)
+	cdc.RegisterConcrete(MsgDelistMarket{}, "exchange/MsgDelistMarket", nil)
+	cdc.RegisterConcrete(MsgBatchOrders{}, "exchange/MsgBatchOrders", nil)
+	cdc.RegisterConcrete(MsgCreateTrailingStop{}, "exchange/MsgCreateTrailingStop", nil)
+	cdc.RegisterConcrete(MsgCancelTrailingStop{}, "exchange/MsgCancelTrailingStop", nil)
 }